@@ -3,17 +3,35 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
 	"path/filepath"
 	rt "runtime"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/jaesung9507/playgo/credential"
 	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/chat"
 	"github.com/jaesung9507/playgo/stream/client"
 	"github.com/jaesung9507/playgo/stream/format/fmp4"
+	"github.com/jaesung9507/playgo/stream/format/trace"
+	"github.com/jaesung9507/playgo/stream/onvif"
+	"github.com/jaesung9507/playgo/stream/output"
+	"github.com/jaesung9507/playgo/stream/output/hls"
+	"github.com/jaesung9507/playgo/stream/output/rtsp"
+	"github.com/jaesung9507/playgo/stream/platform"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
+// connStatsInterval is how often the connection statistics of a client that
+// reports them are sent to the UI.
+const connStatsInterval = 2 * time.Second
+
 // App struct
 type App struct {
 	ctx          context.Context
@@ -22,17 +40,298 @@ type App struct {
 	streamCtx    context.Context
 	cancel       context.CancelFunc
 	wg           sync.WaitGroup
+
+	lowLatency    bool
+	latencyTarget time.Duration
+	quality       platform.QualityPreference
+	waitForLive   time.Duration
+	showChat      bool
+	chatLogDir    string
+	chatCancel    context.CancelFunc
+	credentials   *credential.Store
+	captureDir    string
+	recorder      *trace.Recorder
+	outputs       *output.Group
+	logins        map[string]*url.Userinfo
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
-	return &App{}
+	return &App{
+		outputs: output.NewGroup(),
+	}
 }
 
 func (a *App) SetAlwaysOnTop(b bool) {
 	runtime.WindowSetAlwaysOnTop(a.ctx, b)
 }
 
+// SetLowLatency enables tracking of the live edge for streams started
+// afterwards. Packets lagging more than targetMillis behind are skipped up to
+// the latest keyframe.
+func (a *App) SetLowLatency(enabled bool, targetMillis int) {
+	a.lowLatency = enabled
+	a.latencyTarget = time.Duration(targetMillis) * time.Millisecond
+}
+
+// SetQuality sets the preferred quality of platform streams started
+// afterwards: "best", "audio", a maximum height such as "720p" or a maximum
+// bitrate such as "1500k".
+func (a *App) SetQuality(quality string) error {
+	pref, err := platform.ParseQualityPreference(quality)
+	if err != nil {
+		return err
+	}
+	a.quality = pref
+
+	return nil
+}
+
+// SetWaitForLive makes offline live channels be polled every intervalSeconds
+// until the broadcast starts, instead of failing.
+func (a *App) SetWaitForLive(enabled bool, intervalSeconds int) {
+	a.waitForLive = 0
+	if enabled {
+		a.waitForLive = time.Duration(max(intervalSeconds, 5)) * time.Second
+	}
+}
+
+// SetShowChat enables OnChatMessage events for lives started afterwards.
+func (a *App) SetShowChat(enabled bool) {
+	a.showChat = enabled
+}
+
+// SetChatLogDir sets the folder chat logs are written to. Empty disables
+// chat logs.
+func (a *App) SetChatLogDir(dir string) {
+	a.chatLogDir = dir
+}
+
+// SelectChatLogDir asks for the chat log folder and returns it, or an empty
+// string if the dialog was canceled.
+func (a *App) SelectChatLogDir() string {
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                "Chat Log Folder",
+		CanCreateDirectories: true,
+	})
+	if err != nil {
+		a.MsgBox(err.Error())
+		return ""
+	}
+
+	if len(dir) > 0 {
+		a.chatLogDir = dir
+	}
+
+	return dir
+}
+
+// SetCaptureDir sets the folder packet traces of the streams started
+// afterwards are written to. Empty disables capturing.
+func (a *App) SetCaptureDir(dir string) {
+	a.captureDir = dir
+}
+
+// CaptureDir returns the capture folder, which may have been given on the
+// command line.
+func (a *App) CaptureDir() string {
+	return a.captureDir
+}
+
+// SelectCaptureDir asks for the capture folder and returns it, or an empty
+// string if the dialog was canceled.
+func (a *App) SelectCaptureDir() string {
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                "Trace Capture Folder",
+		CanCreateDirectories: true,
+	})
+	if err != nil {
+		a.MsgBox(err.Error())
+		return ""
+	}
+
+	if len(dir) > 0 {
+		a.captureDir = dir
+	}
+
+	return dir
+}
+
+// StartRTSPServer serves the playing stream, and those played afterwards, over
+// RTSP at address and path. It returns the URL readers connect to.
+func (a *App) StartRTSPServer(address, path string) (string, error) {
+	server := rtsp.NewServer(address, path)
+	if err := server.Start(); err != nil {
+		return "", err
+	}
+
+	if err := a.outputs.Add("rtsp", server); err != nil {
+		server.Close()
+		return "", err
+	}
+
+	return server.URL(), nil
+}
+
+func (a *App) StopRTSPServer() {
+	a.outputs.Remove("rtsp")
+}
+
+// StartHLSServer serves the playing stream, and those played afterwards, as
+// a live HLS playlist. address may carry options such as
+// ":8888?variant=fmp4&dvr=5m". It returns the playlist URL.
+func (a *App) StartHLSServer(address string) (string, error) {
+	server, err := hls.NewServer(address)
+	if err != nil {
+		return "", err
+	}
+
+	if err := server.Start(); err != nil {
+		return "", err
+	}
+
+	if err := a.outputs.Add("hls", server); err != nil {
+		server.Close()
+		return "", err
+	}
+
+	return server.URL(), nil
+}
+
+func (a *App) StopHLSServer() {
+	a.outputs.Remove("hls")
+}
+
+// StartPublish pushes the playing stream, and those played afterwards, to an
+// rtmp://, rtmps:// or srt:// target.
+func (a *App) StartPublish(targetURL string) error {
+	publisher, err := client.Publisher(targetURL)
+	if err != nil {
+		return err
+	}
+
+	return a.outputs.Add("publish", publisher)
+}
+
+func (a *App) StopPublish() {
+	a.outputs.Remove("publish")
+}
+
+// SetLogin sets the username and password to play streamURL with until the
+// app quits, after the server asked for them. They are not saved.
+func (a *App) SetLogin(streamURL, username, password string) {
+	if a.logins == nil {
+		a.logins = make(map[string]*url.Userinfo)
+	}
+	a.logins[streamURL] = url.UserPassword(username, password)
+}
+
+func (a *App) withLogin(streamURL string) string {
+	login, ok := a.logins[streamURL]
+	if !ok {
+		return streamURL
+	}
+
+	u, err := url.Parse(streamURL)
+	if err != nil {
+		return streamURL
+	}
+	u.User = login
+
+	return u.String()
+}
+
+// DiscoverCameras probes the local network for ONVIF cameras.
+func (a *App) DiscoverCameras() ([]onvif.Device, error) {
+	ctx, cancel := context.WithTimeout(a.ctx, 3*time.Second)
+	defer cancel()
+
+	return onvif.Discover(ctx, onvif.DiscoveryAddress)
+}
+
+// CameraProfiles lists the media profiles of the ONVIF camera at xaddr. The
+// login is kept for their stream URLs so that they play without asking again.
+func (a *App) CameraProfiles(xaddr, username, password string) ([]onvif.Profile, error) {
+	ctx, cancel := context.WithTimeout(a.ctx, 30*time.Second)
+	defer cancel()
+
+	profiles, err := onvif.NewClient(xaddr, username, password).Profiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(username) > 0 {
+		for _, p := range profiles {
+			a.SetLogin(p.StreamURI, username, password)
+		}
+	}
+
+	return profiles, nil
+}
+
+// ImportCookies asks for a cookies.txt file exported from a signed-in browser
+// and stores its cookies for the platforms they belong to.
+func (a *App) ImportCookies() ([]string, error) {
+	if a.credentials == nil {
+		return nil, errors.New("credential store is not available")
+	}
+
+	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Cookies",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "Cookies (*.txt)",
+				Pattern:     "*.txt",
+			},
+		},
+	})
+	if err != nil || len(filePath) <= 0 {
+		return nil, err
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cookies, err := credential.ParseCookies(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cookies: %w", err)
+	}
+
+	platforms, err := a.credentials.ImportCookies(cookies)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(platforms) <= 0 {
+		a.MsgBox("No cookies for supported platforms were found.")
+	} else {
+		a.MsgBox(fmt.Sprintf("Imported cookies for %s.", strings.Join(platforms, ", ")))
+	}
+
+	return platforms, nil
+}
+
+// SetToken stores the access token of a platform, such as the jwt-token of
+// SBS.
+func (a *App) SetToken(name, token string) error {
+	if a.credentials == nil {
+		return errors.New("credential store is not available")
+	}
+
+	return a.credentials.SetToken(name, strings.TrimSpace(token))
+}
+
+func (a *App) ClearCredentials() error {
+	if a.credentials == nil {
+		return errors.New("credential store is not available")
+	}
+
+	return a.credentials.Clear()
+}
+
 func (a *App) OpenFile() string {
 	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
 		Title: "Open File",
@@ -41,6 +340,10 @@ func (a *App) OpenFile() string {
 				DisplayName: "Videos (*.flv;*.mp4;*.ts;*.h264;*.264;*.h265;*.265;*.hevc)",
 				Pattern:     "*.flv;*.mp4;*.ts;*.h264;*.264;*.h265;*.265;*.hevc",
 			},
+			{
+				DisplayName: "Traces (*.trace)",
+				Pattern:     "*.trace",
+			},
 		},
 	})
 	if err != nil {
@@ -49,11 +352,16 @@ func (a *App) OpenFile() string {
 	}
 
 	if len(filePath) > 0 {
+		scheme := "file"
+		if filepath.Ext(filePath) == ".trace" {
+			scheme = "trace"
+		}
+
 		switch rt.GOOS {
 		case "windows":
-			filePath = "file:///" + filepath.ToSlash(filePath)
+			filePath = scheme + ":///" + filepath.ToSlash(filePath)
 		default:
-			filePath = "file://" + filePath
+			filePath = scheme + "://" + filePath
 		}
 	}
 
@@ -69,6 +377,14 @@ func (a *App) Quit() {
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 
+	if path, err := credential.DefaultPath(); err != nil {
+		log.Printf("[APP] credential store: %v", err)
+	} else if a.credentials, err = credential.Open(path); err != nil {
+		log.Printf("[APP] credential store: %v", err)
+	} else {
+		credential.SetDefault(a.credentials)
+	}
+
 	if screens, _ := runtime.ScreenGetAll(ctx); len(screens) > 0 {
 		primaryScreen := screens[0]
 
@@ -103,6 +419,11 @@ func (a *App) CloseStream() {
 		a.cancel()
 		a.cancel = nil
 	}
+	// A producer blocked on a full queue would otherwise hold up the
+	// goroutines waited for below.
+	if a.streamClient != nil {
+		a.streamClient.PacketQueue().Close()
+	}
 	a.wg.Wait()
 
 	if a.streamClient != nil {
@@ -113,6 +434,104 @@ func (a *App) CloseStream() {
 	if a.mp4Muxer != nil {
 		a.mp4Muxer = nil
 	}
+	a.chatCancel = nil
+	a.stopCapture(nil)
+	a.outputs.WriteTrailer()
+}
+
+// startCapture records the packets of c to a new trace file in the capture
+// folder. Packets pushed while the codecs are being probed are kept too.
+func (a *App) startCapture(c stream.Client) {
+	if len(a.captureDir) <= 0 {
+		return
+	}
+
+	queue := c.PacketQueue()
+	if queue == nil {
+		return
+	}
+
+	path := filepath.Join(a.captureDir, fmt.Sprintf("trace-%s.trace", time.Now().Format("20060102-150405")))
+	recorder, err := trace.NewRecorder(path)
+	if err != nil {
+		log.Printf("[APP] failed to create trace: %v", err)
+		return
+	}
+	log.Printf("[APP] trace capture: %s", path)
+
+	queue.SetTap(recorder.Tap)
+	a.recorder = recorder
+}
+
+// stopCapture ends the trace with the reason the stream stopped.
+func (a *App) stopCapture(reason any) {
+	if a.recorder == nil {
+		return
+	}
+
+	var err error
+	switch reason := reason.(type) {
+	case nil:
+	case error:
+		err = reason
+	default:
+		err = fmt.Errorf("%v", reason)
+	}
+
+	if err := a.recorder.Close(err); err != nil {
+		log.Printf("[APP] failed to write trace: %v", err)
+	}
+	a.recorder = nil
+}
+
+func (a *App) startChat(url string) {
+	if !a.showChat && len(a.chatLogDir) <= 0 {
+		return
+	}
+
+	connector := client.Chat(url)
+	if connector == nil {
+		return
+	}
+
+	var recorder *chat.Recorder
+	if len(a.chatLogDir) > 0 {
+		path := filepath.Join(a.chatLogDir, fmt.Sprintf("chat-%s.jsonl", time.Now().Format("20060102-150405")))
+		r, err := chat.NewRecorder(path)
+		if err != nil {
+			log.Printf("[APP] failed to create chat log: %v", err)
+		} else {
+			log.Printf("[APP] chat log: %s", path)
+			recorder = r
+		}
+	}
+
+	ctx, cancel := context.WithCancel(a.streamCtx)
+	a.chatCancel = cancel
+	showChat := a.showChat
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		if recorder != nil {
+			defer recorder.Close()
+		}
+
+		err := connector.Run(ctx, func(msg chat.Message) {
+			if showChat {
+				runtime.EventsEmit(a.ctx, "OnChatMessage", msg)
+			}
+
+			if recorder != nil {
+				if err := recorder.Write(msg); err != nil {
+					log.Printf("[APP] failed to write chat log: %v", err)
+				}
+			}
+		})
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("[APP] chat: %v", err)
+		}
+	}()
 }
 
 func (a *App) initStream(client stream.Client, muxer *fmp4.Muxer) {
@@ -124,45 +543,111 @@ func (a *App) streamLoop() {
 	defer a.wg.Done()
 	if a.streamClient != nil && a.mp4Muxer != nil {
 		defer runtime.EventsEmit(a.ctx, "OnStreamStop")
+		if cancel := a.chatCancel; cancel != nil {
+			defer cancel()
+		}
+		queue := a.streamClient.PacketQueue()
+		var monitor *stream.LatencyMonitor
+		if a.lowLatency {
+			monitor = stream.NewLatencyMonitor(a.latencyTarget)
+		}
+		defer func() {
+			stats := queue.Stats()
+			log.Printf("[APP] packet queue: policy=%s capacity=%d max depth=%d pushed=%d dropped=%d",
+				stats.Policy, stats.Capacity, stats.MaxDepth, stats.Pushed, stats.Dropped)
+			ts := queue.TimestampStats()
+			log.Printf("[APP] timestamp corrections: rebased=%d non-monotonic=%d negative cts=%d jumps=%d gaps=%d clamped=%d",
+				ts.Rebased, ts.NonMonotonic, ts.NegativeCTS, ts.Jumps, ts.Gaps, ts.Clamped)
+		}()
+		var statsCh <-chan time.Time
+		reporter, ok := a.streamClient.(stream.StatsReporter)
+		if ok {
+			ticker := time.NewTicker(connStatsInterval)
+			defer ticker.Stop()
+			statsCh = ticker.C
+		}
+		packetCh := queue.Chan()
 		for {
 			select {
 			case <-a.streamCtx.Done():
 				return
-			case <-a.streamClient.CloseCh():
+			case <-statsCh:
+				if stats := reporter.ConnStats(); len(stats) > 0 {
+					runtime.EventsEmit(a.ctx, "OnConnStats", stats)
+				}
+			case reason := <-a.streamClient.CloseCh():
+				a.stopCapture(reason)
 				return
-			case packet := <-a.streamClient.PacketQueue():
-				switch rt.GOOS {
-				case "linux":
-					packet.CompositionTime = 0
+			case packet, ok := <-packetCh:
+				if !ok {
+					if buf, _ := a.mp4Muxer.Flush(); buf != nil {
+						runtime.EventsEmit(a.ctx, "OnFrame", buf)
+					}
+					runtime.EventsEmit(a.ctx, "OnStreamEnd")
+					a.outputs.WriteTrailer()
+					packetCh = nil
+					continue
 				}
 
-				if buf, _ := a.mp4Muxer.WritePacket(*packet); buf != nil {
-					runtime.EventsEmit(a.ctx, "OnFrame", buf)
+				packets := []*stream.Packet{packet}
+				if monitor != nil && monitor.IsBehind(packet) {
+					packets = queue.SkipToLatestKeyFrame(packet)
+					a.mp4Muxer.Skip()
+					monitor.Reset()
+					log.Printf("[APP] jump to live edge: target=%v", monitor.Target())
+				}
+
+				for _, packet := range packets {
+					a.outputs.WritePacket(*packet)
+					if buf, _ := a.mp4Muxer.WritePacket(*packet); buf != nil {
+						runtime.EventsEmit(a.ctx, "OnFrame", buf)
+					}
 				}
 			}
 		}
 	}
 }
 
+// ExpandURL returns the entries of a playlist or channel URL, which the UI
+// plays one after another. It returns nil for a single stream.
+func (a *App) ExpandURL(url string) ([]platform.Entry, error) {
+	return client.Expand(a.ctx, url)
+}
+
+// ListChannels returns the on-air channels of the channel guide.
+func (a *App) ListChannels() []platform.Channel {
+	return client.Channels()
+}
+
 func (a *App) PlayStream(url string) (result bool) {
 	a.streamCtx, a.cancel = context.WithCancel(a.ctx)
 
-	c, err := client.Dial(a.streamCtx, url)
+	c, err := client.Dial(a.streamCtx, a.withLogin(url), client.Options{
+		Quality:     a.quality,
+		WaitForLive: a.waitForLive,
+		OnStatus: func(status string) {
+			runtime.EventsEmit(a.ctx, "OnLiveStatus", status)
+		},
+	})
 	if err != nil {
 		if !errors.Is(err, context.Canceled) {
 			a.MsgBox(err.Error())
 		}
 		return false
 	}
+	a.startCapture(c)
 	defer func() {
 		if !result {
 			c.Close()
+			a.stopCapture(nil)
 		}
 	}()
 
 	codecData, err := client.CodecData(a.streamCtx, c)
 	if err != nil {
-		if !errors.Is(err, context.Canceled) {
+		if errors.Is(err, stream.ErrUnauthorized) {
+			runtime.EventsEmit(a.ctx, "OnAuthRequired", url)
+		} else if !errors.Is(err, context.Canceled) {
 			a.MsgBox(err.Error())
 		}
 		return false
@@ -173,7 +658,15 @@ func (a *App) PlayStream(url string) (result bool) {
 		return false
 	}
 
+	if a.recorder != nil {
+		if err := a.recorder.WriteCodecs(codecData); err != nil {
+			log.Printf("[APP] failed to write trace: %v", err)
+			a.stopCapture(nil)
+		}
+	}
+
 	muxer := fmp4.NewMuxer()
+	muxer.SetLowLatency(a.lowLatency)
 	meta, init, err := muxer.WriteHeader(codecData)
 	if err != nil {
 		a.MsgBox(err.Error())
@@ -183,8 +676,35 @@ func (a *App) PlayStream(url string) (result bool) {
 	secured, trusted, secureInfo := c.Secure()
 	runtime.EventsEmit(a.ctx, "OnSecureInfo", secured, trusted, secureInfo)
 
+	if pc, ok := c.(*platform.Client); ok {
+		if media, quality := pc.Media(), pc.Quality(); media != nil && quality != nil {
+			label := quality.Label
+			if quality.Height > 0 {
+				label = fmt.Sprintf("%dp", quality.Height)
+			} else if quality.AudioOnly {
+				label = "audio"
+			}
+			runtime.EventsEmit(a.ctx, "OnMediaInfo", string(media.Kind), media.Title, media.Channel, media.Thumbnail, label)
+		}
+	}
+
+	if t, ok := c.(stream.Transporter); ok {
+		if transport := t.Transport(); len(transport) > 0 {
+			runtime.EventsEmit(a.ctx, "OnTransport", transport)
+		}
+	}
+
+	if skipped := stream.SkippedTracks(c); len(skipped) > 0 {
+		runtime.EventsEmit(a.ctx, "OnSkippedTracks", skipped)
+	}
+
+	if err := a.outputs.WriteHeader(codecData); err != nil {
+		log.Printf("[APP] output: %v", err)
+	}
+
 	a.initStream(c, muxer)
 	runtime.EventsEmit(a.ctx, "OnInit", meta, init)
+	a.startChat(url)
 
 	return true
 }
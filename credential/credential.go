@@ -0,0 +1,239 @@
+package credential
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	Naver   = "naver"
+	YouTube = "youtube"
+	SBS     = "sbs"
+	SOOP    = "soop"
+)
+
+// platformDomains maps cookie domains to the platform they belong to.
+var platformDomains = map[string]string{
+	"naver.com":      Naver,
+	"youtube.com":    YouTube,
+	"google.com":     YouTube,
+	"sbs.co.kr":      SBS,
+	"sooplive.co.kr": SOOP,
+}
+
+// Credential is what a platform needs to act as a signed-in user.
+type Credential struct {
+	Cookies []*http.Cookie `json:"cookies,omitempty"`
+	Token   string         `json:"token,omitempty"`
+}
+
+// Jar returns a cookie jar seeded with the cookies, or nil if there are none.
+func (c Credential) Jar() http.CookieJar {
+	if len(c.Cookies) == 0 {
+		return nil
+	}
+
+	jar, _ := cookiejar.New(nil)
+	byDomain := make(map[string][]*http.Cookie)
+	for _, cookie := range c.Cookies {
+		domain := strings.TrimPrefix(cookie.Domain, ".")
+		byDomain[domain] = append(byDomain[domain], cookie)
+	}
+	for domain, cookies := range byDomain {
+		jar.SetCookies(&url.URL{Scheme: "https", Host: domain}, cookies)
+	}
+
+	return jar
+}
+
+// Store keeps the credentials of each platform in a JSON file that only the
+// current user can read.
+type Store struct {
+	mu    sync.Mutex
+	path  string
+	creds map[string]Credential
+}
+
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "PlayGo", "credentials.json"), nil
+}
+
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:  path,
+		creds: make(map[string]Credential),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &s.creds); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal credentials: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.creds, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.path, data, 0o600)
+}
+
+func (s *Store) Get(platform string) Credential {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.creds[platform]
+}
+
+func (s *Store) Set(platform string, c Credential) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.creds[platform] = c
+	return s.save()
+}
+
+func (s *Store) SetToken(platform, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.creds[platform]
+	c.Token = token
+	s.creds[platform] = c
+	return s.save()
+}
+
+// ImportCookies assigns the cookies to the platforms their domains belong to
+// and returns the platforms that were updated.
+func (s *Store) ImportCookies(cookies []*http.Cookie) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	imported := make(map[string][]*http.Cookie)
+	for _, cookie := range cookies {
+		if platform := platformOf(cookie.Domain); len(platform) > 0 {
+			imported[platform] = append(imported[platform], cookie)
+		}
+	}
+
+	var platforms []string
+	for platform, cookies := range imported {
+		c := s.creds[platform]
+		c.Cookies = cookies
+		s.creds[platform] = c
+		platforms = append(platforms, platform)
+	}
+	slices.Sort(platforms)
+
+	return platforms, s.save()
+}
+
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.creds = make(map[string]Credential)
+	return s.save()
+}
+
+func platformOf(domain string) string {
+	domain = strings.TrimPrefix(domain, ".")
+	for suffix, platform := range platformDomains {
+		if domain == suffix || strings.HasSuffix(domain, "."+suffix) {
+			return platform
+		}
+	}
+
+	return ""
+}
+
+// ParseCookies reads cookies in the Netscape cookies.txt format exported by
+// browser extensions.
+func ParseCookies(r io.Reader) ([]*http.Cookie, error) {
+	var cookies []*http.Cookie
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		httpOnly := false
+		if rest, ok := strings.CutPrefix(text, "#HttpOnly_"); ok {
+			text, httpOnly = rest, true
+		}
+
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) < 7 {
+			return nil, fmt.Errorf("line %d: invalid cookie", line)
+		}
+
+		cookie := &http.Cookie{
+			Domain:   fields[0],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+		if expires, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, cookie)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return cookies, nil
+}
+
+var defaultStore atomic.Pointer[Store]
+
+// SetDefault sets the store used by Lookup.
+func SetDefault(s *Store) {
+	defaultStore.Store(s)
+}
+
+// Lookup returns the credential of a platform from the default store.
+func Lookup(platform string) Credential {
+	if s := defaultStore.Load(); s != nil {
+		return s.Get(platform)
+	}
+
+	return Credential{}
+}
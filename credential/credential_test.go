@@ -0,0 +1,80 @@
+package credential
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCookies(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		input   string
+		want    []*http.Cookie
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			input: "",
+		},
+		{
+			name: "comments and blank lines",
+			input: "# Netscape HTTP Cookie File\n" +
+				"# https://curl.se/docs/http-cookies.html\n" +
+				"\n" +
+				".naver.com\tTRUE\t/\tTRUE\t1893456000\tNID_AUT\tabc\n",
+			want: []*http.Cookie{
+				{Domain: ".naver.com", Path: "/", Secure: true, Name: "NID_AUT", Value: "abc", Expires: time.Unix(1893456000, 0)},
+			},
+		},
+		{
+			name: "http only",
+			input: "#HttpOnly_.youtube.com\tTRUE\t/\tTRUE\t0\tSID\txyz\r\n" +
+				"www.sooplive.co.kr\tFALSE\t/app\tFALSE\t-1\tPdboxTicket\tt=1\n",
+			want: []*http.Cookie{
+				{Domain: ".youtube.com", Path: "/", Secure: true, Name: "SID", Value: "xyz", HttpOnly: true},
+				{Domain: "www.sooplive.co.kr", Path: "/app", Name: "PdboxTicket", Value: "t=1"},
+			},
+		},
+		{
+			name:    "too few fields",
+			input:   ".naver.com\tTRUE\t/\tTRUE\t0\tNID_AUT\n",
+			wantErr: true,
+		},
+		{
+			name:    "spaces instead of tabs",
+			input:   "# comment\n.naver.com TRUE / TRUE 0 NID_AUT abc\n",
+			wantErr: true,
+		},
+		{
+			name:    "malformed http only",
+			input:   "#HttpOnly_.youtube.com\tTRUE\t/\n",
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCookies(strings.NewReader(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d cookies, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				c := got[i]
+				if c.Domain != want.Domain || c.Path != want.Path || c.Secure != want.Secure || c.HttpOnly != want.HttpOnly ||
+					c.Name != want.Name || c.Value != want.Value || !c.Expires.Equal(want.Expires) {
+					t.Errorf("cookie %d = %+v, want %+v", i, c, want)
+				}
+			}
+		})
+	}
+}
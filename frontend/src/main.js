@@ -5,16 +5,40 @@ import EarthIcon from '~icons/mdi/earth';
 import LockIcon from '~icons/mdi/lock';
 import LockOffIcon from '~icons/mdi/lock-off';
 
-import {PlayStream, CloseStream, OpenFile, SetAlwaysOnTop, MsgBox, Quit} from '../wailsjs/go/main/App';
+import {PlayStream, CloseStream, ExpandURL, OpenFile, SetAlwaysOnTop, SetLowLatency, SetQuality, SetWaitForLive, SetShowChat, SetChatLogDir, SelectChatLogDir, SetCaptureDir, SelectCaptureDir, CaptureDir, SetLogin, DiscoverCameras, CameraProfiles, StartRTSPServer, StopRTSPServer, StartHLSServer, StopHLSServer, StartPublish, StopPublish, ListChannels, ImportCookies, SetToken, ClearCredentials, MsgBox, Quit} from '../wailsjs/go/main/App';
 import {EventsOn, EventsEmit} from '../wailsjs/runtime/runtime';
 
 let mediaSource, sourceBuffer;
 let frameQueue = [];
 let isAppending = false;
 let isReconnecting = false;
+let isLowLatency = false;
+let latencyTarget = 1000;
+let quality = "best";
+let playlist = [];
+let playlistIndex = -1;
+let playRequest = 0;
+let playAttempt = 0;
+let isAdvancing = false;
+let isStreamEnded = false;
+let waitForLiveInterval = 30;
 
 const storageKeyURL = "playgo:ui:url";
 const storageKeyAlwaysOnTop = "playgo:setting:alwaysOnTop";
+const storageKeyLowLatency = "playgo:setting:lowLatency";
+const storageKeyLatencyTarget = "playgo:setting:latencyTarget";
+const storageKeyQuality = "playgo:setting:quality";
+const storageKeyShowChat = "playgo:setting:showChat";
+const storageKeyChatLogDir = "playgo:setting:chatLogDir";
+const storageKeyCaptureDir = "playgo:setting:captureDir";
+const storageKeyRTSPServer = "playgo:setting:rtspServer";
+const storageKeyHLSServer = "playgo:setting:hlsServer";
+const storageKeyPublish = "playgo:setting:publish";
+const storageKeyWaitForLive = "playgo:setting:waitForLive";
+const storageKeyWaitForLiveInterval = "playgo:setting:waitForLiveInterval";
+
+const maxChatMessages = 50;
+const qualities = ["best", "1080p", "720p", "480p", "360p", "audio"];
 
 const btnPlayGo = document.getElementById("btnPlayGo");
 const btnReconnect = document.getElementById("btnReconnect");
@@ -25,7 +49,25 @@ const imgPoster = document.getElementById("imgPoster");
 const btnMenu = document.getElementById("btnMenu");
 const dropdownMenu = document.getElementById("dropdownMenu");
 const menuOpenFile = document.getElementById("menuOpenFile");
+const menuChannelGuide = document.getElementById("menuChannelGuide");
+const channelGuide = document.getElementById("channelGuide");
+const menuFindCameras = document.getElementById("menuFindCameras");
+const cameraList = document.getElementById("cameraList");
 const menuAlwaysOnTop = document.getElementById("menuAlwaysOnTop");
+const menuLowLatency = document.getElementById("menuLowLatency");
+const menuShowChat = document.getElementById("menuShowChat");
+const menuChatLog = document.getElementById("menuChatLog");
+const menuCaptureTrace = document.getElementById("menuCaptureTrace");
+const menuRTSPServer = document.getElementById("menuRTSPServer");
+const menuHLSServer = document.getElementById("menuHLSServer");
+const menuPublish = document.getElementById("menuPublish");
+const chatOverlay = document.getElementById("chatOverlay");
+const menuWaitForLive = document.getElementById("menuWaitForLive");
+const menuQuality = document.getElementById("menuQuality");
+const menuImportCookies = document.getElementById("menuImportCookies");
+const menuSBSToken = document.getElementById("menuSBSToken");
+const menuClearCredentials = document.getElementById("menuClearCredentials");
+const labelQuality = document.getElementById("labelQuality");
 const menuQuit = document.getElementById("menuQuit");
 
 function setURLIcon(svg, title = "", color = "") {
@@ -46,11 +88,98 @@ function initialize() {
         menuAlwaysOnTop.classList.add("checked");
     }
 
+    const target = parseInt(localStorage.getItem(storageKeyLatencyTarget), 10);
+    if (target > 0) {
+        latencyTarget = target;
+    }
+
+    if (localStorage.getItem(storageKeyLowLatency) === "true") {
+        setLowLatency(true);
+    }
+
+    const interval = parseInt(localStorage.getItem(storageKeyWaitForLiveInterval), 10);
+    if (interval > 0) {
+        waitForLiveInterval = interval;
+    }
+
+    if (localStorage.getItem(storageKeyWaitForLive) === "true") {
+        setWaitForLive(true);
+    }
+
+    if (localStorage.getItem(storageKeyShowChat) === "true") {
+        setShowChat(true);
+    }
+
+    const chatLogDir = localStorage.getItem(storageKeyChatLogDir);
+    if (chatLogDir) {
+        setChatLogDir(chatLogDir);
+    }
+
+    // A folder given with -capture on the command line takes precedence.
+    CaptureDir().then(dir => {
+        if (dir) {
+            menuCaptureTrace.classList.add("checked");
+            menuCaptureTrace.title = dir;
+        } else {
+            const captureDir = localStorage.getItem(storageKeyCaptureDir);
+            if (captureDir) {
+                setCaptureDir(captureDir);
+            }
+        }
+    });
+
+    const rtspServer = localStorage.getItem(storageKeyRTSPServer);
+    if (rtspServer) {
+        startRTSPServer(rtspServer);
+    }
+
+    const hlsServer = localStorage.getItem(storageKeyHLSServer);
+    if (hlsServer) {
+        startHLSServer(hlsServer);
+    }
+
+    const lastQuality = localStorage.getItem(storageKeyQuality);
+    if (qualities.includes(lastQuality)) {
+        setQuality(lastQuality);
+    }
+
     setURLIcon(EarthIcon);
 }
 
+function setIdle() {
+    btnPlayGo.innerText = "PlayGo";
+    inputURL.disabled = false;
+    menuOpenFile.classList.remove("disabled");
+    menuChannelGuide.classList.remove("disabled");
+    menuFindCameras.classList.remove("disabled");
+}
+
+function playURL(url) {
+    const attempt = ++playAttempt;
+    btnPlayGo.innerText = "Cancel";
+    inputURL.disabled = true;
+    menuOpenFile.classList.add("disabled");
+    menuChannelGuide.classList.add("disabled");
+    menuFindCameras.classList.add("disabled");
+    showChannelGuide(false);
+    showCameraList(false);
+    PlayStream(url).then(ok => {
+        if (!ok && attempt === playAttempt) {
+            setIdle();
+        }
+    });
+}
+
+function playEntry(index) {
+    playlistIndex = index;
+    console.log(`playlist ${index + 1}/${playlist.length}: ${playlist[index].title}`);
+    playURL(playlist[index].url);
+}
+
 function onPlayGo() {
     if (btnPlayGo.innerText !== "PlayGo") {
+        playRequest++;
+        playlist = [];
         CloseStream();
     } else {
         const url = inputURL.value;
@@ -62,12 +191,21 @@ function onPlayGo() {
         btnPlayGo.innerText = "Cancel";
         inputURL.disabled = true;
         menuOpenFile.classList.add("disabled");
-        PlayStream(url).then(ok => {
-            if (!ok) {
-                btnPlayGo.innerText = "PlayGo";
-                inputURL.disabled = false;
-                menuOpenFile.classList.remove("disabled");
+
+        const request = ++playRequest;
+        ExpandURL(url).then(entries => {
+            if (request !== playRequest) {
+                setIdle();
+            } else if (entries && entries.length > 0) {
+                playlist = entries;
+                playEntry(0);
+            } else {
+                playlist = [];
+                playURL(url);
             }
+        }).catch(e => {
+            setIdle();
+            MsgBox(String(e));
         });
     }
 }
@@ -104,6 +242,143 @@ menuOpenFile.addEventListener("click", () => {
     }
 });
 
+function showChannelGuide(show) {
+    channelGuide.classList.toggle("show", show);
+    menuChannelGuide.classList.toggle("checked", show);
+}
+
+menuChannelGuide.addEventListener("click", () => {
+    if (menuChannelGuide.classList.contains("disabled")) {
+        return;
+    }
+
+    if (channelGuide.classList.contains("show")) {
+        showChannelGuide(false);
+        return;
+    }
+
+    ListChannels().then(channels => {
+        const items = [];
+        let network = "";
+        for (const channel of channels || []) {
+            if (channel.network !== network) {
+                network = channel.network;
+                const header = document.createElement("div");
+                header.className = "channel-network";
+                header.textContent = network;
+                items.push(header);
+            }
+
+            const item = document.createElement("a");
+            item.href = "#";
+            item.textContent = channel.name;
+            item.addEventListener("click", () => {
+                if (btnPlayGo.innerText === "PlayGo") {
+                    inputURL.value = channel.url;
+                    onPlayGo();
+                }
+            });
+            items.push(item);
+        }
+        channelGuide.replaceChildren(...items);
+        showCameraList(false);
+        showChannelGuide(true);
+    });
+});
+
+function showCameraList(show) {
+    cameraList.classList.toggle("show", show);
+    menuFindCameras.classList.toggle("checked", show);
+}
+
+function listItem(text, onClick) {
+    const item = document.createElement("a");
+    item.href = "#";
+    item.textContent = text;
+    item.addEventListener("click", onClick);
+    return item;
+}
+
+function listHeader(text) {
+    const header = document.createElement("div");
+    header.className = "channel-network";
+    header.textContent = text;
+    return header;
+}
+
+function hostOf(address) {
+    try {
+        return new URL(address).host;
+    } catch (e) {
+        return address;
+    }
+}
+
+function listCameras(devices) {
+    const items = [listHeader(devices.length > 0 ? "Cameras" : "No cameras found")];
+    for (const device of devices) {
+        const host = hostOf(device.xaddrs[0]);
+        const item = listItem(device.name === host ? host : `${device.name} (${host})`, () => listProfiles(device.name, device.xaddrs[0], devices));
+        item.title = [device.hardware, device.location].filter(Boolean).join("\n");
+        items.push(item);
+    }
+    items.push(listItem("Enter address…", () => {
+        const xaddr = prompt("ONVIF device service address", "http://192.168.0.10/onvif/device_service");
+        if (xaddr) {
+            listProfiles(hostOf(xaddr), xaddr, devices);
+        }
+    }));
+    cameraList.replaceChildren(...items);
+    showCameraList(true);
+}
+
+function listProfiles(name, xaddr, devices) {
+    const username = prompt(`Username for ${name} (empty if none):`, "admin");
+    if (username === null) {
+        return;
+    }
+    const password = username ? prompt(`Password for ${username}@${name}:`) : "";
+    if (password === null) {
+        return;
+    }
+
+    CameraProfiles(xaddr, username, password).then(profiles => {
+        const items = [listHeader(name)];
+        for (const profile of profiles || []) {
+            const details = [profile.encoding, profile.width && `${profile.width}x${profile.height}`].filter(Boolean).join(" ");
+            const item = listItem(details ? `${profile.name} · ${details}` : profile.name, () => {
+                if (btnPlayGo.innerText === "PlayGo") {
+                    inputURL.value = profile.streamUri;
+                    onPlayGo();
+                }
+            });
+            item.title = profile.streamUri;
+            items.push(item);
+        }
+        items.push(listItem("← Cameras", () => listCameras(devices)));
+        cameraList.replaceChildren(...items);
+    }).catch(e => MsgBox(String(e)));
+}
+
+menuFindCameras.addEventListener("click", () => {
+    if (menuFindCameras.classList.contains("disabled")) {
+        return;
+    }
+
+    if (cameraList.classList.contains("show")) {
+        showCameraList(false);
+        return;
+    }
+
+    showChannelGuide(false);
+    cameraList.replaceChildren(listHeader("Searching…"));
+    showCameraList(true);
+    DiscoverCameras().then(devices => listCameras(devices || [])).catch(e => {
+        showCameraList(false);
+        MsgBox(String(e));
+    });
+});
+
 menuAlwaysOnTop.addEventListener("click", () => {
     const isAlwaysOnTop = !menuAlwaysOnTop.classList.contains("checked");
     SetAlwaysOnTop(isAlwaysOnTop);
@@ -111,6 +386,190 @@ menuAlwaysOnTop.addEventListener("click", () => {
     localStorage.setItem(storageKeyAlwaysOnTop, isAlwaysOnTop);
 });
 
+function setLowLatency(enabled) {
+    isLowLatency = enabled;
+    SetLowLatency(enabled, latencyTarget);
+    menuLowLatency.classList.toggle("checked", enabled);
+    localStorage.setItem(storageKeyLowLatency, enabled);
+}
+
+menuLowLatency.addEventListener("click", () => {
+    setLowLatency(!menuLowLatency.classList.contains("checked"));
+});
+
+function setWaitForLive(enabled) {
+    SetWaitForLive(enabled, waitForLiveInterval);
+    menuWaitForLive.classList.toggle("checked", enabled);
+    localStorage.setItem(storageKeyWaitForLive, enabled);
+}
+
+menuWaitForLive.addEventListener("click", () => {
+    setWaitForLive(!menuWaitForLive.classList.contains("checked"));
+});
+
+function setShowChat(enabled) {
+    SetShowChat(enabled);
+    menuShowChat.classList.toggle("checked", enabled);
+    localStorage.setItem(storageKeyShowChat, enabled);
+    if (!enabled) {
+        clearChat();
+    }
+}
+
+menuShowChat.addEventListener("click", () => {
+    setShowChat(!menuShowChat.classList.contains("checked"));
+});
+
+function setChatLogDir(dir) {
+    SetChatLogDir(dir);
+    menuChatLog.classList.toggle("checked", !!dir);
+    menuChatLog.title = dir;
+    if (dir) {
+        localStorage.setItem(storageKeyChatLogDir, dir);
+    } else {
+        localStorage.removeItem(storageKeyChatLogDir);
+    }
+}
+
+menuChatLog.addEventListener("click", () => {
+    if (menuChatLog.classList.contains("checked")) {
+        setChatLogDir("");
+    } else {
+        SelectChatLogDir().then(dir => {
+            if (dir) {
+                setChatLogDir(dir);
+            }
+        });
+    }
+});
+
+function setCaptureDir(dir) {
+    SetCaptureDir(dir);
+    menuCaptureTrace.classList.toggle("checked", !!dir);
+    menuCaptureTrace.title = dir;
+    if (dir) {
+        localStorage.setItem(storageKeyCaptureDir, dir);
+    } else {
+        localStorage.removeItem(storageKeyCaptureDir);
+    }
+}
+
+menuCaptureTrace.addEventListener("click", () => {
+    if (menuCaptureTrace.classList.contains("checked")) {
+        setCaptureDir("");
+    } else {
+        SelectCaptureDir().then(dir => {
+            if (dir) {
+                setCaptureDir(dir);
+            }
+        });
+    }
+});
+
+// address is "[host]:port/path", e.g. ":8554/live".
+function startRTSPServer(address) {
+    const i = address.indexOf("/");
+    const host = i < 0 ? address : address.slice(0, i);
+    const path = i < 0 ? "" : address.slice(i);
+    StartRTSPServer(host, path).then(url => {
+        menuRTSPServer.classList.add("checked");
+        menuRTSPServer.title = url;
+        localStorage.setItem(storageKeyRTSPServer, address);
+    }).catch(e => MsgBox(String(e)));
+}
+
+menuRTSPServer.addEventListener("click", () => {
+    if (menuRTSPServer.classList.contains("checked")) {
+        StopRTSPServer();
+        menuRTSPServer.classList.remove("checked");
+        menuRTSPServer.title = "";
+        localStorage.removeItem(storageKeyRTSPServer);
+    } else {
+        const address = prompt("RTSP server address and path", localStorage.getItem(storageKeyRTSPServer) || ":8554/live");
+        if (address) {
+            startRTSPServer(address);
+        }
+    }
+});
+
+// address is "[host]:port" with optional options, e.g. ":8888?variant=fmp4&dvr=5m".
+function startHLSServer(address) {
+    StartHLSServer(address).then(url => {
+        menuHLSServer.classList.add("checked");
+        menuHLSServer.title = url;
+        localStorage.setItem(storageKeyHLSServer, address);
+    }).catch(e => MsgBox(String(e)));
+}
+
+menuHLSServer.addEventListener("click", () => {
+    if (menuHLSServer.classList.contains("checked")) {
+        StopHLSServer();
+        menuHLSServer.classList.remove("checked");
+        menuHLSServer.title = "";
+        localStorage.removeItem(storageKeyHLSServer);
+    } else {
+        const address = prompt("HLS server address and options", localStorage.getItem(storageKeyHLSServer) || ":8888?variant=llhls&dvr=1m");
+        if (address) {
+            startHLSServer(address);
+        }
+    }
+});
+
+// The target is not restored on start, so that a stream is never pushed
+// without being asked for.
+menuPublish.addEventListener("click", () => {
+    if (menuPublish.classList.contains("checked")) {
+        StopPublish();
+        menuPublish.classList.remove("checked");
+        menuPublish.title = "";
+    } else {
+        const target = prompt("Publish to rtmp://, rtmps:// or srt:// URL", localStorage.getItem(storageKeyPublish) || "");
+        if (target) {
+            StartPublish(target).then(() => {
+                menuPublish.classList.add("checked");
+                menuPublish.title = target;
+                localStorage.setItem(storageKeyPublish, target);
+            }).catch(e => MsgBox(String(e)));
+        }
+    }
+});
+
+menuImportCookies.addEventListener("click", () => {
+    ImportCookies().catch(e => MsgBox(String(e)));
+});
+
+menuSBSToken.addEventListener("click", () => {
+    const token = prompt("SBS jwt-token (empty to remove)");
+    if (token !== null) {
+        SetToken("sbs", token).catch(e => MsgBox(String(e)));
+    }
+});
+
+menuClearCredentials.addEventListener("click", () => {
+    ClearCredentials()
+        .then(() => MsgBox("Credentials cleared."))
+        .catch(e => MsgBox(String(e)));
+});
+
+function clearChat() {
+    chatOverlay.replaceChildren();
+    chatOverlay.classList.remove("show");
+}
+
+function setQuality(value) {
+    SetQuality(value).then(() => {
+        quality = value;
+        labelQuality.innerText = value;
+        menuQuality.classList.toggle("checked", value !== "best");
+        localStorage.setItem(storageKeyQuality, value);
+    }).catch(e => console.error("failed to set quality:", e));
+}
+
+menuQuality.addEventListener("click", (event) => {
+    event.stopPropagation();
+    setQuality(qualities[(qualities.indexOf(quality) + 1) % qualities.length]);
+});
+
 menuQuit.addEventListener("click", Quit);
 
 inputURL.addEventListener("keydown", (event) => {
@@ -147,9 +606,11 @@ function resetVideo() {
     elVideo.currentTime = 0;
     elVideo.load();
 
-    inputURL.disabled = false;
-    menuOpenFile.classList.remove("disabled");
-    btnPlayGo.innerText = "PlayGo";
+    isStreamEnded = false;
+    clearChat();
+    elVideo.removeAttribute("poster");
+    inputURL.title = "";
+    setIdle();
     btnReconnect.disabled = true;
 
     setURLIcon(EarthIcon);
@@ -169,6 +630,52 @@ EventsOn("OnSecureInfo", function (secured, trusted, info) {
     }
 });
 
+EventsOn("OnMediaInfo", function (kind, title, channel, thumbnail, playing) {
+    const position = playlist.length > 0 ? `${playlistIndex + 1}/${playlist.length}` : "";
+    inputURL.title = [title, channel, playing, position].filter(v => v).join("\n");
+    if (thumbnail) {
+        elVideo.poster = thumbnail;
+    }
+});
+
+EventsOn("OnSkippedTracks", function (tracks) {
+    console.warn("OnSkippedTracks", tracks);
+    const skipped = "Skipped unsupported tracks:\n" +
+        tracks.map(t => `Track ${t.index}: ${t.codec}`).join("\n");
+    inputURL.title = [inputURL.title, skipped].filter(v => v).join("\n\n");
+});
+
+EventsOn("OnTransport", function (transport) {
+    inputURL.title = [inputURL.title, `Transport: ${transport}`].filter(v => v).join("\n");
+});
+
+EventsOn("OnConnStats", function (stats) {
+    const connection = "Connection:\n" +
+        Object.entries(stats).map(([k, v]) => `${k}: ${v}`).join("\n");
+    const info = inputURL.title.split("\n\nConnection:\n")[0];
+    inputURL.title = [info, connection].filter(v => v).join("\n\n");
+});
+
+EventsOn("OnAuthRequired", function (url) {
+    let host = url;
+    try {
+        host = new URL(url).host;
+    } catch (e) {
+        console.error("invalid url:", e);
+    }
+
+    const username = prompt(`${host} requires a login.\nUsername:`);
+    if (username === null) {
+        return;
+    }
+    const password = prompt(`Password for ${username}@${host}:`);
+    if (password === null) {
+        return;
+    }
+
+    SetLogin(url, username, password).then(() => playURL(url));
+});
+
 EventsOn("OnInit", function (meta, init) {
     btnPlayGo.innerText = "Stop";
     btnReconnect.disabled = false;
@@ -204,7 +711,57 @@ EventsOn("OnStreamStop", () => {
     resetVideo();
     if (isReconnecting) {
         isReconnecting = false;
-        onPlayGo();
+        if (playlist.length > 0) {
+            playEntry(playlistIndex);
+        } else {
+            onPlayGo();
+        }
+    } else if (isAdvancing) {
+        isAdvancing = false;
+        playEntry(playlistIndex + 1);
+    }
+});
+
+EventsOn("OnLiveStatus", function (status) {
+    console.log("OnLiveStatus", status);
+    inputURL.title = `Waiting for live, last checked at ${new Date().toLocaleTimeString()}\n${status}`;
+});
+
+EventsOn("OnChatMessage", function (msg) {
+    const el = document.createElement("div");
+    el.className = "chat-message";
+    if (msg.donation > 0) {
+        el.classList.add("donation");
+    }
+
+    for (const badge of msg.badges || []) {
+        const img = document.createElement("img");
+        img.src = badge;
+        el.appendChild(img);
+    }
+
+    const nickname = document.createElement("span");
+    nickname.className = "chat-nickname";
+    nickname.textContent = msg.nickname || "anonymous";
+    el.appendChild(nickname);
+    el.appendChild(document.createTextNode(msg.donation > 0 ? `[${msg.donation}] ${msg.message}` : msg.message));
+
+    chatOverlay.appendChild(el);
+    while (chatOverlay.childElementCount > maxChatMessages) {
+        chatOverlay.firstElementChild.remove();
+    }
+    chatOverlay.classList.add("show");
+});
+
+EventsOn("OnStreamEnd", () => {
+    isStreamEnded = true;
+    endOfStream();
+});
+
+elVideo.addEventListener("ended", () => {
+    if (playlistIndex + 1 < playlist.length) {
+        isAdvancing = true;
+        CloseStream();
     }
 });
 
@@ -242,12 +799,38 @@ function appendFrameData(frame) {
     }
 }
 
+function seekToLiveEdge() {
+    const buffered = elVideo.buffered;
+    if (buffered.length === 0) {
+        return;
+    }
+
+    const liveEdge = buffered.end(buffered.length - 1);
+    if (liveEdge - elVideo.currentTime > latencyTarget / 1000) {
+        elVideo.currentTime = Math.max(liveEdge - 0.1, buffered.start(buffered.length - 1));
+    }
+}
+
+function endOfStream() {
+    if (!isStreamEnded || isAppending || frameQueue.length > 0 || !sourceBuffer || sourceBuffer.updating) {
+        return;
+    }
+
+    if (mediaSource && mediaSource.readyState === "open") {
+        mediaSource.endOfStream();
+    }
+}
+
 function onUpdateEnd() {
     isAppending = false;
     if (elVideo.paused) {
         elVideo.play().catch(e => console.warn("failed to resume play:", e));
     }
+    if (isLowLatency) {
+        seekToLiveEdge();
+    }
     appendNextFrame();
+    endOfStream();
 }
 
 initialize();
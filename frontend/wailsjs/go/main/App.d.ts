@@ -1,8 +1,24 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {onvif} from '../models';
+import {platform} from '../models';
+
+export function CameraProfiles(arg1:string,arg2:string,arg3:string):Promise<Array<onvif.Profile>>;
+
+export function CaptureDir():Promise<string>;
+
+export function ClearCredentials():Promise<void>;
 
 export function CloseStream():Promise<void>;
 
+export function DiscoverCameras():Promise<Array<onvif.Device>>;
+
+export function ExpandURL(arg1:string):Promise<Array<platform.Entry>>;
+
+export function ImportCookies():Promise<Array<string>>;
+
+export function ListChannels():Promise<Array<platform.Channel>>;
+
 export function MsgBox(arg1:string):Promise<void>;
 
 export function OpenFile():Promise<string>;
@@ -11,4 +27,36 @@ export function PlayStream(arg1:string):Promise<boolean>;
 
 export function Quit():Promise<void>;
 
+export function SelectCaptureDir():Promise<string>;
+
+export function SelectChatLogDir():Promise<string>;
+
 export function SetAlwaysOnTop(arg1:boolean):Promise<void>;
+
+export function SetCaptureDir(arg1:string):Promise<void>;
+
+export function SetChatLogDir(arg1:string):Promise<void>;
+
+export function SetLogin(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function SetLowLatency(arg1:boolean,arg2:number):Promise<void>;
+
+export function SetQuality(arg1:string):Promise<void>;
+
+export function SetShowChat(arg1:boolean):Promise<void>;
+
+export function SetToken(arg1:string,arg2:string):Promise<void>;
+
+export function SetWaitForLive(arg1:boolean,arg2:number):Promise<void>;
+
+export function StartHLSServer(arg1:string):Promise<string>;
+
+export function StartPublish(arg1:string):Promise<void>;
+
+export function StartRTSPServer(arg1:string,arg2:string):Promise<string>;
+
+export function StopHLSServer():Promise<void>;
+
+export function StopPublish():Promise<void>;
+
+export function StopRTSPServer():Promise<void>;
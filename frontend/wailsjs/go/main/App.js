@@ -2,10 +2,38 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function CameraProfiles(arg1, arg2, arg3) {
+  return window['go']['main']['App']['CameraProfiles'](arg1, arg2, arg3);
+}
+
+export function CaptureDir() {
+  return window['go']['main']['App']['CaptureDir']();
+}
+
+export function ClearCredentials() {
+  return window['go']['main']['App']['ClearCredentials']();
+}
+
 export function CloseStream() {
   return window['go']['main']['App']['CloseStream']();
 }
 
+export function DiscoverCameras() {
+  return window['go']['main']['App']['DiscoverCameras']();
+}
+
+export function ExpandURL(arg1) {
+  return window['go']['main']['App']['ExpandURL'](arg1);
+}
+
+export function ImportCookies() {
+  return window['go']['main']['App']['ImportCookies']();
+}
+
+export function ListChannels() {
+  return window['go']['main']['App']['ListChannels']();
+}
+
 export function MsgBox(arg1) {
   return window['go']['main']['App']['MsgBox'](arg1);
 }
@@ -22,6 +50,70 @@ export function Quit() {
   return window['go']['main']['App']['Quit']();
 }
 
+export function SelectCaptureDir() {
+  return window['go']['main']['App']['SelectCaptureDir']();
+}
+
+export function SelectChatLogDir() {
+  return window['go']['main']['App']['SelectChatLogDir']();
+}
+
 export function SetAlwaysOnTop(arg1) {
   return window['go']['main']['App']['SetAlwaysOnTop'](arg1);
 }
+
+export function SetCaptureDir(arg1) {
+  return window['go']['main']['App']['SetCaptureDir'](arg1);
+}
+
+export function SetChatLogDir(arg1) {
+  return window['go']['main']['App']['SetChatLogDir'](arg1);
+}
+
+export function SetLogin(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetLogin'](arg1, arg2, arg3);
+}
+
+export function SetLowLatency(arg1, arg2) {
+  return window['go']['main']['App']['SetLowLatency'](arg1, arg2);
+}
+
+export function SetQuality(arg1) {
+  return window['go']['main']['App']['SetQuality'](arg1);
+}
+
+export function SetShowChat(arg1) {
+  return window['go']['main']['App']['SetShowChat'](arg1);
+}
+
+export function SetToken(arg1, arg2) {
+  return window['go']['main']['App']['SetToken'](arg1, arg2);
+}
+
+export function SetWaitForLive(arg1, arg2) {
+  return window['go']['main']['App']['SetWaitForLive'](arg1, arg2);
+}
+
+export function StartHLSServer(arg1) {
+  return window['go']['main']['App']['StartHLSServer'](arg1);
+}
+
+export function StartPublish(arg1) {
+  return window['go']['main']['App']['StartPublish'](arg1);
+}
+
+export function StartRTSPServer(arg1, arg2) {
+  return window['go']['main']['App']['StartRTSPServer'](arg1, arg2);
+}
+
+export function StopHLSServer() {
+  return window['go']['main']['App']['StopHLSServer']();
+}
+
+export function StopPublish() {
+  return window['go']['main']['App']['StopPublish']();
+}
+
+export function StopRTSPServer() {
+  return window['go']['main']['App']['StopRTSPServer']();
+}
@@ -0,0 +1,88 @@
+export namespace onvif {
+	
+	export class Device {
+	    id: string;
+	    name: string;
+	    hardware: string;
+	    location: string;
+	    xaddrs: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Device(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.hardware = source["hardware"];
+	        this.location = source["location"];
+	        this.xaddrs = source["xaddrs"];
+	    }
+	}
+	export class Profile {
+	    token: string;
+	    name: string;
+	    encoding: string;
+	    width: number;
+	    height: number;
+	    frameRate: number;
+	    streamUri: string;
+	    snapshotUri: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Profile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.token = source["token"];
+	        this.name = source["name"];
+	        this.encoding = source["encoding"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.frameRate = source["frameRate"];
+	        this.streamUri = source["streamUri"];
+	        this.snapshotUri = source["snapshotUri"];
+	    }
+	}
+
+}
+
+export namespace platform {
+	
+	export class Channel {
+	    network: string;
+	    name: string;
+	    url: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Channel(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.network = source["network"];
+	        this.name = source["name"];
+	        this.url = source["url"];
+	    }
+	}
+	export class Entry {
+	    title: string;
+	    url: string;
+	    duration: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Entry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.url = source["url"];
+	        this.duration = source["duration"];
+	    }
+	}
+
+}
+
@@ -10,6 +10,7 @@ require (
 	github.com/datarhei/gosrt v0.10.0
 	github.com/deepch/vdk v0.0.20
 	github.com/dop251/goja v0.0.0-20250125213203-5ef83b82af17
+	github.com/gorilla/websocket v1.5.3
 	github.com/jaesung9507/nvver v1.3.2
 	github.com/kkdai/youtube/v2 v2.10.5
 	github.com/pion/rtp v1.10.2
@@ -29,7 +30,6 @@ require (
 	github.com/godbus/dbus/v5 v5.2.0 // indirect
 	github.com/google/pprof v0.0.0-20250208200701-d0013a598941 // indirect
 	github.com/google/uuid v1.6.0 // indirect
-	github.com/gorilla/websocket v1.5.3 // indirect
 	github.com/jchv/go-winloader v0.0.0-20250406163304-c1995be93bd1 // indirect
 	github.com/labstack/echo/v4 v4.13.4 // indirect
 	github.com/labstack/gommon v0.4.2 // indirect
@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"flag"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -17,8 +18,12 @@ var assets embed.FS
 var icon []byte
 
 func main() {
+	captureDir := flag.String("capture", "", "write a packet trace of every stream to `dir`")
+	flag.Parse()
+
 	// Create an instance of the app structure
 	app := NewApp()
+	app.captureDir = *captureDir
 
 	// Create application with options
 	err := wails.Run(&options.App{
@@ -0,0 +1,38 @@
+package chat
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Message is a chat message normalized across platforms.
+type Message struct {
+	Platform string    `json:"platform"`
+	Nickname string    `json:"nickname"`
+	Message  string    `json:"message"`
+	Badges   []string  `json:"badges,omitempty"`
+	Donation int       `json:"donation,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Connector receives the chat of a live broadcast.
+type Connector interface {
+	// Run delivers messages to onMessage until ctx is done or the connection
+	// fails.
+	Run(ctx context.Context, onMessage func(Message)) error
+}
+
+// New returns the chat connector for a live URL, or nil if the platform or
+// URL has no supported chat.
+func New(parsedURL *url.URL) Connector {
+	switch parsedURL.Host {
+	case "chzzk.naver.com":
+		if channelID, ok := strings.CutPrefix(parsedURL.Path, "/live/"); ok {
+			return NewChzzk(strings.TrimSuffix(channelID, "/"))
+		}
+	}
+
+	return nil
+}
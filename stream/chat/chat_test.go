@@ -0,0 +1,30 @@
+package chat
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	for _, tt := range []struct {
+		url  string
+		want Connector
+	}{
+		{"https://chzzk.naver.com/live/abc", NewChzzk("abc")},
+		{"https://www.tiktok.com/@user/live", nil},
+		{"https://www.youtube.com/watch?v=1", nil},
+	} {
+		parsedURL, _ := url.Parse(tt.url)
+		got := New(parsedURL)
+		switch want := tt.want.(type) {
+		case nil:
+			if got != nil {
+				t.Errorf("New(%s) = %#v, want nil", tt.url, got)
+			}
+		case *Chzzk:
+			if c, ok := got.(*Chzzk); !ok || *c != *want {
+				t.Errorf("New(%s) = %#v, want %#v", tt.url, got, want)
+			}
+		}
+	}
+}
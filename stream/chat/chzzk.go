@@ -0,0 +1,261 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jaesung9507/playgo/credential"
+	"github.com/jaesung9507/playgo/secure"
+
+	"github.com/gorilla/websocket"
+	"github.com/jaesung9507/nvver/chzzk"
+)
+
+const (
+	chzzkCmdPing      = 0
+	chzzkCmdPong      = 10000
+	chzzkCmdConnect   = 100
+	chzzkCmdConnected = 10100
+	chzzkCmdChat      = 93101
+	chzzkCmdDonation  = 93102
+
+	chzzkPingInterval = 20 * time.Second
+)
+
+// Chzzk connects to the chat of a CHZZK live.
+type Chzzk struct {
+	channelID string
+
+	// ChatChannelID skips the live detail lookup when set.
+	ChatChannelID string
+	// ServerURL and TokenURL override the chat endpoints, e.g. to point at a
+	// local stand-in.
+	ServerURL string
+	TokenURL  string
+}
+
+func NewChzzk(channelID string) *Chzzk {
+	return &Chzzk{channelID: channelID}
+}
+
+type chzzkPacket struct {
+	Ver   string          `json:"ver"`
+	Cmd   int             `json:"cmd"`
+	SvcID string          `json:"svcid,omitempty"`
+	CID   string          `json:"cid,omitempty"`
+	TID   int             `json:"tid,omitempty"`
+	Bdy   json.RawMessage `json:"bdy,omitempty"`
+}
+
+type chzzkChat struct {
+	Profile string `json:"profile"`
+	Msg     string `json:"msg"`
+	Content string `json:"content"`
+	Extras  string `json:"extras"`
+	MsgTime int64  `json:"msgTime"`
+}
+
+type chzzkProfile struct {
+	Nickname string `json:"nickname"`
+	Badge    *struct {
+		ImageURL string `json:"imageUrl"`
+	} `json:"badge"`
+	ActivityBadges []struct {
+		ImageURL string `json:"imageUrl"`
+	} `json:"activityBadges"`
+}
+
+type chzzkExtras struct {
+	PayAmount int `json:"payAmount"`
+}
+
+func (c *Chzzk) serverURL(chatChannelID string) string {
+	if len(c.ServerURL) > 0 {
+		return c.ServerURL
+	}
+
+	sum := 0
+	for _, ch := range chatChannelID {
+		sum += int(ch)
+	}
+
+	return fmt.Sprintf("wss://kr-ss%d.chat.naver.com/chat", sum%9+1)
+}
+
+func (c *Chzzk) accessToken(ctx context.Context, client *chzzk.Client, chatChannelID string) (string, error) {
+	tokenURL := c.TokenURL
+	if len(tokenURL) <= 0 {
+		tokenURL = "https://comm-api.game.naver.com/nng_main/v1/chats/access-token"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s?channelId=%s&chatType=STREAMING", tokenURL, chatChannelID), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to new request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	result := &struct {
+		Code    int `json:"code"`
+		Content struct {
+			AccessToken string `json:"accessToken"`
+		} `json:"content"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return "", fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	if len(result.Content.AccessToken) <= 0 {
+		return "", fmt.Errorf("not found access token: code=%d", result.Code)
+	}
+
+	return result.Content.AccessToken, nil
+}
+
+func (c *Chzzk) Run(ctx context.Context, onMessage func(Message)) error {
+	client := chzzk.NewClient(&http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: (&secure.TLS{}).Config(),
+		},
+		Jar: credential.Lookup(credential.Naver).Jar(),
+	})
+
+	chatChannelID := c.ChatChannelID
+	if len(chatChannelID) <= 0 {
+		liveDetail, err := client.GetLiveDetail(c.channelID)
+		if err != nil {
+			return err
+		}
+		chatChannelID = liveDetail.ChatChannelID
+	}
+
+	if len(chatChannelID) <= 0 {
+		return errors.New("not found chat channel id")
+	}
+
+	token, err := c.accessToken(ctx, client, chatChannelID)
+	if err != nil {
+		return err
+	}
+
+	serverURL := c.serverURL(chatChannelID)
+	log.Printf("[CHAT] chzzk connect: %s", serverURL)
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, serverURL, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var mu sync.Mutex
+	send := func(packet chzzkPacket) error {
+		mu.Lock()
+		defer mu.Unlock()
+		packet.Ver = "2"
+		return conn.WriteJSON(packet)
+	}
+
+	bdy, _ := json.Marshal(map[string]any{
+		"uid":     nil,
+		"devType": 2001,
+		"accTkn":  token,
+		"auth":    "READ",
+	})
+	if err = send(chzzkPacket{Cmd: chzzkCmdConnect, SvcID: "game", CID: chatChannelID, TID: 1, Bdy: bdy}); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(chzzkPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := send(chzzkPacket{Cmd: chzzkCmdPing}); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		var packet chzzkPacket
+		if err = conn.ReadJSON(&packet); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		switch packet.Cmd {
+		case chzzkCmdPing:
+			if err = send(chzzkPacket{Cmd: chzzkCmdPong}); err != nil {
+				return err
+			}
+		case chzzkCmdConnected:
+			log.Print("[CHAT] chzzk connected")
+		case chzzkCmdChat, chzzkCmdDonation:
+			var chats []chzzkChat
+			if err = json.Unmarshal(packet.Bdy, &chats); err != nil {
+				log.Printf("[CHAT] chzzk: failed to unmarshal chat: %v", err)
+				continue
+			}
+
+			for _, chat := range chats {
+				onMessage(chat.toMessage())
+			}
+		}
+	}
+}
+
+func (c *chzzkChat) toMessage() Message {
+	msg := Message{
+		Platform: "chzzk",
+		Message:  c.Msg,
+		Time:     time.UnixMilli(c.MsgTime),
+	}
+	if len(msg.Message) <= 0 {
+		msg.Message = c.Content
+	}
+	if c.MsgTime <= 0 {
+		msg.Time = time.Now()
+	}
+
+	var profile chzzkProfile
+	if err := json.Unmarshal([]byte(c.Profile), &profile); err == nil {
+		msg.Nickname = profile.Nickname
+		if profile.Badge != nil && len(profile.Badge.ImageURL) > 0 {
+			msg.Badges = append(msg.Badges, profile.Badge.ImageURL)
+		}
+		for _, badge := range profile.ActivityBadges {
+			msg.Badges = append(msg.Badges, badge.ImageURL)
+		}
+	}
+
+	var extras chzzkExtras
+	if err := json.Unmarshal([]byte(c.Extras), &extras); err == nil {
+		msg.Donation = extras.PayAmount
+	}
+
+	return msg
+}
@@ -0,0 +1,104 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestChzzkRun(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("channelId") != "chat-channel" {
+			t.Errorf("channelId = %q", r.URL.Query().Get("channelId"))
+		}
+		w.Write([]byte(`{"code":200,"content":{"accessToken":"token"}}`))
+	})
+	mux.HandleFunc("/chat", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var connect chzzkPacket
+		if err := conn.ReadJSON(&connect); err != nil {
+			t.Errorf("read connect: %v", err)
+			return
+		}
+		var bdy struct {
+			AccTkn string `json:"accTkn"`
+		}
+		json.Unmarshal(connect.Bdy, &bdy)
+		if connect.Cmd != chzzkCmdConnect || connect.CID != "chat-channel" || bdy.AccTkn != "token" {
+			t.Errorf("connect = %+v, %+v", connect, bdy)
+		}
+
+		chats, _ := json.Marshal([]chzzkChat{
+			{
+				Profile: `{"nickname":"viewer","badge":{"imageUrl":"https://badge"}}`,
+				Msg:     "hello",
+				Extras:  `{}`,
+				MsgTime: 1700000000000,
+			},
+			{
+				Profile: `{"nickname":"donor"}`,
+				Msg:     "thanks",
+				Extras:  `{"payAmount":1000}`,
+			},
+		})
+		conn.WriteJSON(chzzkPacket{Cmd: chzzkCmdConnected})
+		conn.WriteJSON(chzzkPacket{Cmd: chzzkCmdPing})
+		conn.WriteJSON(chzzkPacket{Cmd: chzzkCmdChat, Bdy: chats})
+
+		var pong chzzkPacket
+		if err := conn.ReadJSON(&pong); err != nil || pong.Cmd != chzzkCmdPong {
+			t.Errorf("pong = %+v, %v", pong, err)
+		}
+		conn.ReadMessage()
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := NewChzzk("channel")
+	c.ChatChannelID = "chat-channel"
+	c.TokenURL = server.URL + "/token"
+	c.ServerURL = "ws" + strings.TrimPrefix(server.URL, "http") + "/chat"
+
+	messages := runChat(t, c, 2)
+	if messages[0].Nickname != "viewer" || messages[0].Message != "hello" || len(messages[0].Badges) != 1 || !messages[0].Time.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("messages[0] = %+v", messages[0])
+	}
+	if messages[1].Nickname != "donor" || messages[1].Donation != 1000 {
+		t.Errorf("messages[1] = %+v", messages[1])
+	}
+}
+
+// runChat runs the connector until count messages have been received and
+// returns them.
+func runChat(t *testing.T, c Connector, count int) []Message {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var messages []Message
+	err := c.Run(ctx, func(msg Message) {
+		messages = append(messages, msg)
+		if len(messages) >= count {
+			cancel()
+		}
+	})
+	if len(messages) < count {
+		t.Fatalf("got %d messages, want %d: %v", len(messages), count, err)
+	}
+
+	return messages
+}
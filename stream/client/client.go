@@ -6,24 +6,50 @@ import (
 	"net/url"
 	"path"
 	"path/filepath"
+	"time"
 
 	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/chat"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h264"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h265"
 	"github.com/jaesung9507/playgo/stream/format"
+	"github.com/jaesung9507/playgo/stream/format/trace"
+	"github.com/jaesung9507/playgo/stream/output"
+	outrtmp "github.com/jaesung9507/playgo/stream/output/rtmp"
+	outsrt "github.com/jaesung9507/playgo/stream/output/srt"
+	"github.com/jaesung9507/playgo/stream/platform"
 	"github.com/jaesung9507/playgo/stream/platform/cime"
+	"github.com/jaesung9507/playgo/stream/platform/ebs"
+	"github.com/jaesung9507/playgo/stream/platform/kbs"
+	"github.com/jaesung9507/playgo/stream/platform/kick"
+	"github.com/jaesung9507/playgo/stream/platform/mbc"
 	"github.com/jaesung9507/playgo/stream/platform/naver"
 	"github.com/jaesung9507/playgo/stream/platform/pandatv"
 	"github.com/jaesung9507/playgo/stream/platform/popkontv"
 	"github.com/jaesung9507/playgo/stream/platform/sbs"
+	"github.com/jaesung9507/playgo/stream/platform/script"
+	"github.com/jaesung9507/playgo/stream/platform/soop"
 	"github.com/jaesung9507/playgo/stream/platform/tiktok"
+	"github.com/jaesung9507/playgo/stream/platform/twitch"
 	"github.com/jaesung9507/playgo/stream/platform/youtube"
 	"github.com/jaesung9507/playgo/stream/protocol/hls"
 	"github.com/jaesung9507/playgo/stream/protocol/http"
 	"github.com/jaesung9507/playgo/stream/protocol/rtmp"
 	"github.com/jaesung9507/playgo/stream/protocol/rtsp"
 	"github.com/jaesung9507/playgo/stream/protocol/srt"
+	"github.com/jaesung9507/playgo/stream/protocol/testsrc"
 )
 
-func Dial(ctx context.Context, streamURL string) (stream.Client, error) {
+type Options struct {
+	// Quality limits the quality picked by platform clients.
+	Quality platform.QualityPreference
+	// WaitForLive makes platform clients poll an offline channel at this
+	// interval instead of failing. OnStatus receives the reason of each retry.
+	WaitForLive time.Duration
+	OnStatus    func(string)
+}
+
+func Dial(ctx context.Context, streamURL string, opts Options) (stream.Client, error) {
 	parsedURL, err := url.Parse(streamURL)
 	if err != nil {
 		return nil, err
@@ -38,6 +64,11 @@ func Dial(ctx context.Context, streamURL string) (stream.Client, error) {
 	case "rtmp", "rtmps":
 		c = rtmp.New(parsedURL)
 	case "http", "https":
+		if scriptClient := script.Lookup(parsedURL); scriptClient != nil {
+			c = scriptClient
+			break
+		}
+
 		switch parsedURL.Host {
 		case "ci.me":
 			c = cime.New(parsedURL)
@@ -47,12 +78,24 @@ func Dial(ctx context.Context, streamURL string) (stream.Client, error) {
 			c = popkontv.New(parsedURL)
 		case "sbs.co.kr", "www.sbs.co.kr", "allvod.sbs.co.kr", "programs.sbs.co.kr":
 			c = sbs.New(parsedURL)
+		case "onair.kbs.co.kr":
+			c = kbs.New(parsedURL)
+		case "onair.imbc.com":
+			c = mbc.New(parsedURL)
+		case "ebs.co.kr", "www.ebs.co.kr":
+			c = ebs.New(parsedURL)
 		case "tiktok.com", "www.tiktok.com":
 			c = tiktok.New(parsedURL)
 		case "chzzk.naver.com", "tv.naver.com", "view.shoppinglive.naver.com", "comic.naver.com":
 			c = naver.New(parsedURL)
 		case "youtube.com", "www.youtube.com", "music.youtube.com", "youtu.be", "youtubekids.com", "www.youtubekids.com":
 			c = youtube.New(parsedURL)
+		case "twitch.tv", "www.twitch.tv", "m.twitch.tv", "clips.twitch.tv":
+			c = twitch.New(parsedURL)
+		case "kick.com", "www.kick.com":
+			c = kick.New(parsedURL)
+		case "play.sooplive.co.kr", "vod.sooplive.co.kr":
+			c = soop.New(parsedURL)
 		default:
 			switch filepath.Ext(path.Base(parsedURL.Path)) {
 			case ".m3u8":
@@ -65,13 +108,29 @@ func Dial(ctx context.Context, streamURL string) (stream.Client, error) {
 		}
 	case "srt":
 		c = srt.New(parsedURL)
+	case "testsrc":
+		c = testsrc.New(parsedURL)
+	case "trace":
+		c = trace.New(parsedURL)
 	default:
 		return nil, fmt.Errorf("unsupported protocol: %s", parsedURL.Scheme)
 	}
 
+	if pc, ok := c.(*platform.Client); ok {
+		pc.SetQualityPreference(opts.Quality)
+		if opts.WaitForLive > 0 {
+			pc.WaitForLive(ctx, opts.WaitForLive, opts.OnStatus)
+		}
+	}
+
+	dial := c.Dial
+	if cd, ok := c.(stream.ContextDialer); ok {
+		dial = func() error { return cd.DialContext(ctx) }
+	}
+
 	ch := make(chan error, 1)
 	go func() {
-		ch <- c.Dial()
+		ch <- dial()
 	}()
 
 	select {
@@ -91,6 +150,69 @@ func Dial(ctx context.Context, streamURL string) (stream.Client, error) {
 	return c, nil
 }
 
+// Expand turns a collection URL such as a playlist or a channel page into its
+// playable entries. It returns nil if the URL refers to a single stream.
+func Expand(ctx context.Context, streamURL string) ([]platform.Entry, error) {
+	parsedURL, err := url.Parse(streamURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch parsedURL.Host {
+	case "chzzk.naver.com":
+		return naver.Expand(ctx, parsedURL)
+	case "youtube.com", "www.youtube.com", "music.youtube.com", "youtubekids.com", "www.youtubekids.com":
+		return youtube.Expand(ctx, parsedURL)
+	case "vod.sooplive.co.kr":
+		return soop.Expand(ctx, parsedURL)
+	case "programs.sbs.co.kr":
+		return sbs.Expand(ctx, parsedURL)
+	}
+
+	return nil, nil
+}
+
+// Channels returns the on-air channels of the terrestrial broadcasters for
+// the channel guide.
+func Channels() []platform.Channel {
+	var channels []platform.Channel
+	channels = append(channels, kbs.Channels()...)
+	channels = append(channels, mbc.Channels()...)
+	channels = append(channels, sbs.Channels()...)
+	channels = append(channels, ebs.Channels()...)
+
+	return channels
+}
+
+// Chat returns the chat connector of a live URL, or nil if it has none.
+func Chat(streamURL string) chat.Connector {
+	parsedURL, err := url.Parse(streamURL)
+	if err != nil {
+		return nil
+	}
+
+	return chat.New(parsedURL)
+}
+
+// Publisher returns an output that pushes the stream to an RTMP(S) ingest or
+// an SRT listener. It dials and writes on a goroutine of its own, so that a
+// slow ingest does not hold up playback.
+func Publisher(targetURL string) (output.Output, error) {
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch parsedURL.Scheme {
+	case "rtmp", "rtmps":
+		return output.NewAsync(outrtmp.NewPublisher(parsedURL), output.DefaultAsyncSize), nil
+	case "srt":
+		return output.NewAsync(outsrt.NewPublisher(parsedURL), output.DefaultAsyncSize), nil
+	}
+
+	return nil, fmt.Errorf("unsupported publish protocol: %s", parsedURL.Scheme)
+}
+
 func CodecData(ctx context.Context, c stream.Client) (codecs []stream.Codec, err error) {
 	defer func() {
 		if err != nil {
@@ -113,6 +235,19 @@ func CodecData(ctx context.Context, c stream.Client) (codecs []stream.Codec, err
 			return nil, err
 		}
 	}
+	c.PacketQueue().SetVideoTrack(videoTrack(codecs))
 
 	return codecs, nil
 }
+
+// videoTrack returns the index of the first video track, -1 if there is none.
+func videoTrack(codecs []stream.Codec) int {
+	for i, codec := range codecs {
+		switch codec.(type) {
+		case *h264.Codec, *h265.Codec:
+			return i
+		}
+	}
+
+	return -1
+}
@@ -1,8 +1,6 @@
 package h265
 
 import (
-	"bytes"
-	"encoding/binary"
 	"fmt"
 	"io"
 	"time"
@@ -96,15 +94,14 @@ func (d *Demuxer) ReadPacket() (stream.Packet, error) {
 		dts = d.pts
 	}
 
-	buf := bytes.NewBuffer(nil)
-	for _, nalu := range au {
-		binary.Write(buf, binary.BigEndian, uint32(len(nalu)))
-		buf.Write(nalu)
+	data, err := h26x.AVCC(au).Marshal()
+	if err != nil {
+		return stream.Packet{}, err
 	}
 
 	pkt := stream.Packet{
 		IsKeyFrame: IsKeyFrame(au),
-		Data:       buf.Bytes(),
+		Data:       data,
 		Time:       time.Duration(d.pts) * time.Second / timebase,
 	}
 	pkt.CompositionTime = pkt.Time - (time.Duration(dts) * time.Second / timebase)
@@ -1,60 +1,103 @@
 package h26x
 
 import (
-	"bufio"
+	"bytes"
 	"errors"
 	"io"
 )
 
+const chunkSize = 1 * 1024 * 1024
+
+var startCode = []byte{0, 0, 1}
+
+// NALUReader splits an Annex-B byte stream into NAL units.
+//
+// Input is read in large chunks and scanned for start codes with bytes.Index.
+// Returned NAL units are slices of those chunks and stay valid after the next
+// Read, since a chunk is never overwritten once it has been handed out.
+//
+// Chunks are not pooled: the demuxers put the returned slices into packets
+// without copying, and those packets outlive the reader in queues, muxers and
+// outputs, so there is no point at which a chunk is known to be free again.
+// Pooling would need a copy per NAL unit, which costs more than the one
+// allocation per chunk it saves.
 type NALUReader struct {
-	r *bufio.Reader
+	r       io.Reader
+	buf     []byte
+	pos     int
+	started bool
+	err     error
 }
 
 func NewNALUReader(r io.Reader) *NALUReader {
-	return &NALUReader{r: bufio.NewReader(r)}
+	return &NALUReader{r: r}
+}
+
+// fill reads more data after the unread part of the buffer. When the current
+// chunk is full, the unread tail is moved to a newly allocated chunk instead of
+// being compacted in place, so that slices already returned remain intact.
+func (r *NALUReader) fill() bool {
+	if r.err != nil {
+		return false
+	}
+
+	if len(r.buf) == cap(r.buf) {
+		remain := r.buf[r.pos:]
+		buf := make([]byte, len(remain), max(chunkSize, 2*len(remain)))
+		copy(buf, remain)
+		r.buf, r.pos = buf, 0
+	}
+
+	n, err := r.r.Read(r.buf[len(r.buf):cap(r.buf)])
+	r.buf = r.buf[:len(r.buf)+n]
+	if err != nil {
+		r.err = err
+	}
+
+	return n > 0 || err == nil
 }
 
 func (r *NALUReader) Read() ([]byte, error) {
-	for {
-		p, err := r.r.Peek(4)
-		if err != nil {
-			return nil, err
+	for !r.started {
+		if i := bytes.Index(r.buf[r.pos:], startCode); i >= 0 {
+			r.pos += i + len(startCode)
+			r.started = true
+			break
 		}
 
-		if len(p) >= 3 && p[0] == 0 && p[1] == 0 {
-			if p[2] == 1 {
-				r.r.Discard(3)
-				break
-			}
-			if len(p) >= 4 && p[2] == 0 && p[3] == 1 {
-				r.r.Discard(4)
-				break
-			}
+		r.pos = max(r.pos, len(r.buf)-len(startCode)+1)
+		if !r.fill() {
+			return nil, r.err
 		}
-		r.r.Discard(1)
 	}
 
-	var nalu []byte
+	var scanned int
 	for {
-		p, err := r.r.Peek(4)
-		if len(p) >= 3 && p[0] == 0 && p[1] == 0 && (p[2] == 1 || (len(p) >= 4 && p[2] == 0 && p[3] == 1)) {
-			break
+		if i := bytes.Index(r.buf[r.pos+scanned:], startCode); i >= 0 {
+			end := r.pos + scanned + i
+			nalu := r.buf[r.pos:end]
+			r.pos = end + len(startCode)
+			return trimTrailingZeros(nalu), nil
 		}
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				buf := make([]byte, r.r.Buffered())
-				n, _ := r.r.Read(buf)
-				nalu = append(nalu, buf[:n]...)
-			}
-			if len(nalu) == 0 {
-				return nil, err
+
+		scanned = max(len(r.buf)-r.pos-len(startCode)+1, 0)
+		if !r.fill() {
+			if errors.Is(r.err, io.EOF) && r.pos < len(r.buf) {
+				nalu := r.buf[r.pos:]
+				r.pos = len(r.buf)
+				return trimTrailingZeros(nalu), nil
 			}
-			break
+			return nil, r.err
 		}
+	}
+}
 
-		b, _ := r.r.ReadByte()
-		nalu = append(nalu, b)
+// trimTrailingZeros drops the leading zero byte of a following 4-byte start
+// code as well as any trailing_zero_8bits.
+func trimTrailingZeros(nalu []byte) []byte {
+	for len(nalu) > 0 && nalu[len(nalu)-1] == 0 {
+		nalu = nalu[:len(nalu)-1]
 	}
 
-	return nalu, nil
+	return nalu
 }
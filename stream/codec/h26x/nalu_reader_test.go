@@ -0,0 +1,197 @@
+package h26x
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"testing"
+	"testing/iotest"
+)
+
+// bufioNALUReader is the byte-by-byte reader NALUReader replaced, kept to
+// check its output and to compare their speed.
+type bufioNALUReader struct {
+	r *bufio.Reader
+}
+
+func (r *bufioNALUReader) Read() ([]byte, error) {
+	for {
+		p, err := r.r.Peek(4)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(p) >= 3 && p[0] == 0 && p[1] == 0 {
+			if p[2] == 1 {
+				r.r.Discard(3)
+				break
+			}
+			if len(p) >= 4 && p[2] == 0 && p[3] == 1 {
+				r.r.Discard(4)
+				break
+			}
+		}
+		r.r.Discard(1)
+	}
+
+	var nalu []byte
+	for {
+		p, err := r.r.Peek(4)
+		if len(p) >= 3 && p[0] == 0 && p[1] == 0 && (p[2] == 1 || (len(p) >= 4 && p[2] == 0 && p[3] == 1)) {
+			break
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				buf := make([]byte, r.r.Buffered())
+				n, _ := r.r.Read(buf)
+				nalu = append(nalu, buf[:n]...)
+			}
+			if len(nalu) == 0 {
+				return nil, err
+			}
+			break
+		}
+
+		b, _ := r.r.ReadByte()
+		nalu = append(nalu, b)
+	}
+
+	return nalu, nil
+}
+
+// annexB returns a byte stream of count NAL units with mixed 3 and 4-byte
+// start codes. Payloads contain no zero bytes, as emulation prevention
+// guarantees for the start code patterns.
+func annexB(count, maxSize int) ([]byte, [][]byte) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	var stream bytes.Buffer
+	nalus := make([][]byte, count)
+	for i := range nalus {
+		nalu := make([]byte, 1+rng.IntN(maxSize))
+		for j := range nalu {
+			nalu[j] = byte(1 + rng.IntN(255))
+		}
+		nalus[i] = nalu
+
+		if rng.IntN(2) == 0 {
+			stream.Write([]byte{0, 0, 0, 1})
+		} else {
+			stream.Write([]byte{0, 0, 1})
+		}
+		stream.Write(nalu)
+	}
+
+	return stream.Bytes(), nalus
+}
+
+func readAll(t *testing.T, read func() ([]byte, error)) [][]byte {
+	t.Helper()
+
+	var nalus [][]byte
+	for {
+		nalu, err := read()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("read: %v", err)
+			}
+			return nalus
+		}
+		nalus = append(nalus, nalu)
+	}
+}
+
+func TestNALUReader(t *testing.T) {
+	data, want := annexB(300, 20000)
+
+	readers := map[string]func() io.Reader{
+		"whole":    func() io.Reader { return bytes.NewReader(data) },
+		"one byte": func() io.Reader { return iotest.OneByteReader(bytes.NewReader(data)) },
+		"half":     func() io.Reader { return iotest.HalfReader(bytes.NewReader(data)) },
+		"data eof": func() io.Reader { return iotest.DataErrReader(bytes.NewReader(data)) },
+	}
+	for name, newReader := range readers {
+		t.Run(name, func(t *testing.T) {
+			got := readAll(t, NewNALUReader(newReader()).Read)
+			if len(got) != len(want) {
+				t.Fatalf("got %d NAL units, want %d", len(got), len(want))
+			}
+			for i := range want {
+				if !bytes.Equal(got[i], want[i]) {
+					t.Fatalf("NAL unit %d: got %d bytes, want %d", i, len(got[i]), len(want[i]))
+				}
+			}
+		})
+	}
+}
+
+func TestNALUReaderMatchesBufio(t *testing.T) {
+	data, _ := annexB(100, 5000)
+
+	got := readAll(t, NewNALUReader(bytes.NewReader(data)).Read)
+	old := &bufioNALUReader{r: bufio.NewReader(bytes.NewReader(data))}
+	want := readAll(t, old.Read)
+	if len(got) != len(want) {
+		t.Fatalf("got %d NAL units, bufio reader %d", len(got), len(want))
+	}
+	for i := range want {
+		if !bytes.Equal(got[i], want[i]) {
+			t.Fatalf("NAL unit %d differs", i)
+		}
+	}
+}
+
+func TestNALUReaderKeepsReturnedUnits(t *testing.T) {
+	// Units larger than a chunk force the reader to move to new chunks.
+	data, want := annexB(8, 3*chunkSize/2)
+
+	got := readAll(t, NewNALUReader(iotest.HalfReader(bytes.NewReader(data))).Read)
+	for i := range want {
+		if !bytes.Equal(got[i], want[i]) {
+			t.Fatalf("NAL unit %d changed after later reads", i)
+		}
+	}
+}
+
+func TestNALUReaderTrailingZeros(t *testing.T) {
+	data := []byte{0xff, 0, 0, 0, 1, 0x67, 1, 2, 0, 0, 0, 0, 1, 0x68, 3, 0, 0}
+
+	got := readAll(t, NewNALUReader(bytes.NewReader(data)).Read)
+	want := [][]byte{{0x67, 1, 2}, {0x68, 3}}
+	if len(got) != len(want) {
+		t.Fatalf("got %d NAL units, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !bytes.Equal(got[i], want[i]) {
+			t.Fatalf("NAL unit %d: got %x, want %x", i, got[i], want[i])
+		}
+	}
+}
+
+func benchmarkReader(b *testing.B, read func(io.Reader) func() ([]byte, error)) {
+	data, _ := annexB(2000, 8000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		next := read(bytes.NewReader(data))
+		for {
+			if _, err := next(); err != nil {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkNALUReader(b *testing.B) {
+	benchmarkReader(b, func(r io.Reader) func() ([]byte, error) {
+		return NewNALUReader(r).Read
+	})
+}
+
+func BenchmarkBufioNALUReader(b *testing.B) {
+	benchmarkReader(b, func(r io.Reader) func() ([]byte, error) {
+		return (&bufioNALUReader{r: bufio.NewReader(r)}).Read
+	})
+}
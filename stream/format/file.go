@@ -24,7 +24,7 @@ type LocalFile struct {
 	closer      io.Closer
 	demuxer     stream.Demuxer
 	signal      chan any
-	packetQueue chan *stream.Packet
+	packetQueue *stream.PacketQueue
 }
 
 func NewLocalFile(filePath string) *LocalFile {
@@ -38,7 +38,7 @@ func NewLocalFile(filePath string) *LocalFile {
 	return &LocalFile{
 		path:        filePath,
 		signal:      make(chan any, 1),
-		packetQueue: make(chan *stream.Packet),
+		packetQueue: stream.NewPacketQueue(stream.DefaultQueueCapacity, stream.QueueBlock),
 	}
 }
 
@@ -90,6 +90,7 @@ func (f *LocalFile) Dial() error {
 }
 
 func (f *LocalFile) Close() {
+	f.packetQueue.Close()
 	if f.closer != nil {
 		f.closer.Close()
 	}
@@ -104,10 +105,14 @@ func (f *LocalFile) CodecData() ([]stream.Codec, error) {
 				if err != nil {
 					if !errors.Is(err, io.EOF) {
 						f.signal <- err
+					} else {
+						f.packetQueue.Finish()
 					}
 					return
 				}
-				f.packetQueue <- &packet
+				if !f.packetQueue.Push(&packet) {
+					return
+				}
 			}
 		}()
 	}
@@ -115,7 +120,11 @@ func (f *LocalFile) CodecData() ([]stream.Codec, error) {
 	return codecs, err
 }
 
-func (f *LocalFile) PacketQueue() <-chan *stream.Packet {
+func (f *LocalFile) SkippedTracks() []stream.SkippedTrack {
+	return stream.SkippedTracks(f.demuxer)
+}
+
+func (f *LocalFile) PacketQueue() *stream.PacketQueue {
 	return f.packetQueue
 }
 
@@ -0,0 +1,261 @@
+package fmp4
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/codec/aac"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h264"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h265"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/fmp4"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/mp4/codecs"
+)
+
+// maxBoxSize bounds the memory taken by a single top-level box.
+const maxBoxSize = 64 * 1024 * 1024
+
+type demuxerTrack struct {
+	idx       int8
+	timeScale uint32
+}
+
+// Demuxer reads a fragmented MP4 stream such as a DASH representation:
+// an initialization block followed by moof/mdat pairs.
+type Demuxer struct {
+	r      io.Reader
+	tracks map[int]demuxerTrack
+	moof   []byte
+	q      []stream.Packet
+}
+
+func NewDemuxer(r io.Reader) *Demuxer {
+	return &Demuxer{r: r}
+}
+
+func readBoxHeader(r io.Reader) (string, uint64, int, error) {
+	var header [16]byte
+	if _, err := io.ReadFull(r, header[:8]); err != nil {
+		return "", 0, 0, err
+	}
+
+	size := uint64(binary.BigEndian.Uint32(header[:4]))
+	typ := string(header[4:8])
+	headerSize := 8
+	if size == 1 {
+		if _, err := io.ReadFull(r, header[8:16]); err != nil {
+			return "", 0, 0, err
+		}
+		size = binary.BigEndian.Uint64(header[8:16])
+		headerSize = 16
+	}
+
+	if size != 0 && size < uint64(headerSize) {
+		return "", 0, 0, fmt.Errorf("invalid box size: %s %d", typ, size)
+	}
+
+	return typ, size, headerSize, nil
+}
+
+// readBox returns a whole top-level box including its header.
+func (d *Demuxer) readBox() (string, []byte, error) {
+	typ, size, headerSize, err := readBoxHeader(d.r)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if size == 0 || size > maxBoxSize {
+		return "", nil, fmt.Errorf("unsupported box size: %s %d", typ, size)
+	}
+
+	box := make([]byte, size)
+	binary.BigEndian.PutUint32(box[:4], uint32(size))
+	copy(box[4:8], typ)
+	if headerSize == 16 {
+		binary.BigEndian.PutUint32(box[:4], 1)
+		binary.BigEndian.PutUint64(box[8:16], size)
+	}
+	if _, err = io.ReadFull(d.r, box[headerSize:]); err != nil {
+		return "", nil, err
+	}
+
+	return typ, box, nil
+}
+
+func (d *Demuxer) CodecData() ([]stream.Codec, error) {
+	var buf []byte
+	for {
+		typ, box, err := d.readBox()
+		if err != nil {
+			return nil, err
+		}
+
+		switch typ {
+		case "ftyp":
+			buf = append(buf, box...)
+			continue
+		case "moov":
+			buf = append(buf, box...)
+		default:
+			continue
+		}
+		break
+	}
+
+	var init fmp4.Init
+	if err := init.Unmarshal(bytes.NewReader(buf)); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal init: %w", err)
+	}
+
+	d.tracks = make(map[int]demuxerTrack)
+	var result []stream.Codec
+	for _, track := range init.Tracks {
+		log.Printf("[FMP4] on track %d: %T", track.ID, track.Codec)
+		switch codec := track.Codec.(type) {
+		case *codecs.H264:
+			result = append(result, &h264.Codec{SPS: codec.SPS, PPS: codec.PPS})
+		case *codecs.H265:
+			result = append(result, &h265.Codec{VPS: codec.VPS, SPS: codec.SPS, PPS: codec.PPS})
+		case *codecs.MPEG4Audio:
+			asc, err := codec.Config.Marshal()
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, &aac.Codec{ASC: asc, Config: codec.Config})
+		default:
+			return nil, fmt.Errorf("not supported codec: %T", codec)
+		}
+		d.tracks[track.ID] = demuxerTrack{
+			idx:       int8(len(result) - 1),
+			timeScale: track.TimeScale,
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, errors.New("not found tracks")
+	}
+
+	return result, nil
+}
+
+func (d *Demuxer) ReadPacket() (stream.Packet, error) {
+	for len(d.q) == 0 {
+		typ, box, err := d.readBox()
+		if err != nil {
+			return stream.Packet{}, err
+		}
+
+		switch typ {
+		case "moof":
+			d.moof = box
+		case "mdat":
+			if d.moof == nil {
+				continue
+			}
+
+			err = d.parseFragment(append(d.moof, box...))
+			d.moof = nil
+			if err != nil {
+				return stream.Packet{}, err
+			}
+		}
+	}
+
+	packet := d.q[0]
+	d.q = d.q[1:]
+
+	return packet, nil
+}
+
+func (d *Demuxer) parseFragment(data []byte) error {
+	var parts fmp4.Parts
+	if err := parts.Unmarshal(data); err != nil {
+		return fmt.Errorf("failed to unmarshal fragment: %w", err)
+	}
+
+	for _, part := range parts {
+		for _, partTrack := range part.Tracks {
+			track, ok := d.tracks[partTrack.ID]
+			if !ok {
+				continue
+			}
+
+			ticks := partTrack.BaseTime
+			for _, sample := range partTrack.Samples {
+				d.q = append(d.q, stream.Packet{
+					Idx:             track.idx,
+					IsKeyFrame:      !sample.IsNonSyncSample,
+					Time:            ticksToDuration(ticks, track.timeScale),
+					CompositionTime: time.Duration(sample.PTSOffset) * time.Second / time.Duration(track.timeScale),
+					Data:            sample.Payload,
+				})
+				ticks += uint64(sample.Duration)
+			}
+		}
+	}
+
+	slices.SortStableFunc(d.q, func(a, b stream.Packet) int {
+		return cmp.Compare(a.Time, b.Time)
+	})
+
+	return nil
+}
+
+func ticksToDuration(ticks uint64, timeScale uint32) time.Duration {
+	return time.Duration(ticks/uint64(timeScale))*time.Second +
+		time.Duration(ticks%uint64(timeScale))*time.Second/time.Duration(timeScale)
+}
+
+// IsFragmented reports whether the MP4 file carries its samples in movie
+// fragments, which is signalled by an mvex box in moov. The reader is
+// rewound to the start.
+func IsFragmented(r io.ReadSeeker) (bool, error) {
+	defer r.Seek(0, io.SeekStart)
+
+	for {
+		typ, size, headerSize, err := readBoxHeader(r)
+		if err != nil {
+			return false, err
+		}
+
+		if typ != "moov" {
+			if size == 0 {
+				return false, nil
+			}
+			if _, err = r.Seek(int64(size)-int64(headerSize), io.SeekCurrent); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		if size == 0 || size > maxBoxSize {
+			return false, fmt.Errorf("unsupported moov size: %d", size)
+		}
+
+		moov := make([]byte, size-uint64(headerSize))
+		if _, err = io.ReadFull(r, moov); err != nil {
+			return false, err
+		}
+
+		for len(moov) >= 8 {
+			childSize := binary.BigEndian.Uint32(moov[:4])
+			if string(moov[4:8]) == "mvex" {
+				return true, nil
+			}
+			if childSize < 8 || int(childSize) > len(moov) {
+				break
+			}
+			moov = moov[childSize:]
+		}
+
+		return false, nil
+	}
+}
@@ -15,58 +15,135 @@ import (
 	"github.com/bluenviron/mediacommon/v2/pkg/formats/mp4/codecs"
 )
 
+const (
+	DefaultFragmentDuration = 200 * time.Millisecond
+
+	// maxTrackSamples bounds the samples held for a track whose timestamps
+	// never reach the fragment boundary, e.g. because of a foreign time base.
+	maxTrackSamples = 256
+)
+
+type sample struct {
+	*fmp4.Sample
+	time time.Duration
+}
+
+type muxerTrack struct {
+	*fmp4.InitTrack
+	isVideo         bool
+	baseTime        uint64
+	samples         []sample
+	prev            *stream.Packet
+	defaultDuration uint32
+}
+
+// duration converts both timestamps before subtracting them, so that rounding
+// does not accumulate into drift between tracks.
+func (t *muxerTrack) duration(from, to time.Duration) uint32 {
+	return uint32(t.timestamp(to) - t.timestamp(from))
+}
+
+func (t *muxerTrack) timestamp(d time.Duration) int64 {
+	return int64((d*time.Duration(t.TimeScale) + time.Second/2) / time.Second)
+}
+
+func (t *muxerTrack) addSample(prev *stream.Packet, duration uint32) {
+	t.samples = append(t.samples, sample{
+		Sample: &fmp4.Sample{
+			Duration:        duration,
+			PTSOffset:       int32(prev.CompositionTime * time.Duration(t.TimeScale) / time.Second),
+			IsNonSyncSample: !prev.IsKeyFrame,
+			Payload:         prev.Data,
+		},
+		time: prev.Time,
+	})
+	t.defaultDuration = duration
+}
+
+// Muxer packs packets of all tracks into fragments that cover the same time
+// span. A fragment is cut when the fragment duration is reached and in front
+// of every video keyframe, so each keyframe starts a new fragment.
 type Muxer struct {
-	tracks      []*fmp4.InitTrack
-	samples     map[int8][]*fmp4.Sample
-	baseTimes   map[int8]uint64
-	prevPackets map[int8]*stream.Packet
-	sequenceNum uint32
-	maxFrames   int
+	tracks           []*muxerTrack
+	sequenceNum      uint32
+	fragmentDuration time.Duration
 }
 
 func NewMuxer() *Muxer {
 	return &Muxer{
-		baseTimes:   make(map[int8]uint64),
-		samples:     make(map[int8][]*fmp4.Sample),
-		prevPackets: make(map[int8]*stream.Packet),
-		maxFrames:   5,
+		fragmentDuration: DefaultFragmentDuration,
+	}
+}
+
+// SetFragmentDuration sets the target duration of a fragment. Zero emits a
+// fragment for every packet.
+func (m *Muxer) SetFragmentDuration(d time.Duration) {
+	m.fragmentDuration = max(d, 0)
+}
+
+// SetLowLatency emits a fragment for every sample instead of batching them.
+func (m *Muxer) SetLowLatency(enabled bool) {
+	if enabled {
+		m.SetFragmentDuration(0)
+	} else {
+		m.SetFragmentDuration(DefaultFragmentDuration)
+	}
+}
+
+// Skip discards buffered samples so that writing can continue from a later
+// keyframe. Track base times are kept, so the output timeline has no gap.
+func (m *Muxer) Skip() {
+	for _, track := range m.tracks {
+		track.samples = nil
+		track.prev = nil
 	}
 }
 
 func (m *Muxer) WriteHeader(codecData []stream.Codec) (string, []byte, error) {
 	var (
-		tracks       []*fmp4.InitTrack
+		tracks       []*muxerTrack
 		codecStrings []string
 	)
 
 	for i, codec := range codecData {
 		switch codec := codec.(type) {
 		case *h264.Codec:
-			tracks = append(tracks, &fmp4.InitTrack{
-				ID:        i + 1,
-				TimeScale: 90000,
-				Codec: &codecs.H264{
-					SPS: codec.SPS,
-					PPS: codec.PPS,
+			tracks = append(tracks, &muxerTrack{
+				InitTrack: &fmp4.InitTrack{
+					ID:        i + 1,
+					TimeScale: 90000,
+					Codec: &codecs.H264{
+						SPS: codec.SPS,
+						PPS: codec.PPS,
+					},
 				},
+				isVideo:         true,
+				defaultDuration: 90000 / 30,
 			})
 		case *h265.Codec:
-			tracks = append(tracks, &fmp4.InitTrack{
-				ID:        i + 1,
-				TimeScale: 90000,
-				Codec: &codecs.H265{
-					VPS: codec.VPS,
-					SPS: codec.SPS,
-					PPS: codec.PPS,
+			tracks = append(tracks, &muxerTrack{
+				InitTrack: &fmp4.InitTrack{
+					ID:        i + 1,
+					TimeScale: 90000,
+					Codec: &codecs.H265{
+						VPS: codec.VPS,
+						SPS: codec.SPS,
+						PPS: codec.PPS,
+					},
 				},
+				isVideo:         true,
+				defaultDuration: 90000 / 30,
 			})
 		case *aac.Codec:
-			tracks = append(tracks, &fmp4.InitTrack{
-				ID:        i + 1,
-				TimeScale: uint32(codec.Config.SampleRate),
-				Codec: &codecs.MPEG4Audio{
-					Config: codec.Config,
+			tracks = append(tracks, &muxerTrack{
+				InitTrack: &fmp4.InitTrack{
+					ID:        i + 1,
+					TimeScale: uint32(codec.Config.SampleRate),
+					Codec: &codecs.MPEG4Audio{
+						Config: codec.Config,
+					},
 				},
+				defaultDuration: aac.SamplesPerAccessUnit,
 			})
 		default:
 			return "", nil, fmt.Errorf("unsupported codec: %T", codec)
@@ -75,8 +152,9 @@ func (m *Muxer) WriteHeader(codecData []stream.Codec) (string, []byte, error) {
 	}
 
 	m.tracks = tracks
-	init := &fmp4.Init{
-		Tracks: tracks,
+	init := &fmp4.Init{}
+	for _, track := range tracks {
+		init.Tracks = append(init.Tracks, track.InitTrack)
 	}
 
 	buf := &seekablebuffer.Buffer{}
@@ -87,44 +165,92 @@ func (m *Muxer) WriteHeader(codecData []stream.Codec) (string, []byte, error) {
 	return strings.Join(codecStrings, ","), buf.Bytes(), nil
 }
 
+// WritePacket buffers the packet and returns a fragment when one is complete.
+// A sample is held back until the next packet of its track gives its duration.
 func (m *Muxer) WritePacket(packet stream.Packet) ([]byte, error) {
-	if int(packet.Idx) >= len(m.tracks) {
+	if packet.Idx < 0 || int(packet.Idx) >= len(m.tracks) {
 		return nil, fmt.Errorf("invalid track index: %d", packet.Idx)
 	}
-	defer func() { m.prevPackets[packet.Idx] = &packet }()
 
 	track := m.tracks[packet.Idx]
-
-	if prev := m.prevPackets[packet.Idx]; prev != nil {
-		sample := &fmp4.Sample{
-			Duration:        uint32((packet.Time - prev.Time) * time.Duration(track.TimeScale) / time.Second),
-			PTSOffset:       int32(prev.CompositionTime * time.Duration(track.TimeScale) / time.Second),
-			IsNonSyncSample: !prev.IsKeyFrame,
-			Payload:         prev.Data,
+	if prev := track.prev; prev != nil {
+		duration := track.defaultDuration
+		if packet.Time > prev.Time {
+			duration = track.duration(prev.Time, packet.Time)
 		}
-		m.samples[packet.Idx] = append(m.samples[packet.Idx], sample)
+		track.addSample(prev, duration)
 	}
+	track.prev = &packet
 
-	if len(m.samples[packet.Idx]) < m.maxFrames {
+	if !m.isBoundary(track, &packet) {
 		return nil, nil
 	}
 
+	return m.cut(packet.Time, false)
+}
+
+func (m *Muxer) isBoundary(track *muxerTrack, packet *stream.Packet) bool {
+	if m.fragmentDuration <= 0 || (track.isVideo && packet.IsKeyFrame) {
+		return true
+	}
+
+	start, ok := m.startTime()
+	if !ok {
+		return false
+	}
+
+	return packet.Time-start >= m.fragmentDuration
+}
+
+func (m *Muxer) startTime() (time.Duration, bool) {
+	var (
+		start time.Duration
+		found bool
+	)
+	for _, track := range m.tracks {
+		if len(track.samples) > 0 && (!found || track.samples[0].time < start) {
+			start, found = track.samples[0].time, true
+		}
+	}
+
+	return start, found
+}
+
+// cut emits the samples of all tracks that start before end. With all set,
+// every buffered sample is emitted.
+func (m *Muxer) cut(end time.Duration, all bool) ([]byte, error) {
 	part := &fmp4.Part{
 		SequenceNumber: m.sequenceNum,
-		Tracks: []*fmp4.PartTrack{
-			{
-				ID:       track.ID,
-				BaseTime: m.baseTimes[packet.Idx],
-				Samples:  m.samples[packet.Idx],
-			},
-		},
 	}
 
-	for _, s := range m.samples[packet.Idx] {
-		m.baseTimes[packet.Idx] += uint64(s.Duration)
+	for _, track := range m.tracks {
+		n := len(track.samples)
+		if !all && n < maxTrackSamples {
+			n = 0
+			for n < len(track.samples) && track.samples[n].time < end {
+				n++
+			}
+		}
+		if n == 0 {
+			continue
+		}
+
+		partTrack := &fmp4.PartTrack{
+			ID:       track.ID,
+			BaseTime: track.baseTime,
+		}
+		for _, s := range track.samples[:n] {
+			partTrack.Samples = append(partTrack.Samples, s.Sample)
+			track.baseTime += uint64(s.Duration)
+		}
+		track.samples = track.samples[n:]
+		part.Tracks = append(part.Tracks, partTrack)
+	}
+
+	if len(part.Tracks) == 0 {
+		return nil, nil
 	}
 	m.sequenceNum++
-	m.samples[packet.Idx] = nil
 
 	buf := &seekablebuffer.Buffer{}
 	if err := part.Marshal(buf); err != nil {
@@ -133,3 +259,17 @@ func (m *Muxer) WritePacket(packet stream.Packet) ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
+
+// Flush emits everything still buffered, including the last packet of each
+// track, whose duration is taken from the previous sample. It is meant to be
+// called once the stream has ended.
+func (m *Muxer) Flush() ([]byte, error) {
+	for _, track := range m.tracks {
+		if track.prev != nil {
+			track.addSample(track.prev, track.defaultDuration)
+			track.prev = nil
+		}
+	}
+
+	return m.cut(0, true)
+}
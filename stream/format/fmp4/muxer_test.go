@@ -0,0 +1,109 @@
+package fmp4
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/internal/streamtest"
+)
+
+func TestMuxerRoundTrip(t *testing.T) {
+	codecs, packets := streamtest.Collect(t, "testsrc://bars?size=240p&fps=10&gop=10&duration=3s&realtime=0")
+
+	m := NewMuxer()
+	codecString, init, err := m.WriteHeader(codecs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if codecString != "avc1.42C015,mp4a.40.2" {
+		t.Errorf("codec string = %s", codecString)
+	}
+
+	buf := bytes.NewBuffer(init)
+	var fragments int
+	for _, packet := range packets {
+		fragment, err := m.WritePacket(packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fragment != nil {
+			fragments++
+			buf.Write(fragment)
+		}
+	}
+	fragment, err := m.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Write(fragment)
+
+	// Fragments are cut at every keyframe and every 200ms.
+	if fragments < 14 {
+		t.Errorf("%d fragments", fragments)
+	}
+
+	d := NewDemuxer(buf)
+	demuxed, err := d.CodecData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(demuxed) != len(codecs) {
+		t.Fatalf("demuxed %d codecs", len(demuxed))
+	}
+
+	var got []stream.Packet
+	for {
+		packet, err := d.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, packet)
+	}
+
+	if len(got) != len(packets) {
+		t.Fatalf("demuxed %d packets, want %d", len(got), len(packets))
+	}
+
+	want := make(map[int8][]stream.Packet)
+	for _, packet := range packets {
+		want[packet.Idx] = append(want[packet.Idx], packet)
+	}
+	for _, packet := range got {
+		w := want[packet.Idx][0]
+		want[packet.Idx] = want[packet.Idx][1:]
+
+		if d := packet.Time - w.Time; d < -time.Millisecond || d > time.Millisecond {
+			t.Errorf("track %d: time %v, want %v", packet.Idx, packet.Time, w.Time)
+		}
+		if packet.IsKeyFrame != w.IsKeyFrame || !bytes.Equal(packet.Data, w.Data) {
+			t.Errorf("track %d at %v: sample differs", packet.Idx, w.Time)
+		}
+	}
+}
+
+func TestMuxerLowLatency(t *testing.T) {
+	codecs, packets := streamtest.Collect(t, "testsrc://bars?size=240p&fps=10&duration=1s&audio=0&realtime=0")
+
+	m := NewMuxer()
+	m.SetLowLatency(true)
+	if _, _, err := m.WriteHeader(codecs); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every packet but the first completes the sample before it.
+	for i, packet := range packets {
+		fragment, err := m.WritePacket(packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (fragment != nil) != (i > 0) {
+			t.Errorf("packet %d: fragment=%t", i, fragment != nil)
+		}
+	}
+}
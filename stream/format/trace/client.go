@@ -0,0 +1,161 @@
+package trace
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+)
+
+// Client replays a trace file, e.g. trace:///tmp/trace.trace?speed=4. The
+// packets are delivered at their recorded arrival times divided by speed, or
+// as fast as the consumer reads them with speed=0.
+type Client struct {
+	url         *url.URL
+	path        string
+	speed       float64
+	file        *os.File
+	reader      *Reader
+	signal      chan any
+	packetQueue *stream.PacketQueue
+	done        chan struct{}
+	once        sync.Once
+}
+
+func New(parsedURL *url.URL) *Client {
+	filePath := parsedURL.Path
+	switch runtime.GOOS {
+	case "windows":
+		if len(filePath) > 0 && filePath[0] == '/' {
+			filePath = filepath.FromSlash(filePath[1:])
+		}
+	}
+
+	return &Client{
+		url:         parsedURL,
+		path:        filePath,
+		signal:      make(chan any, 1),
+		packetQueue: stream.NewPacketQueue(stream.DefaultQueueCapacity, stream.QueueBlock),
+		done:        make(chan struct{}),
+	}
+}
+
+func (c *Client) Dial() error {
+	log.Printf("[TRACE] dial: %s", c.url.String())
+	c.speed = 1
+	if value := c.url.Query().Get("speed"); len(value) > 0 {
+		speed, err := strconv.ParseFloat(value, 64)
+		if err != nil || speed < 0 {
+			return fmt.Errorf("invalid speed: %s", value)
+		}
+		c.speed = speed
+	}
+
+	file, err := os.Open(c.path)
+	if err != nil {
+		return err
+	}
+
+	if c.reader, err = NewReader(file); err != nil {
+		file.Close()
+		return err
+	}
+	c.file = file
+
+	return nil
+}
+
+func (c *Client) Close() {
+	log.Print("[TRACE] close")
+	c.once.Do(func() {
+		close(c.done)
+	})
+	c.packetQueue.Close()
+	if c.file != nil {
+		c.file.Close()
+	}
+}
+
+func (c *Client) CodecData() ([]stream.Codec, error) {
+	if c.reader == nil {
+		return nil, errors.New("not dialed")
+	}
+
+	go c.replay()
+
+	return c.reader.Codecs(), nil
+}
+
+func (c *Client) replay() {
+	start := time.Now()
+	for {
+		record, err := c.reader.ReadPacket()
+		if err != nil {
+			switch {
+			case errors.Is(err, io.EOF):
+				log.Print("[TRACE] end of trace")
+				c.packetQueue.Finish()
+			case errors.Is(err, io.ErrUnexpectedEOF):
+				log.Print("[TRACE] trace is truncated")
+				c.packetQueue.Finish()
+			default:
+				c.fail(err)
+			}
+			return
+		}
+
+		if c.speed > 0 {
+			select {
+			case <-time.After(time.Until(start.Add(time.Duration(float64(record.Arrival) / c.speed)))):
+			case <-c.done:
+				return
+			}
+		}
+
+		if !c.packetQueue.Push(&record.Packet) {
+			return
+		}
+	}
+}
+
+// fail reports the error the recorded stream ended with once the packets
+// before it have been consumed, as they were in the original session.
+func (c *Client) fail(err error) {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for c.packetQueue.Stats().Depth > 0 {
+		select {
+		case <-ticker.C:
+		case <-c.done:
+			return
+		}
+	}
+
+	select {
+	case <-c.done:
+	default:
+		c.signal <- err
+	}
+}
+
+func (c *Client) PacketQueue() *stream.PacketQueue {
+	return c.packetQueue
+}
+
+func (c *Client) CloseCh() <-chan any {
+	return c.signal
+}
+
+func (c *Client) Secure() (bool, bool, map[string]string) {
+	return false, false, nil
+}
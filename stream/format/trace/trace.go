@@ -0,0 +1,382 @@
+// Package trace records what a stream.Client delivers, the codecs and every
+// packet with its arrival time, so that a problematic stream can be replayed
+// later through the trace:// scheme.
+//
+// A trace file starts with a magic string followed by records. Each record is
+// a type byte, a big-endian uint32 body length and the body:
+//
+//	'C' codecs: JSON array of codec records
+//	'P' packet: idx(1) flags(1) arrival(8) time(8) cts(8) data
+//	'E' end:    the error that ended the stream, empty for a clean end
+//
+// Durations are nanoseconds as big-endian int64.
+package trace
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/codec/aac"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h264"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h265"
+)
+
+const magic = "PLAYGOTRACE1"
+
+const (
+	recordCodecs = 'C'
+	recordPacket = 'P'
+	recordEnd    = 'E'
+)
+
+const (
+	flagKeyFrame = 1 << iota
+)
+
+const (
+	packetHeaderSize = 26
+	maxRecordSize    = 64 << 20
+)
+
+type codecRecord struct {
+	Type string `json:"type"`
+	VPS  []byte `json:"vps,omitempty"`
+	SPS  []byte `json:"sps,omitempty"`
+	PPS  []byte `json:"pps,omitempty"`
+	ASC  []byte `json:"asc,omitempty"`
+}
+
+func encodeCodecs(codecs []stream.Codec) ([]byte, error) {
+	records := make([]codecRecord, 0, len(codecs))
+	for _, codec := range codecs {
+		switch codec := codec.(type) {
+		case *h264.Codec:
+			records = append(records, codecRecord{Type: "h264", SPS: codec.SPS, PPS: codec.PPS})
+		case *h265.Codec:
+			records = append(records, codecRecord{Type: "h265", VPS: codec.VPS, SPS: codec.SPS, PPS: codec.PPS})
+		case *aac.Codec:
+			records = append(records, codecRecord{Type: "aac", ASC: codec.ASC})
+		default:
+			return nil, fmt.Errorf("unsupported codec: %T", codec)
+		}
+	}
+
+	return json.Marshal(records)
+}
+
+func decodeCodecs(data []byte) ([]stream.Codec, error) {
+	var records []codecRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+
+	codecs := make([]stream.Codec, 0, len(records))
+	for _, record := range records {
+		switch record.Type {
+		case "h264":
+			codecs = append(codecs, &h264.Codec{SPS: record.SPS, PPS: record.PPS})
+		case "h265":
+			codecs = append(codecs, &h265.Codec{VPS: record.VPS, SPS: record.SPS, PPS: record.PPS})
+		case "aac":
+			codec := &aac.Codec{ASC: record.ASC}
+			if err := codec.Decode(); err != nil {
+				return nil, fmt.Errorf("invalid audio specific config: %w", err)
+			}
+			codecs = append(codecs, codec)
+		default:
+			return nil, fmt.Errorf("unsupported codec: %s", record.Type)
+		}
+	}
+
+	return codecs, nil
+}
+
+// recorderBuffer is the number of records queued for the writer goroutine.
+const recorderBuffer = 1024
+
+// recorderEntry is a record handed to the writer goroutine.
+type recorderEntry struct {
+	recordType byte
+	body       []byte
+	record     Record
+}
+
+// Recorder writes a trace file. Records are written by a goroutine of their
+// own, so that Tap does not wait for the disk. Packets passed to Tap before
+// the codecs are known are held back, because clients may start pushing while
+// CodecData is still running.
+type Recorder struct {
+	mu      sync.Mutex
+	closed  bool
+	codecs  bool
+	start   time.Time
+	entries chan recorderEntry
+	done    chan struct{}
+
+	// Owned by the writer goroutine until done is closed.
+	file    *os.File
+	w       *bufio.Writer
+	ready   bool
+	pending []Record
+	err     error
+}
+
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{
+		file:    file,
+		w:       bufio.NewWriterSize(file, 1<<20),
+		start:   time.Now(),
+		entries: make(chan recorderEntry, recorderBuffer),
+		done:    make(chan struct{}),
+	}
+	if _, err := r.w.WriteString(magic); err != nil {
+		file.Close()
+		return nil, err
+	}
+	go r.run()
+
+	return r, nil
+}
+
+// send hands an entry to the writer goroutine. It waits only when the writer
+// is a whole buffer behind.
+func (r *Recorder) send(entry recorderEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.closed {
+		r.entries <- entry
+	}
+}
+
+func (r *Recorder) run() {
+	defer close(r.done)
+
+	for entry := range r.entries {
+		switch entry.recordType {
+		case recordCodecs:
+			r.ready = true
+			r.writeRecord(recordCodecs, entry.body)
+			for _, record := range r.pending {
+				r.writePacket(record)
+			}
+			r.pending = nil
+		case recordPacket:
+			if !r.ready {
+				r.pending = append(r.pending, entry.record)
+				continue
+			}
+			r.writePacket(entry.record)
+		case recordEnd:
+			if r.ready {
+				r.writeRecord(recordEnd, entry.body)
+			}
+		}
+	}
+
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+	if err := r.file.Close(); r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *Recorder) writeRecord(recordType byte, body ...[]byte) {
+	if r.err != nil {
+		return
+	}
+
+	var size int
+	for _, b := range body {
+		size += len(b)
+	}
+
+	header := [5]byte{recordType}
+	binary.BigEndian.PutUint32(header[1:], uint32(size))
+	if _, r.err = r.w.Write(header[:]); r.err != nil {
+		return
+	}
+	for _, b := range body {
+		if _, r.err = r.w.Write(b); r.err != nil {
+			return
+		}
+	}
+}
+
+func (r *Recorder) writePacket(record Record) {
+	var header [packetHeaderSize]byte
+	header[0] = byte(record.Packet.Idx)
+	if record.Packet.IsKeyFrame {
+		header[1] |= flagKeyFrame
+	}
+	binary.BigEndian.PutUint64(header[2:], uint64(record.Arrival))
+	binary.BigEndian.PutUint64(header[10:], uint64(record.Packet.Time))
+	binary.BigEndian.PutUint64(header[18:], uint64(record.Packet.CompositionTime))
+	r.writeRecord(recordPacket, header[:], record.Packet.Data)
+}
+
+// WriteCodecs writes the codecs and the packets tapped so far. Write errors
+// are reported by Close.
+func (r *Recorder) WriteCodecs(codecs []stream.Codec) error {
+	data, err := encodeCodecs(codecs)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return errors.New("recorder closed")
+	}
+	if r.codecs {
+		return errors.New("codecs already written")
+	}
+	r.codecs = true
+	r.entries <- recorderEntry{recordType: recordCodecs, body: data}
+
+	return nil
+}
+
+// Tap records a packet as it arrives. It is meant to be installed with
+// PacketQueue.SetTap so that packets are captured before normalization.
+func (r *Recorder) Tap(packet stream.Packet) {
+	r.send(recorderEntry{
+		recordType: recordPacket,
+		record:     Record{Arrival: time.Since(r.start), Packet: packet},
+	})
+}
+
+// Close writes the end record with the reason the stream ended, nil for a
+// clean end, waits for the writer and closes the file.
+func (r *Recorder) Close(reason error) error {
+	var message []byte
+	if reason != nil {
+		message = []byte(reason.Error())
+	}
+
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		r.entries <- recorderEntry{recordType: recordEnd, body: message}
+		close(r.entries)
+	}
+	r.mu.Unlock()
+	<-r.done
+
+	return r.err
+}
+
+// Record is a packet of a trace with the time it arrived, relative to the
+// start of the capture.
+type Record struct {
+	Arrival time.Duration
+	Packet  stream.Packet
+}
+
+// Reader reads a trace file. ReadPacket returns io.EOF at a clean end and the
+// recorded error if the stream ended with one.
+type Reader struct {
+	r      *bufio.Reader
+	codecs []stream.Codec
+}
+
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{r: bufio.NewReader(r)}
+
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(reader.r, header); err != nil {
+		return nil, fmt.Errorf("invalid trace: %w", err)
+	}
+	if string(header) != magic {
+		return nil, errors.New("invalid trace: bad magic")
+	}
+
+	recordType, body, err := reader.readRecord()
+	if err != nil {
+		return nil, err
+	}
+	if recordType != recordCodecs {
+		return nil, errors.New("invalid trace: missing codecs")
+	}
+	if reader.codecs, err = decodeCodecs(body); err != nil {
+		return nil, fmt.Errorf("invalid trace: %w", err)
+	}
+
+	return reader, nil
+}
+
+func (r *Reader) readRecord() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxRecordSize {
+		return 0, nil, fmt.Errorf("invalid trace: record too large: %d", size)
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r.r, body); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+
+	return header[0], body, nil
+}
+
+func (r *Reader) Codecs() []stream.Codec {
+	return r.codecs
+}
+
+func (r *Reader) ReadPacket() (Record, error) {
+	for {
+		recordType, body, err := r.readRecord()
+		if err != nil {
+			return Record{}, err
+		}
+
+		switch recordType {
+		case recordPacket:
+			if len(body) < packetHeaderSize {
+				return Record{}, errors.New("invalid trace: short packet")
+			}
+			return Record{
+				Arrival: time.Duration(binary.BigEndian.Uint64(body[2:])),
+				Packet: stream.Packet{
+					Idx:             int8(body[0]),
+					IsKeyFrame:      body[1]&flagKeyFrame != 0,
+					Time:            time.Duration(binary.BigEndian.Uint64(body[10:])),
+					CompositionTime: time.Duration(binary.BigEndian.Uint64(body[18:])),
+					Data:            body[packetHeaderSize:],
+				},
+			}, nil
+		case recordEnd:
+			if len(body) > 0 {
+				return Record{}, errors.New(string(body))
+			}
+			return Record{}, io.EOF
+		}
+		// Unknown records are skipped so that newer traces stay readable.
+	}
+}
@@ -0,0 +1,124 @@
+package trace
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/protocol/testsrc"
+)
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.trace")
+	r, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedURL, _ := url.Parse("testsrc://bars?size=240p&fps=10&duration=2s&realtime=0&jump=1s/1h")
+	c := testsrc.New(parsedURL)
+	if err = c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.PacketQueue().SetTap(r.Tap)
+
+	codecs, err := c.CodecData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Packets tapped before the codecs are written are kept.
+	first := <-c.PacketQueue().Chan()
+	if err = r.WriteCodecs(codecs); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.WriteCodecs(codecs); err == nil {
+		t.Error("codecs written twice")
+	}
+
+	count := 1
+	for range c.PacketQueue().Chan() {
+		count++
+	}
+	if err = r.Close(errors.New("test end")); err != nil {
+		t.Fatal(err)
+	}
+	r.Tap(*first)
+	if err = r.Close(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	reader, err := NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reader.Codecs()) != len(codecs) {
+		t.Fatalf("codecs = %v", reader.Codecs())
+	}
+
+	var records []Record
+	for {
+		record, err := reader.ReadPacket()
+		if err != nil {
+			if errors.Is(err, io.EOF) || err.Error() != "test end" {
+				t.Fatalf("end = %v, want the recorded error", err)
+			}
+			break
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != count {
+		t.Fatalf("read %d packets, want %d", len(records), count)
+	}
+
+	// The tap sees the timestamps before normalization, jump included.
+	var jumped bool
+	for i, record := range records {
+		if i > 0 && record.Arrival < records[i-1].Arrival {
+			t.Errorf("record %d arrived before the previous one", i)
+		}
+		if record.Packet.Time >= time.Hour {
+			jumped = true
+		}
+	}
+	if !jumped {
+		t.Error("trace has normalized timestamps")
+	}
+	if records[0].Packet.Time != first.Time || records[0].Packet.Idx != first.Idx || len(records[0].Packet.Data) != len(first.Data) {
+		t.Errorf("first record = %+v", records[0].Packet)
+	}
+}
+
+func TestRecorderCloseWithoutCodecs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.trace")
+	r, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Tap(stream.Packet{Data: []byte{1}})
+	if err = r.Close(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != magic {
+		t.Errorf("trace = %q, want only the magic", data)
+	}
+}
@@ -3,7 +3,7 @@ package ts
 import (
 	"bytes"
 	"encoding/binary"
-	"fmt"
+	"errors"
 	"io"
 	"log"
 	"slices"
@@ -19,8 +19,9 @@ import (
 )
 
 type Demuxer struct {
-	r *mpegts.Reader
-	q []stream.Packet
+	r       *mpegts.Reader
+	q       []stream.Packet
+	skipped []stream.SkippedTrack
 }
 
 func NewDemuxer(r io.Reader) *Demuxer {
@@ -34,17 +35,26 @@ func (d *Demuxer) CodecData() ([]stream.Codec, error) {
 		return nil, err
 	}
 
-	tracks := d.r.Tracks()
-	result := make([]stream.Codec, len(tracks))
-	for i, track := range tracks {
+	var result []stream.Codec
+	for i, track := range d.r.Tracks() {
 		log.Printf("[MPEG-TS] on track %d: %T", i, track.Codec)
+		switch track.Codec.(type) {
+		case *codecs.H264, *codecs.H265, *codecs.MPEG4Audio:
+		default:
+			log.Printf("[MPEG-TS] skip track %d: %T", i, track.Codec)
+			d.skipped = append(d.skipped, stream.SkippedTrack{Index: i, Codec: stream.CodecName(track.Codec)})
+			continue
+		}
+
+		idx := len(result)
+		result = append(result, nil)
 		switch codec := track.Codec.(type) {
 		case *codecs.H264:
 			h264Codec := &h264.Codec{}
 			d.r.OnDataH264(track, func(pts, dts int64, au [][]byte) error {
 				isKeyFrame, data := h264Codec.ParseAU(au)
-				if result[i] == nil && h264Codec.SPS != nil && h264Codec.PPS != nil {
-					result[i] = h264Codec
+				if result[idx] == nil && h264Codec.SPS != nil && h264Codec.PPS != nil {
+					result[idx] = h264Codec
 					log.Printf("[MPEG-TS] track %d: H264 codec ready", i)
 				}
 
@@ -52,7 +62,7 @@ func (d *Demuxer) CodecData() ([]stream.Codec, error) {
 					pts := time.Duration(pts) * time.Second / time.Duration(90000)
 					dts := time.Duration(dts) * time.Second / time.Duration(90000)
 					d.q = append(d.q, stream.Packet{
-						Idx:             int8(i),
+						Idx:             int8(idx),
 						IsKeyFrame:      isKeyFrame,
 						CompositionTime: pts - dts,
 						Time:            dts,
@@ -88,8 +98,8 @@ func (d *Demuxer) CodecData() ([]stream.Codec, error) {
 					}
 				}
 
-				if result[i] == nil && h265Codec.VPS != nil && h265Codec.SPS != nil && h265Codec.PPS != nil {
-					result[i] = h265Codec
+				if result[idx] == nil && h265Codec.VPS != nil && h265Codec.SPS != nil && h265Codec.PPS != nil {
+					result[idx] = h265Codec
 					log.Printf("[MPEG-TS] track %d: H265 codec ready", i)
 				}
 
@@ -97,7 +107,7 @@ func (d *Demuxer) CodecData() ([]stream.Codec, error) {
 					pts := time.Duration(pts) * time.Second / time.Duration(90000)
 					dts := time.Duration(dts) * time.Second / time.Duration(90000)
 					d.q = append(d.q, stream.Packet{
-						Idx:             int8(i),
+						Idx:             int8(idx),
 						IsKeyFrame:      isKeyFrame,
 						CompositionTime: pts - dts,
 						Time:            dts,
@@ -112,14 +122,14 @@ func (d *Demuxer) CodecData() ([]stream.Codec, error) {
 			if err != nil {
 				return nil, err
 			}
-			result[i] = &aac.Codec{ASC: asc, Config: codec.Config}
+			result[idx] = &aac.Codec{ASC: asc, Config: codec.Config}
 			log.Printf("[MPEG-TS] track %d: AAC codec ready", i)
 
 			d.r.OnDataMPEG4Audio(track, func(pts int64, aus [][]byte) error {
 				for j, au := range aus {
 					delta := time.Duration(j) * aac.SamplesPerAccessUnit * time.Second / time.Duration(codec.Config.SampleRate)
 					d.q = append(d.q, stream.Packet{
-						Idx:  int8(i),
+						Idx:  int8(idx),
 						Time: (time.Duration(pts) * time.Second / time.Duration(90000)) + delta,
 						Data: au,
 					})
@@ -127,11 +137,13 @@ func (d *Demuxer) CodecData() ([]stream.Codec, error) {
 
 				return nil
 			})
-		default:
-			return nil, fmt.Errorf("unsupported codec: %T", track.Codec)
 		}
 	}
 
+	if len(result) <= 0 {
+		return nil, errors.New("no supported tracks")
+	}
+
 	for !stream.IsCodecReady(result) {
 		if err := d.r.Read(); err != nil {
 			return nil, err
@@ -141,6 +153,10 @@ func (d *Demuxer) CodecData() ([]stream.Codec, error) {
 	return result, nil
 }
 
+func (d *Demuxer) SkippedTracks() []stream.SkippedTrack {
+	return d.skipped
+}
+
 func (d *Demuxer) ReadPacket() (stream.Packet, error) {
 	for len(d.q) <= 0 {
 		if err := d.r.Read(); err != nil {
@@ -0,0 +1,115 @@
+package ts
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/codec/aac"
+	"github.com/jaesung9507/playgo/stream/codec/h26x"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h264"
+	"github.com/jaesung9507/playgo/stream/internal/streamtest"
+	"github.com/jaesung9507/playgo/stream/output"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/mpegts"
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/mpegts/codecs"
+)
+
+// mux writes a test pattern as MPEG-TS after a KLV metadata PID, which the
+// demuxer does not play.
+func mux(t *testing.T, withPattern bool) []byte {
+	t.Helper()
+
+	klv := &mpegts.Track{Codec: &codecs.KLV{Synchronous: true}}
+	tracks := []*mpegts.Track{klv}
+
+	var packets []stream.Packet
+	if withPattern {
+		var streamCodecs []stream.Codec
+		streamCodecs, packets = streamtest.Collect(t, "testsrc://bars?size=240p&fps=10&gop=5&duration=1s&realtime=0")
+		tracks = append(tracks,
+			&mpegts.Track{Codec: &codecs.H264{}},
+			&mpegts.Track{Codec: &codecs.MPEG4Audio{Config: streamCodecs[1].(*aac.Codec).Config}},
+		)
+	}
+
+	var buf bytes.Buffer
+	w := &mpegts.Writer{W: &buf, Tracks: tracks}
+	if err := w.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.WriteKLV(klv, 0, []byte{0x06, 0x0e, 0x2b, 0x34, 0x01, 0x00}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, packet := range packets {
+		ticks := output.Ticks(packet.Time, 90000)
+		switch packet.Idx {
+		case 0:
+			var au h26x.AVCC
+			if err := au.Unmarshal(packet.Data); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteH264(tracks[1], ticks, ticks, au); err != nil {
+				t.Fatal(err)
+			}
+		case 1:
+			if err := w.WriteMPEG4Audio(tracks[2], ticks, [][]byte{packet.Data}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	return buf.Bytes()
+}
+
+func TestDemuxerSkipsUnknownTracks(t *testing.T) {
+	d := NewDemuxer(bytes.NewReader(mux(t, true)))
+	result, err := d.CodecData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result) != 2 {
+		t.Fatalf("codecs = %v", result)
+	}
+	if _, ok := result[0].(*h264.Codec); !ok {
+		t.Errorf("track 0 is %T", result[0])
+	}
+	if _, ok := result[1].(*aac.Codec); !ok {
+		t.Errorf("track 1 is %T", result[1])
+	}
+	if skipped := d.SkippedTracks(); !reflect.DeepEqual(skipped, []stream.SkippedTrack{{Index: 0, Codec: "KLV"}}) {
+		t.Errorf("skipped = %v", skipped)
+	}
+
+	count := map[int8]int{}
+	for {
+		packet, err := d.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		count[packet.Idx]++
+	}
+
+	// The last video frame stays buffered until a next one would start.
+	if len(count) != 2 || count[0] < 9 || count[1] == 0 {
+		t.Errorf("packets per track = %v", count)
+	}
+}
+
+func TestDemuxerNoSupportedTracks(t *testing.T) {
+	d := NewDemuxer(bytes.NewReader(mux(t, false)))
+	if _, err := d.CodecData(); err == nil || err.Error() != "no supported tracks" {
+		t.Fatalf("got %v", err)
+	}
+	if skipped := d.SkippedTracks(); len(skipped) != 1 {
+		t.Errorf("skipped = %v", skipped)
+	}
+}
@@ -0,0 +1,30 @@
+// Package streamtest holds helpers shared by the tests of the stream
+// packages.
+package streamtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+)
+
+// Rewrite is a transport that sends every request to a local server,
+// keeping the path and query, so that clients with fixed endpoints can be
+// tested against an httptest.Server.
+type Rewrite struct {
+	Target *url.URL
+}
+
+func (r Rewrite) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = r.Target.Scheme
+	req.URL.Host = r.Target.Host
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// Client returns an HTTP client whose requests are all served by srv.
+func Client(srv *httptest.Server) *http.Client {
+	target, _ := url.Parse(srv.URL)
+	return &http.Client{Transport: Rewrite{Target: target}}
+}
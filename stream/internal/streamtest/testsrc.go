@@ -0,0 +1,73 @@
+package streamtest
+
+import (
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/protocol/testsrc"
+)
+
+// Dial starts a test pattern and returns it with its codecs. The client is
+// closed when the test ends.
+func Dial(t *testing.T, rawURL string) (*testsrc.Client, []stream.Codec) {
+	t.Helper()
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := testsrc.New(parsedURL)
+	if err = c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+
+	codecs, err := c.CodecData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c, codecs
+}
+
+// Collect returns the codecs and packets of a test pattern, as delivered by
+// its packet queue until the stream ends.
+func Collect(t *testing.T, rawURL string) ([]stream.Codec, []stream.Packet) {
+	t.Helper()
+
+	c, codecs := Dial(t, rawURL)
+	defer c.Close()
+
+	var packets []stream.Packet
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case packet, ok := <-c.PacketQueue().Chan():
+			if !ok {
+				return codecs, packets
+			}
+			packets = append(packets, *packet)
+		case err := <-c.CloseCh():
+			t.Fatalf("stream closed: %v", err)
+		case <-timeout:
+			t.Fatal("timeout")
+		}
+	}
+}
+
+// FreeAddress returns a local TCP address that nothing listens on.
+func FreeAddress(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	return l.Addr().String()
+}
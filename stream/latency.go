@@ -0,0 +1,64 @@
+package stream
+
+import (
+	"time"
+)
+
+const DefaultLatencyTarget = 1 * time.Second
+
+// LatencyMonitor measures how far packets lag behind the live edge.
+//
+// The earliest arrival seen so far, relative to each track's media time, is
+// taken as the live edge; the drift of a packet is how much later it arrived
+// than that edge predicts.
+type LatencyMonitor struct {
+	target time.Duration
+	tracks map[int8]*latencyBase
+}
+
+type latencyBase struct {
+	wall time.Time
+	time time.Duration
+}
+
+func NewLatencyMonitor(target time.Duration) *LatencyMonitor {
+	if target <= 0 {
+		target = DefaultLatencyTarget
+	}
+
+	return &LatencyMonitor{
+		target: target,
+		tracks: make(map[int8]*latencyBase),
+	}
+}
+
+func (m *LatencyMonitor) Target() time.Duration {
+	return m.target
+}
+
+func (m *LatencyMonitor) Drift(packet *Packet) time.Duration {
+	now := time.Now()
+	base, ok := m.tracks[packet.Idx]
+	if !ok {
+		m.tracks[packet.Idx] = &latencyBase{wall: now, time: packet.Time}
+		return 0
+	}
+
+	drift := now.Sub(base.wall) - (packet.Time - base.time)
+	if drift < 0 {
+		base.wall, base.time = now, packet.Time
+		return 0
+	}
+
+	return drift
+}
+
+// IsBehind reports whether the packet lags the live edge by more than the
+// latency target.
+func (m *LatencyMonitor) IsBehind(packet *Packet) bool {
+	return m.Drift(packet) > m.target
+}
+
+func (m *LatencyMonitor) Reset() {
+	clear(m.tracks)
+}
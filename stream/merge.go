@@ -0,0 +1,161 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// MergedClient plays several clients as one, e.g. the separate video and
+// audio representations of a DASH stream. Their tracks are appended in order
+// and their packets are interleaved by timestamp. The clients' queues pass
+// the original timestamps through, so that they are interleaved on the
+// common timeline and normalized once by the merged queue.
+type MergedClient struct {
+	clients     []Client
+	packetQueue *PacketQueue
+	signal      chan any
+	done        chan struct{}
+	once        sync.Once
+}
+
+func NewMergedClient(clients ...Client) *MergedClient {
+	for _, client := range clients {
+		client.PacketQueue().SetNormalize(false)
+	}
+
+	return &MergedClient{
+		clients:     clients,
+		packetQueue: NewPacketQueue(DefaultQueueCapacity, QueueBlock),
+		signal:      make(chan any, 1),
+		done:        make(chan struct{}),
+	}
+}
+
+func (c *MergedClient) Dial() error {
+	if len(c.clients) == 0 {
+		return errors.New("no clients to merge")
+	}
+
+	ch := make(chan error, len(c.clients))
+	for _, client := range c.clients {
+		go func() {
+			ch <- client.Dial()
+		}()
+	}
+
+	var errs []error
+	for range c.clients {
+		if err := <-ch; err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *MergedClient) Close() {
+	log.Print("[MERGE] close")
+	c.once.Do(func() {
+		close(c.done)
+	})
+	c.packetQueue.Close()
+	for _, client := range c.clients {
+		client.Close()
+	}
+}
+
+func (c *MergedClient) CodecData() ([]Codec, error) {
+	var (
+		codecs  []Codec
+		offsets []int8
+	)
+	for i, client := range c.clients {
+		clientCodecs, err := client.CodecData()
+		if err != nil {
+			return nil, fmt.Errorf("client %d: %w", i, err)
+		}
+		offsets = append(offsets, int8(len(codecs)))
+		codecs = append(codecs, clientCodecs...)
+	}
+
+	for _, client := range c.clients {
+		go c.forwardSignal(client)
+	}
+	go c.merge(offsets)
+
+	return codecs, nil
+}
+
+func (c *MergedClient) forwardSignal(client Client) {
+	select {
+	case sig := <-client.CloseCh():
+		select {
+		case c.signal <- sig:
+		default:
+		}
+	case <-c.done:
+	}
+}
+
+// merge pushes the earliest head packet among the clients until all of them
+// have finished. Waiting for every client to have a packet keeps the output
+// in timestamp order.
+func (c *MergedClient) merge(offsets []int8) {
+	heads := make([]*Packet, len(c.clients))
+	finished := make([]bool, len(c.clients))
+	for {
+		for i, client := range c.clients {
+			if heads[i] != nil || finished[i] {
+				continue
+			}
+
+			select {
+			case packet, ok := <-client.PacketQueue().Chan():
+				if !ok {
+					finished[i] = true
+					continue
+				}
+				packet.Idx += offsets[i]
+				heads[i] = packet
+			case <-c.done:
+				return
+			}
+		}
+
+		next := -1
+		for i, packet := range heads {
+			if packet != nil && (next < 0 || packet.Time < heads[next].Time) {
+				next = i
+			}
+		}
+
+		if next < 0 {
+			log.Print("[MERGE] all clients finished")
+			c.packetQueue.Finish()
+			return
+		}
+
+		if !c.packetQueue.Push(heads[next]) {
+			return
+		}
+		heads[next] = nil
+	}
+}
+
+func (c *MergedClient) PacketQueue() *PacketQueue {
+	return c.packetQueue
+}
+
+func (c *MergedClient) CloseCh() <-chan any {
+	return c.signal
+}
+
+func (c *MergedClient) Secure() (bool, bool, map[string]string) {
+	if len(c.clients) > 0 {
+		return c.clients[0].Secure()
+	}
+
+	return false, false, nil
+}
@@ -0,0 +1,59 @@
+package stream_test
+
+import (
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/protocol/testsrc"
+)
+
+func TestMergedClientNormalizesOnce(t *testing.T) {
+	var clients []stream.Client
+	for _, query := range []string{"audio=0&duration=2s", "audio=0&duration=2s&jump=0s/1m"} {
+		parsedURL, _ := url.Parse("testsrc://bars?size=240p&fps=10&realtime=0&" + query)
+		clients = append(clients, testsrc.New(parsedURL))
+	}
+
+	c := stream.NewMergedClient(clients...)
+	if err := c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+
+	var mu sync.Mutex
+	tapped := make(map[int8][]time.Duration)
+	c.PacketQueue().SetTap(func(packet stream.Packet) {
+		mu.Lock()
+		defer mu.Unlock()
+		tapped[packet.Idx] = append(tapped[packet.Idx], packet.Time)
+	})
+
+	if _, err := c.CodecData(); err != nil {
+		t.Fatal(err)
+	}
+	packets := drain(t, c.PacketQueue())
+	if len(packets) != 40 {
+		t.Fatalf("got %d packets", len(packets))
+	}
+	checkTimeline(t, packets, 110*time.Millisecond)
+
+	// The inner queues pass the timestamps through, so the tap sees the
+	// original ones and the merged queue rebases the late track once.
+	for _, client := range clients {
+		if stats := client.PacketQueue().TimestampStats(); stats != (stream.TimestampStats{}) {
+			t.Errorf("inner queue normalized: %+v", stats)
+		}
+	}
+	if stats := c.PacketQueue().TimestampStats(); stats.Rebased != 1 {
+		t.Errorf("merged stats = %+v, want one rebased track", stats)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(tapped[0]) != 20 || len(tapped[1]) != 20 || tapped[0][0] != 0 || tapped[1][0] != time.Minute {
+		t.Errorf("tapped %d and %d packets, starting at %v and %v", len(tapped[0]), len(tapped[1]), tapped[0][0], tapped[1][0])
+	}
+}
@@ -0,0 +1,133 @@
+package stream
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxTrackSkew is how far the first timestamp of a track may be from the
+	// common zero before the track is considered to use its own time base.
+	maxTrackSkew = 10 * time.Second
+	// maxJump is the largest forward step treated as a gap rather than a
+	// timestamp discontinuity.
+	maxJump = 5 * time.Second
+	// gapThreshold is the smallest forward step reported as a gap.
+	gapThreshold = 1 * time.Second
+
+	defaultStep = 10 * time.Millisecond
+)
+
+type TimestampStats struct {
+	Rebased      uint64
+	NonMonotonic uint64
+	NegativeCTS  uint64
+	Jumps        uint64
+	Gaps         uint64
+	Clamped      uint64
+}
+
+// normalizerStats are counted atomically, so that they can be read while the
+// producer is normalizing.
+type normalizerStats struct {
+	rebased      atomic.Uint64
+	nonMonotonic atomic.Uint64
+	negativeCTS  atomic.Uint64
+	jumps        atomic.Uint64
+	gaps         atomic.Uint64
+	clamped      atomic.Uint64
+}
+
+type normalizerTrack struct {
+	offset time.Duration
+	last   time.Duration
+	step   time.Duration
+}
+
+// Normalizer rewrites packet timestamps so that every client delivers the
+// same timeline: all tracks start from a common zero, decode times increase
+// monotonically per track, composition offsets are not negative, and
+// discontinuities are stitched over. The common zero is the first packet of
+// the stream; a track that starts slightly earlier is clamped to zero until it
+// catches up.
+type Normalizer struct {
+	started bool
+	base    time.Duration
+	lastOut time.Duration
+	tracks  map[int8]*normalizerTrack
+	stats   normalizerStats
+}
+
+func NewNormalizer() *Normalizer {
+	return &Normalizer{
+		tracks: make(map[int8]*normalizerTrack),
+	}
+}
+
+func (n *Normalizer) addTrack(packet *Packet) *normalizerTrack {
+	if !n.started {
+		n.started = true
+		n.base = packet.Time
+	}
+
+	track := &normalizerTrack{offset: n.base, step: defaultStep}
+	if skew := packet.Time - n.base; skew > maxTrackSkew || skew < -maxTrackSkew {
+		track.offset = packet.Time - n.lastOut
+		n.stats.rebased.Add(1)
+		log.Printf("[NORMALIZER] track %d: rebase, skew=%v", packet.Idx, skew)
+	}
+	n.tracks[packet.Idx] = track
+
+	return track
+}
+
+func (n *Normalizer) Normalize(packet *Packet) {
+	track, ok := n.tracks[packet.Idx]
+	if !ok {
+		track = n.addTrack(packet)
+		packet.Time -= track.offset
+	} else {
+		t := packet.Time - track.offset
+		switch delta := t - track.last; {
+		case delta > maxJump || delta < -maxJump:
+			track.offset += delta - track.step
+			t = track.last + track.step
+			n.stats.jumps.Add(1)
+			log.Printf("[NORMALIZER] track %d: timestamp jump of %v", packet.Idx, delta)
+		case delta <= 0:
+			t = track.last + time.Millisecond
+			packet.CompositionTime = max(packet.CompositionTime+delta-time.Millisecond, 0)
+			n.stats.nonMonotonic.Add(1)
+		case delta > gapThreshold:
+			n.stats.gaps.Add(1)
+			log.Printf("[NORMALIZER] track %d: gap of %v", packet.Idx, delta)
+		default:
+			track.step = delta
+		}
+		packet.Time = t
+	}
+	if packet.Time < 0 {
+		packet.CompositionTime = max(packet.CompositionTime+packet.Time, 0)
+		packet.Time = 0
+		n.stats.clamped.Add(1)
+	}
+	track.last = packet.Time
+	n.lastOut = max(n.lastOut, packet.Time)
+
+	if packet.CompositionTime < 0 {
+		packet.CompositionTime = 0
+		n.stats.negativeCTS.Add(1)
+	}
+}
+
+func (n *Normalizer) Stats() TimestampStats {
+	return TimestampStats{
+		Rebased:      n.stats.rebased.Load(),
+		NonMonotonic: n.stats.nonMonotonic.Load(),
+		NegativeCTS:  n.stats.negativeCTS.Load(),
+		Jumps:        n.stats.jumps.Load(),
+		Gaps:         n.stats.gaps.Load(),
+		Clamped:      n.stats.clamped.Load(),
+	}
+}
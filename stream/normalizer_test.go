@@ -0,0 +1,75 @@
+package stream_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/internal/streamtest"
+)
+
+// checkTimeline checks that decode times increase per track and that no step
+// is larger than maxStep.
+func checkTimeline(t *testing.T, packets []*stream.Packet, maxStep time.Duration) {
+	t.Helper()
+
+	last := make(map[int8]time.Duration)
+	for _, packet := range packets {
+		if prev, ok := last[packet.Idx]; ok {
+			if packet.Time <= prev {
+				t.Errorf("track %d: %v after %v", packet.Idx, packet.Time, prev)
+			} else if packet.Time-prev > maxStep {
+				t.Errorf("track %d: step of %v at %v", packet.Idx, packet.Time-prev, prev)
+			}
+		}
+		last[packet.Idx] = packet.Time
+		if packet.CompositionTime < 0 {
+			t.Errorf("track %d: negative composition time at %v", packet.Idx, packet.Time)
+		}
+	}
+}
+
+func TestNormalizerJumps(t *testing.T) {
+	for _, jump := range []string{"1s/1h", "1s/-1h"} {
+		c, _ := streamtest.Dial(t, pattern+"duration=3s&jump="+jump)
+		packets := drain(t, c.PacketQueue())
+		checkTimeline(t, packets, 110*time.Millisecond)
+
+		stats := c.PacketQueue().TimestampStats()
+		if stats.Jumps != 2 || stats.Gaps != 0 {
+			t.Errorf("jump %s: stats = %+v, want a jump per track", jump, stats)
+		}
+		if last := packets[len(packets)-1].Time; last > 3*time.Second {
+			t.Errorf("jump %s: stream ends at %v", jump, last)
+		}
+	}
+}
+
+func TestNormalizerGaps(t *testing.T) {
+	c, _ := streamtest.Dial(t, pattern+"duration=4s&gap=1s/2s")
+	packets := drain(t, c.PacketQueue())
+	checkTimeline(t, packets, 2100*time.Millisecond)
+
+	stats := c.PacketQueue().TimestampStats()
+	if stats.Gaps != 2 || stats.Jumps != 0 {
+		t.Errorf("stats = %+v, want a gap per track", stats)
+	}
+
+	// A gap is kept on the timeline.
+	if last := packets[len(packets)-1].Time; last < 3900*time.Millisecond {
+		t.Errorf("stream ends at %v", last)
+	}
+}
+
+func TestNormalizerStartsAtZero(t *testing.T) {
+	c, _ := streamtest.Dial(t, pattern+"duration=1s&jump=0s/10m")
+	packets := drain(t, c.PacketQueue())
+	checkTimeline(t, packets, 110*time.Millisecond)
+
+	if first := packets[0].Time; first != 0 {
+		t.Errorf("first packet at %v", first)
+	}
+	if stats := c.PacketQueue().TimestampStats(); stats != (stream.TimestampStats{}) {
+		t.Errorf("stats = %+v", stats)
+	}
+}
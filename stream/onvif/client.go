@@ -0,0 +1,268 @@
+package onvif
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 10 * time.Second
+
+// Profile is a media profile of a camera with the URLs to play it.
+type Profile struct {
+	Token       string  `json:"token"`
+	Name        string  `json:"name"`
+	Encoding    string  `json:"encoding"`
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	FrameRate   float64 `json:"frameRate"`
+	StreamURI   string  `json:"streamUri"`
+	SnapshotURI string  `json:"snapshotUri"`
+}
+
+// Client calls the device and media services of a camera. Requests are
+// signed with a WS-Security UsernameToken when a username is set.
+type Client struct {
+	xaddr      string
+	media      string
+	username   string
+	password   string
+	offset     time.Duration
+	httpClient *http.Client
+}
+
+// NewClient returns a client for the device service address of a camera,
+// e.g. http://192.168.0.10/onvif/device_service.
+func NewClient(xaddr, username, password string) *Client {
+	return &Client{
+		xaddr:      xaddr,
+		username:   username,
+		password:   password,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (c *Client) call(ctx context.Context, endpoint, action, body string, result any) error {
+	var header string
+	if len(c.username) > 0 {
+		header = usernameToken(c.username, c.password, time.Now().Add(c.offset))
+	}
+
+	return call(ctx, c.httpClient, endpoint, action, envelope(header, body), result)
+}
+
+// syncTime measures how far the clock of the camera is off, since cameras
+// reject tokens that were created too far from their own time.
+func (c *Client) syncTime(ctx context.Context) error {
+	var resp struct {
+		UTC struct {
+			Date struct {
+				Year  int `xml:"Year"`
+				Month int `xml:"Month"`
+				Day   int `xml:"Day"`
+			} `xml:"Date"`
+			Time struct {
+				Hour   int `xml:"Hour"`
+				Minute int `xml:"Minute"`
+				Second int `xml:"Second"`
+			} `xml:"Time"`
+		} `xml:"SystemDateAndTime>UTCDateTime"`
+	}
+
+	err := call(ctx, c.httpClient, c.xaddr, nsDevice+"/GetSystemDateAndTime",
+		envelope("", `<tds:GetSystemDateAndTime/>`), &resp)
+	if err != nil {
+		return err
+	}
+
+	d, t := resp.UTC.Date, resp.UTC.Time
+	if d.Year <= 0 {
+		return nil
+	}
+	device := time.Date(d.Year, time.Month(d.Month), d.Day, t.Hour, t.Minute, t.Second, 0, time.UTC)
+	c.offset = time.Until(device)
+
+	return nil
+}
+
+func (c *Client) mediaAddr(ctx context.Context) (string, error) {
+	if len(c.media) > 0 {
+		return c.media, nil
+	}
+
+	var resp struct {
+		XAddr string `xml:"Capabilities>Media>XAddr"`
+	}
+	err := c.call(ctx, c.xaddr, nsDevice+"/GetCapabilities",
+		`<tds:GetCapabilities><tds:Category>Media</tds:Category></tds:GetCapabilities>`, &resp)
+	if err != nil {
+		return "", err
+	}
+
+	c.media = c.xaddr
+	if len(resp.XAddr) > 0 {
+		c.media = c.sameHost(resp.XAddr)
+	}
+
+	return c.media, nil
+}
+
+// sameHost replaces the host of a service address with the host the device
+// was reached at, as cameras behind NAT report their internal address.
+func (c *Client) sameHost(addr string) string {
+	device, err := url.Parse(c.xaddr)
+	if err != nil {
+		return addr
+	}
+	service, err := url.Parse(addr)
+	if err != nil {
+		return addr
+	}
+	service.Host = device.Host
+
+	return service.String()
+}
+
+// sameHostname replaces the hostname of a stream or snapshot URI with the one
+// the device was reached at. Unlike sameHost it keeps the port of the URI,
+// which belongs to the RTSP or HTTP server of the camera rather than to its
+// ONVIF service.
+func (c *Client) sameHostname(uri string) string {
+	device, err := url.Parse(c.xaddr)
+	if err != nil || len(device.Hostname()) <= 0 {
+		return uri
+	}
+	u, err := url.Parse(uri)
+	if err != nil || len(u.Host) <= 0 {
+		return uri
+	}
+
+	if port := u.Port(); len(port) > 0 {
+		u.Host = net.JoinHostPort(device.Hostname(), port)
+	} else if strings.Contains(device.Hostname(), ":") {
+		u.Host = "[" + device.Hostname() + "]"
+	} else {
+		u.Host = device.Hostname()
+	}
+
+	return u.String()
+}
+
+// Profiles returns the media profiles of the camera with their RTSP stream
+// URIs and, where the camera has them, snapshot URIs.
+func (c *Client) Profiles(ctx context.Context) ([]Profile, error) {
+	log.Printf("[ONVIF] profiles: %s", c.xaddr)
+	if err := c.syncTime(ctx); err != nil {
+		log.Printf("[ONVIF] failed to get system time: %v", err)
+	} else if c.offset.Abs() > time.Second {
+		log.Printf("[ONVIF] clock offset: %v", c.offset.Round(time.Second))
+	}
+
+	media, err := c.mediaAddr(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Profiles []struct {
+			Token   string `xml:"token,attr"`
+			Name    string `xml:"Name"`
+			Encoder *struct {
+				Encoding   string `xml:"Encoding"`
+				Resolution struct {
+					Width  int `xml:"Width"`
+					Height int `xml:"Height"`
+				} `xml:"Resolution"`
+				FrameRate float64 `xml:"RateControl>FrameRateLimit"`
+			} `xml:"VideoEncoderConfiguration"`
+		} `xml:"Profiles"`
+	}
+	if err := c.call(ctx, media, nsMedia+"/GetProfiles", `<trt:GetProfiles/>`, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Profiles) <= 0 {
+		return nil, errors.New("onvif: no media profiles")
+	}
+
+	// A profile without a stream, e.g. of audio or metadata only, is left
+	// out instead of hiding the others.
+	var uriErr error
+	profiles := make([]Profile, 0, len(resp.Profiles))
+	for _, p := range resp.Profiles {
+		profile := Profile{Token: p.Token, Name: p.Name}
+		if p.Encoder != nil {
+			profile.Encoding = p.Encoder.Encoding
+			profile.Width = p.Encoder.Resolution.Width
+			profile.Height = p.Encoder.Resolution.Height
+			profile.FrameRate = p.Encoder.FrameRate
+		}
+
+		profile.StreamURI, err = c.StreamURI(ctx, p.Token)
+		if err != nil {
+			log.Printf("[ONVIF] skip profile %s: %v", p.Token, err)
+			uriErr = err
+			continue
+		}
+		if profile.SnapshotURI, err = c.SnapshotURI(ctx, p.Token); err != nil {
+			log.Printf("[ONVIF] failed to get snapshot uri of %s: %v", p.Token, err)
+		}
+		profiles = append(profiles, profile)
+	}
+	if len(profiles) <= 0 {
+		return nil, fmt.Errorf("onvif: no profile has a stream uri: %w", uriErr)
+	}
+
+	return profiles, nil
+}
+
+// StreamURI returns the RTSP URI of a media profile, with the host the
+// device was reached at.
+func (c *Client) StreamURI(ctx context.Context, token string) (string, error) {
+	media, err := c.mediaAddr(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		URI string `xml:"MediaUri>Uri"`
+	}
+	err = c.call(ctx, media, nsMedia+"/GetStreamUri",
+		`<trt:GetStreamUri>`+
+			`<trt:StreamSetup><tt:Stream>RTP-Unicast</tt:Stream><tt:Transport><tt:Protocol>RTSP</tt:Protocol></tt:Transport></trt:StreamSetup>`+
+			`<trt:ProfileToken>`+escape(token)+`</trt:ProfileToken>`+
+			`</trt:GetStreamUri>`, &resp)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.URI) <= 0 {
+		return "", errors.New("onvif: empty stream uri")
+	}
+
+	return c.sameHostname(resp.URI), nil
+}
+
+// SnapshotURI returns the JPEG snapshot URI of a media profile, with the host
+// the device was reached at.
+func (c *Client) SnapshotURI(ctx context.Context, token string) (string, error) {
+	media, err := c.mediaAddr(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		URI string `xml:"MediaUri>Uri"`
+	}
+	err = c.call(ctx, media, nsMedia+"/GetSnapshotUri",
+		`<trt:GetSnapshotUri><trt:ProfileToken>`+escape(token)+`</trt:ProfileToken></trt:GetSnapshotUri>`, &resp)
+	if err != nil || len(resp.URI) <= 0 {
+		return "", err
+	}
+
+	return c.sameHostname(resp.URI), nil
+}
@@ -0,0 +1,140 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DiscoveryAddress is the WS-Discovery multicast group.
+const DiscoveryAddress = "239.255.255.250:3702"
+
+// Device is a camera that answered a discovery probe.
+type Device struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Hardware string   `json:"hardware"`
+	Location string   `json:"location"`
+	XAddrs   []string `json:"xaddrs"`
+}
+
+type probeMatches struct {
+	Header struct {
+		RelatesTo string `xml:"RelatesTo"`
+	} `xml:"Header"`
+	Matches []struct {
+		Address string `xml:"EndpointReference>Address"`
+		Scopes  string `xml:"Scopes"`
+		XAddrs  string `xml:"XAddrs"`
+	} `xml:"Body>ProbeMatches>ProbeMatch"`
+}
+
+func probe(messageID string) []byte {
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>` +
+		`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:d="http://schemas.xmlsoap.org/ws/2005/04/discovery" xmlns:dn="http://www.onvif.org/ver10/network/wsdl">` +
+		`<s:Header>` +
+		`<a:Action s:mustUnderstand="1">http://schemas.xmlsoap.org/ws/2005/04/discovery/Probe</a:Action>` +
+		`<a:MessageID>` + messageID + `</a:MessageID>` +
+		`<a:ReplyTo><a:Address>http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous</a:Address></a:ReplyTo>` +
+		`<a:To s:mustUnderstand="1">urn:schemas-xmlsoap-org:ws:2005:04:discovery</a:To>` +
+		`</s:Header>` +
+		`<s:Body><d:Probe><d:Types>dn:NetworkVideoTransmitter</d:Types></d:Probe></s:Body>` +
+		`</s:Envelope>`)
+}
+
+// Discover sends a WS-Discovery probe for network video transmitters to
+// address and collects the answers until ctx is done.
+func Discover(ctx context.Context, address string) ([]Device, error) {
+	addr, err := net.ResolveUDPAddr("udp4", address)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	messageID := "urn:uuid:" + newUUID()
+	log.Printf("[ONVIF] probe: %s", addr)
+	if _, err := conn.WriteToUDP(probe(messageID), addr); err != nil {
+		return nil, err
+	}
+
+	var devices []Device
+	seen := make(map[string]bool)
+	buf := make([]byte, 64*1024)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			return devices, err
+		}
+
+		var matches probeMatches
+		if err := xml.Unmarshal(buf[:n], &matches); err != nil {
+			log.Printf("[ONVIF] invalid probe match from %s: %v", from, err)
+			continue
+		}
+		if matches.Header.RelatesTo != messageID {
+			continue
+		}
+
+		for _, m := range matches.Matches {
+			device := newDevice(m.Address, m.Scopes, m.XAddrs)
+			if len(device.XAddrs) <= 0 || seen[device.ID] {
+				continue
+			}
+			seen[device.ID] = true
+			log.Printf("[ONVIF] found %q at %s", device.Name, device.XAddrs[0])
+			devices = append(devices, device)
+		}
+	}
+
+	return devices, nil
+}
+
+func newDevice(address, scopes, xaddrs string) Device {
+	device := Device{ID: address, XAddrs: strings.Fields(xaddrs)}
+	if len(device.ID) <= 0 && len(device.XAddrs) > 0 {
+		device.ID = device.XAddrs[0]
+	}
+
+	for _, scope := range strings.Fields(scopes) {
+		for key, field := range map[string]*string{
+			"name":     &device.Name,
+			"hardware": &device.Hardware,
+			"location": &device.Location,
+		} {
+			prefix := "onvif://www.onvif.org/" + key + "/"
+			if value, ok := strings.CutPrefix(scope, prefix); ok && len(*field) <= 0 {
+				if unescaped, err := url.PathUnescape(value); err == nil {
+					value = unescaped
+				}
+				*field = value
+			}
+		}
+	}
+
+	if len(device.Name) <= 0 && len(device.XAddrs) > 0 {
+		if u, err := url.Parse(device.XAddrs[0]); err == nil {
+			device.Name = u.Hostname()
+		}
+	}
+
+	return device
+}
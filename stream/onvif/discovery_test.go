@@ -0,0 +1,86 @@
+package onvif
+
+import (
+	"context"
+	"encoding/xml"
+	"net"
+	"testing"
+	"time"
+)
+
+func probeMatch(relatesTo, address, scopes, xaddrs string) []byte {
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>` +
+		`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:d="http://schemas.xmlsoap.org/ws/2005/04/discovery">` +
+		`<s:Header><a:RelatesTo>` + relatesTo + `</a:RelatesTo></s:Header>` +
+		`<s:Body><d:ProbeMatches><d:ProbeMatch>` +
+		`<a:EndpointReference><a:Address>` + address + `</a:Address></a:EndpointReference>` +
+		`<d:Scopes>` + scopes + `</d:Scopes>` +
+		`<d:XAddrs>` + xaddrs + `</d:XAddrs>` +
+		`</d:ProbeMatch></d:ProbeMatches></s:Body>` +
+		`</s:Envelope>`)
+}
+
+func TestDiscover(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// The responder answers the probe like two cameras, one of them twice,
+	// and sends a match for another probe and a datagram that is not XML.
+	go func() {
+		buf := make([]byte, 64*1024)
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		var probe struct {
+			MessageID string `xml:"Header>MessageID"`
+			Types     string `xml:"Body>Probe>Types"`
+		}
+		if err := xml.Unmarshal(buf[:n], &probe); err != nil || probe.Types != "dn:NetworkVideoTransmitter" {
+			t.Errorf("unexpected probe: %s", buf[:n])
+			return
+		}
+
+		cam1 := probeMatch(probe.MessageID, "urn:uuid:cam1",
+			"onvif://www.onvif.org/name/Front%20Door onvif://www.onvif.org/hardware/IPC-1 onvif://www.onvif.org/location/Gate",
+			"http://192.168.1.10/onvif/device_service http://[fe80::1]/onvif/device_service")
+		for _, message := range [][]byte{
+			[]byte("not xml"),
+			probeMatch("urn:uuid:other", "urn:uuid:cam3", "", "http://192.168.1.30/onvif/device_service"),
+			cam1,
+			probeMatch(probe.MessageID, "urn:uuid:cam2", "", "http://192.168.1.20/onvif/device_service"),
+			cam1,
+		} {
+			conn.WriteToUDP(message, from)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	devices, err := Discover(ctx, conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(devices) != 2 {
+		t.Fatalf("got %d devices: %+v", len(devices), devices)
+	}
+
+	cam1 := devices[0]
+	if cam1.ID != "urn:uuid:cam1" || cam1.Name != "Front Door" || cam1.Hardware != "IPC-1" || cam1.Location != "Gate" {
+		t.Errorf("unexpected device: %+v", cam1)
+	}
+	if len(cam1.XAddrs) != 2 || cam1.XAddrs[0] != "http://192.168.1.10/onvif/device_service" {
+		t.Errorf("unexpected xaddrs: %v", cam1.XAddrs)
+	}
+
+	// Without a name scope the host of the service address is used.
+	if cam2 := devices[1]; cam2.ID != "urn:uuid:cam2" || cam2.Name != "192.168.1.20" {
+		t.Errorf("unexpected device: %+v", cam2)
+	}
+}
@@ -0,0 +1,157 @@
+// Package onvif finds ONVIF cameras on the local network and asks them for
+// the RTSP URLs of their media profiles.
+package onvif
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+)
+
+const (
+	nsDevice = "http://www.onvif.org/ver10/device/wsdl"
+	nsMedia  = "http://www.onvif.org/ver10/media/wsdl"
+	nsSchema = "http://www.onvif.org/ver10/schema"
+
+	passwordDigest = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordDigest"
+	base64Binary   = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-soap-message-security-1.0#Base64Binary"
+)
+
+const maxResponseSize = 4 << 20
+
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func escape(s string) string {
+	var buf strings.Builder
+	xml.EscapeText(&buf, []byte(s))
+
+	return buf.String()
+}
+
+// usernameToken returns a WS-Security header with the password digest of
+// the UsernameToken profile, created at the time of the device.
+func usernameToken(username, password string, created time.Time) string {
+	var nonce [16]byte
+	rand.Read(nonce[:])
+	timestamp := created.UTC().Format("2006-01-02T15:04:05.000Z")
+
+	h := sha1.New()
+	h.Write(nonce[:])
+	h.Write([]byte(timestamp))
+	h.Write([]byte(password))
+	digest := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	return `<wsse:Security s:mustUnderstand="1" xmlns:wsse="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd" xmlns:wsu="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd">` +
+		`<wsse:UsernameToken>` +
+		`<wsse:Username>` + escape(username) + `</wsse:Username>` +
+		`<wsse:Password Type="` + passwordDigest + `">` + digest + `</wsse:Password>` +
+		`<wsse:Nonce EncodingType="` + base64Binary + `">` + base64.StdEncoding.EncodeToString(nonce[:]) + `</wsse:Nonce>` +
+		`<wsu:Created>` + timestamp + `</wsu:Created>` +
+		`</wsse:UsernameToken>` +
+		`</wsse:Security>`
+}
+
+func envelope(header, body string) []byte {
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>` +
+		`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:tds="` + nsDevice + `" xmlns:trt="` + nsMedia + `" xmlns:tt="` + nsSchema + `">` +
+		`<s:Header>` + header + `</s:Header>` +
+		`<s:Body>` + body + `</s:Body>` +
+		`</s:Envelope>`)
+}
+
+type fault struct {
+	Code struct {
+		Value   string `xml:"Value"`
+		Subcode struct {
+			Value string `xml:"Value"`
+		} `xml:"Subcode"`
+	} `xml:"Code"`
+	Reason string `xml:"Reason>Text"`
+}
+
+func (f *fault) err() error {
+	reason := f.Reason
+	if len(reason) <= 0 {
+		reason = f.Code.Value
+	}
+
+	if strings.HasSuffix(f.Code.Subcode.Value, "NotAuthorized") {
+		return fmt.Errorf("%w: %s", stream.ErrUnauthorized, reason)
+	}
+
+	return fmt.Errorf("onvif fault: %s", reason)
+}
+
+// call posts a SOAP request and decodes the first element of the response
+// body into result.
+func call(ctx context.Context, client *http.Client, endpoint, action string, message []byte, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", `application/soap+xml; charset=utf-8; action="`+action+`"`)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+
+	var env struct {
+		Body struct {
+			Fault   *fault `xml:"Fault"`
+			Content []byte `xml:",innerxml"`
+		} `xml:"Body"`
+	}
+	if err := xml.Unmarshal(data, &env); err != nil {
+		if resp.StatusCode == http.StatusUnauthorized {
+			return fmt.Errorf("%w: %s", stream.ErrUnauthorized, resp.Status)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("onvif: %s", resp.Status)
+		}
+		return fmt.Errorf("invalid response: %w", err)
+	}
+
+	if env.Body.Fault != nil {
+		return env.Body.Fault.err()
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%w: %s", stream.ErrUnauthorized, resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("onvif: %s", resp.Status)
+	}
+
+	if err := xml.Unmarshal(env.Body.Content, result); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("empty response")
+		}
+		return fmt.Errorf("invalid response: %w", err)
+	}
+
+	return nil
+}
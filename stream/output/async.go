@@ -0,0 +1,198 @@
+package output
+
+import (
+	"log"
+	"sync"
+
+	"github.com/jaesung9507/playgo/stream"
+)
+
+// DefaultAsyncSize is the number of packets an Async output holds, a few
+// seconds of a typical stream.
+const DefaultAsyncSize = 512
+
+type asyncEventType int
+
+const (
+	asyncHeader asyncEventType = iota
+	asyncPacket
+	asyncTrailer
+)
+
+type asyncEvent struct {
+	eventType asyncEventType
+	codecs    []stream.Codec
+	packet    stream.Packet
+}
+
+// Async runs an output on a goroutine of its own, so that an output which
+// dials or writes to the network does not hold up the stream it is fed from.
+// Up to size packets are queued; when the output falls behind, the oldest
+// are dropped and it resumes at the next keyframe. An error of the output is
+// returned by the next call after it occurred.
+type Async struct {
+	output Output
+	size   int
+
+	mu      sync.Mutex
+	events  []asyncEvent
+	packets int
+	resync  bool
+	dropped int
+	err     error
+	closed  bool
+	notify  chan struct{}
+}
+
+func NewAsync(o Output, size int) *Async {
+	a := &Async{
+		output: o,
+		size:   max(size, 1),
+		notify: make(chan struct{}, 1),
+	}
+	go a.run()
+
+	return a
+}
+
+func (a *Async) push(event asyncEvent) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.err != nil {
+		return a.err
+	}
+	if a.closed {
+		return nil
+	}
+
+	if event.eventType == asyncPacket {
+		if a.packets >= a.size {
+			for i, e := range a.events {
+				if e.eventType == asyncPacket {
+					a.events = append(a.events[:i], a.events[i+1:]...)
+					break
+				}
+			}
+			a.packets--
+			a.resync = true
+			a.dropped++
+		}
+		a.packets++
+	}
+	a.events = append(a.events, event)
+
+	select {
+	case a.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// pop waits for the next event and reports whether packets were dropped
+// before it. ok is false once the output is closed.
+func (a *Async) pop() (event asyncEvent, resync bool, ok bool) {
+	for {
+		a.mu.Lock()
+		if a.closed {
+			a.mu.Unlock()
+			return asyncEvent{}, false, false
+		}
+		if len(a.events) > 0 {
+			event = a.events[0]
+			a.events[0] = asyncEvent{}
+			a.events = a.events[1:]
+			if event.eventType == asyncPacket {
+				a.packets--
+			}
+			resync = a.resync
+			a.resync = false
+			a.mu.Unlock()
+			return event, resync, true
+		}
+		a.mu.Unlock()
+
+		<-a.notify
+	}
+}
+
+func (a *Async) run() {
+	defer a.output.Close()
+
+	var codecs []stream.Codec
+	var gate KeyFrameGate
+	for {
+		event, resync, ok := a.pop()
+		if !ok {
+			return
+		}
+
+		var err error
+		switch event.eventType {
+		case asyncHeader:
+			codecs = event.codecs
+			gate.Reset(codecs)
+			err = a.output.WriteHeader(codecs)
+		case asyncPacket:
+			if resync {
+				gate.Reset(codecs)
+			}
+			if gate.Pass(event.packet) {
+				err = a.output.WritePacket(event.packet)
+			}
+		case asyncTrailer:
+			codecs = nil
+			err = a.output.WriteTrailer()
+		}
+
+		if err != nil {
+			a.mu.Lock()
+			a.err = err
+			a.events = nil
+			a.packets = 0
+			a.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (a *Async) WriteHeader(codecs []stream.Codec) error {
+	return a.push(asyncEvent{eventType: asyncHeader, codecs: codecs})
+}
+
+func (a *Async) WritePacket(packet stream.Packet) error {
+	return a.push(asyncEvent{eventType: asyncPacket, packet: packet})
+}
+
+func (a *Async) WriteTrailer() error {
+	a.mu.Lock()
+	dropped := a.dropped
+	a.dropped = 0
+	a.mu.Unlock()
+
+	if dropped > 0 {
+		log.Printf("[OUTPUT] %d packets dropped behind a slow output", dropped)
+	}
+
+	return a.push(asyncEvent{eventType: asyncTrailer})
+}
+
+// Close stops the goroutine, which closes the output once a write in
+// progress returns. It does not wait for that.
+func (a *Async) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.closed {
+		a.closed = true
+		a.events = nil
+		a.packets = 0
+		select {
+		case a.notify <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package output
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h264"
+)
+
+// slowOutput blocks in WriteHeader, like a publisher dialing, until release
+// is closed, and records what it is written.
+type slowOutput struct {
+	release chan struct{}
+	err     error
+
+	mu      sync.Mutex
+	packets []stream.Packet
+	closed  chan struct{}
+}
+
+func newSlowOutput() *slowOutput {
+	return &slowOutput{release: make(chan struct{}), closed: make(chan struct{})}
+}
+
+func (o *slowOutput) WriteHeader(codecs []stream.Codec) error {
+	<-o.release
+	return nil
+}
+
+func (o *slowOutput) WritePacket(packet stream.Packet) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.packets = append(o.packets, packet)
+	return o.err
+}
+
+func (o *slowOutput) WriteTrailer() error {
+	return nil
+}
+
+func (o *slowOutput) Close() error {
+	close(o.closed)
+	return nil
+}
+
+func (o *slowOutput) written() []stream.Packet {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return append([]stream.Packet(nil), o.packets...)
+}
+
+func videoPacket(i int) stream.Packet {
+	return stream.Packet{
+		IsKeyFrame: i%10 == 0,
+		Time:       time.Duration(i) * 40 * time.Millisecond,
+		Data:       []byte{byte(i)},
+	}
+}
+
+func TestAsync(t *testing.T) {
+	o := newSlowOutput()
+	a := NewAsync(o, 25)
+	defer a.Close()
+
+	// Nothing waits for the output while it is dialing.
+	start := time.Now()
+	if err := a.WriteHeader([]stream.Codec{&h264.Codec{}}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 40 {
+		if err := a.WritePacket(videoPacket(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("writes blocked for %v", elapsed)
+	}
+	close(o.release)
+
+	// Packets 0 to 14 were dropped, so the output resumes at the keyframe
+	// of packet 20.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(o.written()) < 20 {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d packets", len(o.written()))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for i, packet := range o.written() {
+		if packet.Data[0] != byte(20+i) {
+			t.Fatalf("packet %d: got %d, want %d", i, packet.Data[0], 20+i)
+		}
+	}
+}
+
+func TestAsyncError(t *testing.T) {
+	o := newSlowOutput()
+	o.err = errors.New("connection lost")
+	close(o.release)
+
+	a := NewAsync(o, DefaultAsyncSize)
+	defer a.Close()
+
+	if err := a.WriteHeader([]stream.Codec{&h264.Codec{}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.WritePacket(videoPacket(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-o.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("output not closed after its error")
+	}
+	if err := a.WritePacket(videoPacket(1)); err == nil || err.Error() != "connection lost" {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestGroupRemovesFailedAsync(t *testing.T) {
+	o := newSlowOutput()
+	o.err = errors.New("connection lost")
+	close(o.release)
+
+	g := NewGroup()
+	defer g.Close()
+
+	if err := g.WriteHeader([]stream.Codec{&h264.Codec{}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Add("publish", NewAsync(o, DefaultAsyncSize)); err != nil {
+		t.Fatal(err)
+	}
+	g.WritePacket(videoPacket(0))
+
+	<-o.closed
+	g.WritePacket(videoPacket(1))
+	if g.Get("publish") != nil {
+		t.Fatal("failed output was not removed")
+	}
+}
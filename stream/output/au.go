@@ -0,0 +1,73 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/codec/h26x"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h264"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h265"
+)
+
+// AccessUnit splits the AVCC data of an H264 or H265 packet into NALUs. The
+// parameter sets of codec are prepended to keyframes that lack them, so that
+// readers of outputs without out-of-band configuration can join at any
+// keyframe.
+func AccessUnit(codec stream.Codec, packet stream.Packet) ([][]byte, error) {
+	var au h26x.AVCC
+	if err := au.Unmarshal(packet.Data); err != nil {
+		return nil, err
+	}
+
+	if !packet.IsKeyFrame {
+		return au, nil
+	}
+
+	switch codec := codec.(type) {
+	case *h264.Codec:
+		for _, nalu := range au {
+			if len(nalu) > 0 && h264.ParseNALUType(nalu[0]) == h264.NALUnitSPS {
+				return au, nil
+			}
+		}
+		return append([][]byte{codec.SPS, codec.PPS}, au...), nil
+	case *h265.Codec:
+		for _, nalu := range au {
+			if len(nalu) > 0 && h265.ParseNALUType(nalu[0]) == h265.NALUnitSPS {
+				return au, nil
+			}
+		}
+		return append([][]byte{codec.VPS, codec.SPS, codec.PPS}, au...), nil
+	}
+
+	return nil, fmt.Errorf("not a video codec: %s", codec.CodecString())
+}
+
+// KeyFrameGate drops packets until the first keyframe of the video track, so
+// that an output which joins a running stream starts with a decodable frame.
+type KeyFrameGate struct {
+	videoIdx int8
+	waiting  bool
+}
+
+// Reset makes the gate wait for a keyframe of the first video track in
+// codecs. Streams without video pass through.
+func (g *KeyFrameGate) Reset(codecs []stream.Codec) {
+	g.waiting = false
+	for i, codec := range codecs {
+		switch codec.(type) {
+		case *h264.Codec, *h265.Codec:
+			g.videoIdx = int8(i)
+			g.waiting = true
+			return
+		}
+	}
+}
+
+func (g *KeyFrameGate) Pass(packet stream.Packet) bool {
+	if g.waiting && packet.Idx == g.videoIdx && packet.IsKeyFrame {
+		g.waiting = false
+	}
+
+	return !g.waiting
+}
@@ -0,0 +1,306 @@
+package hls
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/codec/aac"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h264"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h265"
+	"github.com/jaesung9507/playgo/stream/output"
+
+	"github.com/bluenviron/gohlslib/v2"
+	"github.com/bluenviron/gohlslib/v2/pkg/codecs"
+)
+
+const DefaultAddress = ":8888"
+
+// minSegmentCount is the number of segments Low-Latency HLS requires.
+const minSegmentCount = 7
+
+// Options configure the server through the query of its address, e.g.
+// :8888?variant=fmp4&segment=2s&dvr=5m.
+type Options struct {
+	// Variant is "ts", "fmp4" or "llhls" (default) for fMP4 with partial
+	// segments.
+	Variant gohlslib.MuxerVariant
+	// SegmentDuration is the minimum duration of a segment. Segments are cut
+	// at keyframes.
+	SegmentDuration time.Duration
+	// DVRWindow is how far viewers can seek back. It keeps at least seven
+	// segments.
+	DVRWindow time.Duration
+}
+
+func ParseOptions(query url.Values) (Options, error) {
+	opts := Options{
+		Variant:         gohlslib.MuxerVariantLowLatency,
+		SegmentDuration: time.Second,
+	}
+
+	switch variant := query.Get("variant"); variant {
+	case "", "llhls":
+	case "fmp4":
+		opts.Variant = gohlslib.MuxerVariantFMP4
+	case "ts":
+		opts.Variant = gohlslib.MuxerVariantMPEGTS
+	default:
+		return opts, fmt.Errorf("invalid variant: %s", variant)
+	}
+
+	if value := query.Get("segment"); len(value) > 0 {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return opts, fmt.Errorf("invalid segment: %s", value)
+		}
+		opts.SegmentDuration = d
+	}
+
+	if value := query.Get("dvr"); len(value) > 0 {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return opts, fmt.Errorf("invalid dvr: %s", value)
+		}
+		opts.DVRWindow = d
+	}
+
+	return opts, nil
+}
+
+func (o Options) segmentCount() int {
+	return max(int(o.DVRWindow/o.SegmentDuration), minSegmentCount)
+}
+
+type track struct {
+	track *gohlslib.Track
+	write func(muxer *gohlslib.Muxer, ntp time.Time, pts int64, packet stream.Packet) error
+}
+
+// Server serves the stream written to it as a live HLS playlist at
+// /index.m3u8, for browsers and TVs that cannot play the source directly.
+type Server struct {
+	address string
+	opts    Options
+	server  *http.Server
+
+	mu     sync.RWMutex
+	muxer  *gohlslib.Muxer
+	tracks []*track
+	start  time.Time
+}
+
+// NewServer creates a server listening on address, which may carry the
+// options as a query, e.g. ":8888?variant=ts".
+func NewServer(address string) (*Server, error) {
+	address, rawQuery, _ := strings.Cut(address, "?")
+	if len(address) <= 0 {
+		address = DefaultAddress
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := ParseOptions(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		address: address,
+		opts:    opts,
+	}, nil
+}
+
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+
+	s.server = &http.Server{Handler: s}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[HLS SERVER] %v", err)
+		}
+	}()
+	log.Printf("[HLS SERVER] listening on %s", s.URL())
+
+	return nil
+}
+
+// URL returns the playlist URL, with the host of an unspecified address
+// replaced by localhost.
+func (s *Server) URL() string {
+	host, port, err := net.SplitHostPort(s.address)
+	if err != nil {
+		return "http://" + s.address + "/index.m3u8"
+	}
+
+	if ip := net.ParseIP(host); len(host) <= 0 || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+
+	return "http://" + net.JoinHostPort(host, port) + "/index.m3u8"
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	s.mu.RLock()
+	muxer := s.muxer
+	s.mu.RUnlock()
+
+	if muxer == nil {
+		http.Error(w, "no stream is playing", http.StatusNotFound)
+		return
+	}
+
+	// Muxer.Handle blocks LL-HLS playlist requests until the requested part
+	// is ready, so the lock is not held here.
+	muxer.Handle(w, r)
+}
+
+func newTrack(codec stream.Codec) (*track, error) {
+	switch codec := codec.(type) {
+	case *h264.Codec:
+		return &track{
+			track: &gohlslib.Track{Codec: &codecs.H264{SPS: codec.SPS, PPS: codec.PPS}, ClockRate: 90000},
+			write: func(muxer *gohlslib.Muxer, ntp time.Time, pts int64, packet stream.Packet) error {
+				au, err := output.AccessUnit(codec, packet)
+				if err != nil {
+					return err
+				}
+				return muxer.WriteH264(muxer.Tracks[packet.Idx], ntp, pts, au)
+			},
+		}, nil
+	case *h265.Codec:
+		return &track{
+			track: &gohlslib.Track{Codec: &codecs.H265{VPS: codec.VPS, SPS: codec.SPS, PPS: codec.PPS}, ClockRate: 90000},
+			write: func(muxer *gohlslib.Muxer, ntp time.Time, pts int64, packet stream.Packet) error {
+				au, err := output.AccessUnit(codec, packet)
+				if err != nil {
+					return err
+				}
+				return muxer.WriteH265(muxer.Tracks[packet.Idx], ntp, pts, au)
+			},
+		}, nil
+	case *aac.Codec:
+		return &track{
+			track: &gohlslib.Track{Codec: &codecs.MPEG4Audio{Config: codec.Config}, ClockRate: codec.Config.SampleRate},
+			write: func(muxer *gohlslib.Muxer, ntp time.Time, pts int64, packet stream.Packet) error {
+				return muxer.WriteMPEG4Audio(muxer.Tracks[packet.Idx], ntp, pts, [][]byte{packet.Data})
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported codec: %s", codec.CodecString())
+}
+
+// WriteHeader starts a new playlist. Viewers of the previous stream have to
+// reload it.
+func (s *Server) WriteHeader(codecs []stream.Codec) error {
+	if s.server == nil {
+		return errors.New("server is not started")
+	}
+
+	variant := s.opts.Variant
+	tracks := make([]*track, 0, len(codecs))
+	for _, codec := range codecs {
+		t, err := newTrack(codec)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := codec.(*h265.Codec); ok && variant == gohlslib.MuxerVariantMPEGTS {
+			log.Print("[HLS SERVER] H265 is not supported in MPEG-TS segments, using fMP4")
+			variant = gohlslib.MuxerVariantFMP4
+		}
+		tracks = append(tracks, t)
+	}
+
+	muxer := &gohlslib.Muxer{
+		Variant:            variant,
+		SegmentCount:       s.opts.segmentCount(),
+		SegmentMinDuration: s.opts.SegmentDuration,
+		OnEncodeError: func(err error) {
+			log.Printf("[HLS SERVER] %v", err)
+		},
+	}
+	for _, t := range tracks {
+		muxer.Tracks = append(muxer.Tracks, t.track)
+	}
+	if err := muxer.Start(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.muxer != nil {
+		s.muxer.Close()
+	}
+	s.muxer = muxer
+	s.tracks = tracks
+	s.start = time.Now()
+
+	return nil
+}
+
+func (s *Server) WritePacket(packet stream.Packet) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.muxer == nil || int(packet.Idx) < 0 || int(packet.Idx) >= len(s.tracks) {
+		return nil
+	}
+
+	t := s.tracks[packet.Idx]
+	pts := packet.Time + packet.CompositionTime
+	ticks := output.Ticks(pts, t.track.ClockRate)
+	if err := t.write(s.muxer, s.start.Add(pts), ticks, packet); err != nil {
+		return fmt.Errorf("track %d: %w", packet.Idx, err)
+	}
+
+	return nil
+}
+
+// WriteTrailer stops serving the playlist. The server keeps running for the
+// next stream.
+func (s *Server) WriteTrailer() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.muxer != nil {
+		s.muxer.Close()
+		s.muxer = nil
+		s.tracks = nil
+	}
+
+	return nil
+}
+
+func (s *Server) Close() error {
+	s.WriteTrailer()
+	if s.server != nil {
+		log.Print("[HLS SERVER] close")
+		s.server.Close()
+		s.server = nil
+	}
+
+	return nil
+}
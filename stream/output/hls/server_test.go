@@ -0,0 +1,159 @@
+package hls
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream/internal/streamtest"
+
+	"github.com/bluenviron/gohlslib/v2/pkg/playlist"
+)
+
+func get(t *testing.T, base *url.URL, ref string) []byte {
+	t.Helper()
+
+	u, err := base.Parse(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := http.Get(u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("%s: %s: %s", u, res.Status, body)
+	}
+
+	return body
+}
+
+func TestParseOptions(t *testing.T) {
+	for _, query := range []string{"variant=dash", "segment=0s", "segment=x", "dvr=-1s"} {
+		values, _ := url.ParseQuery(query)
+		if _, err := ParseOptions(values); err == nil {
+			t.Errorf("%s: expected an error", query)
+		}
+	}
+
+	s, err := NewServer(":9000?variant=fmp4&segment=2s&dvr=1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.address != ":9000" || s.opts.SegmentDuration != 2*time.Second || s.opts.segmentCount() != 30 {
+		t.Errorf("unexpected server: %s %+v", s.address, s.opts)
+	}
+	if s.URL() != "http://localhost:9000/index.m3u8" {
+		t.Errorf("unexpected URL: %s", s.URL())
+	}
+}
+
+func TestServer(t *testing.T) {
+	codecs, packets := streamtest.Collect(t, "testsrc://bars?size=240p&fps=10&gop=5&duration=4s&realtime=0")
+
+	for _, tc := range []struct {
+		variant string
+		prefix  []byte
+	}{
+		{"ts", []byte{0x47}},
+		{"fmp4", nil},
+	} {
+		t.Run(tc.variant, func(t *testing.T) {
+			s, err := NewServer(streamtest.FreeAddress(t) + "?segment=1s&variant=" + tc.variant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = s.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			base, err := url.Parse(s.URL())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := http.Get(base.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != http.StatusNotFound {
+				t.Errorf("playlist before the stream: %s", res.Status)
+			}
+
+			if err = s.WriteHeader(codecs); err != nil {
+				t.Fatal(err)
+			}
+			for _, packet := range packets {
+				if err = s.WritePacket(packet); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			pl, err := playlist.Unmarshal(get(t, base, base.String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			multivariant, ok := pl.(*playlist.Multivariant)
+			if !ok || len(multivariant.Variants) <= 0 {
+				t.Fatalf("unexpected playlist: %T", pl)
+			}
+
+			pl, err = playlist.Unmarshal(get(t, base, multivariant.Variants[0].URI))
+			if err != nil {
+				t.Fatal(err)
+			}
+			media, ok := pl.(*playlist.Media)
+			if !ok {
+				t.Fatalf("unexpected playlist: %T", pl)
+			}
+
+			// The last segment is still open, so the 4s stream gives three.
+			if len(media.Segments) != 3 {
+				t.Fatalf("got %d segments, want 3", len(media.Segments))
+			}
+			for i, segment := range media.Segments {
+				if segment.Duration != time.Second {
+					t.Errorf("segment %d: duration %v", i, segment.Duration)
+				}
+			}
+
+			if (media.Map != nil) != (tc.variant == "fmp4") {
+				t.Errorf("unexpected init segment: %v", media.Map)
+			}
+			if media.Map != nil {
+				if init := get(t, base, media.Map.URI); !bytes.Contains(init, []byte("ftyp")) {
+					t.Error("init segment without ftyp")
+				}
+			}
+
+			segment := get(t, base, media.Segments[0].URI)
+			if len(segment) <= 0 || !bytes.HasPrefix(segment, tc.prefix) {
+				t.Errorf("unexpected segment: % x", segment[:min(len(segment), 8)])
+			}
+
+			if err = s.WriteTrailer(); err != nil {
+				t.Fatal(err)
+			}
+			res, err = http.Get(base.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != http.StatusNotFound {
+				t.Errorf("playlist after the stream: %s", res.Status)
+			}
+		})
+	}
+}
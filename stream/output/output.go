@@ -0,0 +1,172 @@
+// Package output republishes a stream, the codecs and packets a
+// stream.Client delivers, to other players.
+package output
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/jaesung9507/playgo/stream"
+)
+
+// Output receives the codecs of a stream followed by its packets in
+// timestamp order. WriteTrailer ends the stream; an output that outlives it,
+// such as a server, may receive another header afterwards.
+type Output interface {
+	WriteHeader(codecs []stream.Codec) error
+	WritePacket(packet stream.Packet) error
+	WriteTrailer() error
+	Close() error
+}
+
+// Group fans a stream out to outputs that can be added and removed while it
+// is running. An output that fails is closed and removed.
+type Group struct {
+	mu      sync.Mutex
+	codecs  []stream.Codec
+	outputs map[string]Output
+}
+
+func NewGroup() *Group {
+	return &Group{outputs: make(map[string]Output)}
+}
+
+// Add adds an output under name, replacing the previous one. If a stream is
+// running, the output starts with its header.
+func (g *Group) Add(name string, o Output) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.codecs != nil {
+		if err := o.WriteHeader(g.codecs); err != nil {
+			return err
+		}
+	}
+
+	if prev, ok := g.outputs[name]; ok {
+		prev.Close()
+	}
+	g.outputs[name] = o
+
+	return nil
+}
+
+func (g *Group) Get(name string) Output {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.outputs[name]
+}
+
+func (g *Group) Remove(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.remove(name, nil)
+}
+
+func (g *Group) remove(name string, err error) {
+	o, ok := g.outputs[name]
+	if !ok {
+		return
+	}
+
+	if err != nil {
+		log.Printf("[OUTPUT] %s: %v", name, err)
+	}
+	o.Close()
+	delete(g.outputs, name)
+}
+
+func (g *Group) WriteHeader(codecs []stream.Codec) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.codecs = codecs
+	for name, o := range g.outputs {
+		if err := o.WriteHeader(codecs); err != nil {
+			g.remove(name, err)
+		}
+	}
+
+	return nil
+}
+
+func (g *Group) WritePacket(packet stream.Packet) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for name, o := range g.outputs {
+		if err := o.WritePacket(packet); err != nil {
+			g.remove(name, err)
+		}
+	}
+
+	return nil
+}
+
+func (g *Group) WriteTrailer() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.codecs == nil {
+		return nil
+	}
+
+	g.codecs = nil
+	for name, o := range g.outputs {
+		if err := o.WriteTrailer(); err != nil {
+			g.remove(name, err)
+		}
+	}
+
+	return nil
+}
+
+func (g *Group) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for name := range g.outputs {
+		g.remove(name, nil)
+	}
+
+	return nil
+}
+
+// Run republishes a dialed client to o until its stream ends, the client
+// fails or ctx is canceled.
+func Run(ctx context.Context, c stream.Client, o Output) error {
+	codecs, err := c.CodecData()
+	if err != nil {
+		return err
+	}
+
+	if err := o.WriteHeader(codecs); err != nil {
+		return err
+	}
+	defer o.WriteTrailer()
+
+	packetCh := c.PacketQueue().Chan()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case reason := <-c.CloseCh():
+			if err, ok := reason.(error); ok {
+				return err
+			}
+			return fmt.Errorf("%v", reason)
+		case packet, ok := <-packetCh:
+			if !ok {
+				return nil
+			}
+
+			if err := o.WritePacket(*packet); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package rtmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/codec/aac"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h264"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h265"
+	"github.com/jaesung9507/playgo/stream/output"
+	"github.com/jaesung9507/playgo/stream/protocol/rtmp"
+
+	"github.com/bluenviron/gortmplib"
+	"github.com/bluenviron/gortmplib/pkg/codecs"
+)
+
+const (
+	dialTimeout  = 10 * time.Second
+	writeTimeout = 5 * time.Second
+)
+
+// Publisher pushes the stream written to it to an RTMP(S) ingest such as
+// rtmp://a.example.com/app/{stream key}. H265 is sent as Enhanced RTMP. Each
+// stream is published on a new connection.
+type Publisher struct {
+	url *url.URL
+
+	mu     sync.Mutex
+	client *gortmplib.Client
+	writer *gortmplib.Writer
+	codecs []stream.Codec
+	gate   output.KeyFrameGate
+	tracks []*gortmplib.Track
+	err    error
+}
+
+func NewPublisher(parsedURL *url.URL) *Publisher {
+	return &Publisher{url: parsedURL}
+}
+
+func newTrack(codec stream.Codec) (*gortmplib.Track, error) {
+	switch codec := codec.(type) {
+	case *h264.Codec:
+		return &gortmplib.Track{Codec: &codecs.H264{SPS: codec.SPS, PPS: codec.PPS}}, nil
+	case *h265.Codec:
+		return &gortmplib.Track{Codec: &codecs.H265{VPS: codec.VPS, SPS: codec.SPS, PPS: codec.PPS}}, nil
+	case *aac.Codec:
+		config := codec.Config
+		return &gortmplib.Track{Codec: &codecs.MPEG4Audio{Config: &config}}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported codec: %s", codec.CodecString())
+}
+
+func (p *Publisher) WriteHeader(codecs []stream.Codec) error {
+	p.WriteTrailer()
+
+	tracks := make([]*gortmplib.Track, 0, len(codecs))
+	for _, codec := range codecs {
+		track, err := newTrack(codec)
+		if err != nil {
+			return err
+		}
+		tracks = append(tracks, track)
+	}
+
+	u := *p.url
+	if _, _, err := net.SplitHostPort(u.Host); err != nil {
+		if u.Scheme == "rtmps" {
+			u.Host += rtmp.DefaultRtmpsPort
+		} else {
+			u.Host += rtmp.DefaultRtmpPort
+		}
+	}
+	log.Printf("[RTMP PUBLISH] dial: %s://%s%s", u.Scheme, u.Host, u.Path)
+
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+
+	client := &gortmplib.Client{
+		URL:     &u,
+		Publish: true,
+	}
+	if err := client.Initialize(ctx); err != nil {
+		return err
+	}
+
+	writer := &gortmplib.Writer{
+		Conn:   client,
+		Tracks: tracks,
+	}
+	if err := writer.Initialize(); err != nil {
+		client.Close()
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.client = client
+	p.writer = writer
+	p.codecs = codecs
+	p.gate.Reset(codecs)
+	p.tracks = tracks
+	p.err = nil
+
+	// The ingest only sends control messages, but they have to be read for
+	// the connection to make progress, and a read error means it is gone.
+	go func() {
+		for {
+			if _, err := client.Read(); err != nil {
+				p.mu.Lock()
+				if p.client == client && p.err == nil {
+					p.err = err
+				}
+				p.mu.Unlock()
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (p *Publisher) WritePacket(packet stream.Packet) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return fmt.Errorf("connection lost: %w", p.err)
+	}
+
+	if p.writer == nil || int(packet.Idx) < 0 || int(packet.Idx) >= len(p.tracks) || !p.gate.Pass(packet) {
+		return nil
+	}
+
+	// A stalled ingest would fill the queue forever, so it is given up on.
+	p.client.NetConn().SetWriteDeadline(time.Now().Add(writeTimeout))
+
+	track := p.tracks[packet.Idx]
+	dts := packet.Time
+	pts := packet.Time + packet.CompositionTime
+	switch codec := p.codecs[packet.Idx].(type) {
+	case *h264.Codec:
+		au, err := output.AccessUnit(codec, packet)
+		if err != nil {
+			return err
+		}
+		return p.writer.WriteH264(track, pts, dts, au)
+	case *h265.Codec:
+		au, err := output.AccessUnit(codec, packet)
+		if err != nil {
+			return err
+		}
+		return p.writer.WriteH265(track, pts, dts, au)
+	case *aac.Codec:
+		return p.writer.WriteMPEG4Audio(track, pts, packet.Data)
+	}
+
+	return errors.New("unsupported track")
+}
+
+func (p *Publisher) WriteTrailer() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.client != nil {
+		log.Print("[RTMP PUBLISH] close")
+		p.client.Close()
+		p.client = nil
+		p.writer = nil
+		p.codecs = nil
+		p.tracks = nil
+	}
+
+	return nil
+}
+
+func (p *Publisher) Close() error {
+	return p.WriteTrailer()
+}
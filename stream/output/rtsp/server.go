@@ -0,0 +1,300 @@
+package rtsp
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/codec/aac"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h264"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h265"
+	"github.com/jaesung9507/playgo/stream/output"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/pion/rtp"
+)
+
+const (
+	DefaultAddress = ":8554"
+	DefaultPath    = "/live"
+
+	// writeQueueSize holds the RTP packets of a large keyframe, which the
+	// default of 256 drops for high resolutions.
+	writeQueueSize = 1024
+)
+
+type track struct {
+	media     *description.Media
+	clockRate int
+	encode    func(packet stream.Packet) ([]*rtp.Packet, error)
+}
+
+// Server serves the stream written to it at a single path over RTSP with
+// TCP transport, so that other players can watch what PlayGo is playing.
+type Server struct {
+	address string
+	path    string
+	server  *gortsplib.Server
+
+	mu     sync.RWMutex
+	stream *gortsplib.ServerStream
+	tracks []*track
+	start  time.Time
+	offset uint32
+}
+
+func NewServer(address, path string) *Server {
+	if len(address) <= 0 {
+		address = DefaultAddress
+	}
+
+	path = "/" + strings.Trim(path, "/")
+	if path == "/" {
+		path = DefaultPath
+	}
+
+	return &Server{
+		address: address,
+		path:    path,
+	}
+}
+
+func (s *Server) Start() error {
+	s.server = &gortsplib.Server{
+		Handler:        s,
+		RTSPAddress:    s.address,
+		WriteQueueSize: writeQueueSize,
+	}
+	if err := s.server.Start(); err != nil {
+		return err
+	}
+	log.Printf("[RTSP SERVER] listening on %s", s.URL())
+
+	return nil
+}
+
+// URL returns the address readers connect to, with the host of an
+// unspecified address replaced by localhost.
+func (s *Server) URL() string {
+	host, port, err := net.SplitHostPort(s.address)
+	if err != nil {
+		return "rtsp://" + s.address + s.path
+	}
+
+	if ip := net.ParseIP(host); len(host) <= 0 || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+
+	return "rtsp://" + net.JoinHostPort(host, port) + s.path
+}
+
+func (s *Server) OnConnOpen(ctx *gortsplib.ServerHandlerOnConnOpenCtx) {
+	log.Printf("[RTSP SERVER] conn opened: %s", ctx.Conn.NetConn().RemoteAddr())
+}
+
+func (s *Server) OnConnClose(ctx *gortsplib.ServerHandlerOnConnCloseCtx) {
+	log.Printf("[RTSP SERVER] conn closed: %s: %v", ctx.Conn.NetConn().RemoteAddr(), ctx.Error)
+}
+
+func (s *Server) currentStream(path string) (*base.Response, *gortsplib.ServerStream, error) {
+	if path != s.path {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil, fmt.Errorf("path not found: %s", path)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.stream == nil {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil, errors.New("no stream is playing")
+	}
+
+	return &base.Response{StatusCode: base.StatusOK}, s.stream, nil
+}
+
+func (s *Server) OnDescribe(ctx *gortsplib.ServerHandlerOnDescribeCtx) (*base.Response, *gortsplib.ServerStream, error) {
+	return s.currentStream(ctx.Path)
+}
+
+func (s *Server) OnSetup(ctx *gortsplib.ServerHandlerOnSetupCtx) (*base.Response, *gortsplib.ServerStream, error) {
+	return s.currentStream(ctx.Path)
+}
+
+func (s *Server) OnPlay(ctx *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+	log.Printf("[RTSP SERVER] play: %s", ctx.Conn.NetConn().RemoteAddr())
+	return &base.Response{StatusCode: base.StatusOK}, nil
+}
+
+func newTrack(codec stream.Codec) (*track, error) {
+	switch codec := codec.(type) {
+	case *h264.Codec:
+		f := &format.H264{PayloadTyp: 96, PacketizationMode: 1, SPS: codec.SPS, PPS: codec.PPS}
+		encoder, err := f.CreateEncoder()
+		if err != nil {
+			return nil, err
+		}
+
+		return &track{
+			media:     &description.Media{Type: description.MediaTypeVideo, Formats: []format.Format{f}},
+			clockRate: f.ClockRate(),
+			encode: func(packet stream.Packet) ([]*rtp.Packet, error) {
+				au, err := output.AccessUnit(codec, packet)
+				if err != nil {
+					return nil, err
+				}
+
+				return encoder.Encode(au)
+			},
+		}, nil
+	case *h265.Codec:
+		f := &format.H265{PayloadTyp: 96, VPS: codec.VPS, SPS: codec.SPS, PPS: codec.PPS}
+		encoder, err := f.CreateEncoder()
+		if err != nil {
+			return nil, err
+		}
+
+		return &track{
+			media:     &description.Media{Type: description.MediaTypeVideo, Formats: []format.Format{f}},
+			clockRate: f.ClockRate(),
+			encode: func(packet stream.Packet) ([]*rtp.Packet, error) {
+				au, err := output.AccessUnit(codec, packet)
+				if err != nil {
+					return nil, err
+				}
+
+				return encoder.Encode(au)
+			},
+		}, nil
+	case *aac.Codec:
+		config := codec.Config
+		f := &format.MPEG4Audio{
+			PayloadTyp:       97,
+			Config:           &config,
+			SizeLength:       13,
+			IndexLength:      3,
+			IndexDeltaLength: 3,
+		}
+		encoder, err := f.CreateEncoder()
+		if err != nil {
+			return nil, err
+		}
+
+		return &track{
+			media:     &description.Media{Type: description.MediaTypeAudio, Formats: []format.Format{f}},
+			clockRate: f.ClockRate(),
+			encode: func(packet stream.Packet) ([]*rtp.Packet, error) {
+				return encoder.Encode([][]byte{packet.Data})
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported codec: %s", codec.CodecString())
+}
+
+// WriteHeader starts serving a new stream. Readers of the previous one are
+// disconnected and have to reconnect.
+func (s *Server) WriteHeader(codecs []stream.Codec) error {
+	if s.server == nil {
+		return errors.New("server is not started")
+	}
+
+	tracks := make([]*track, 0, len(codecs))
+	desc := &description.Session{}
+	for _, codec := range codecs {
+		t, err := newTrack(codec)
+		if err != nil {
+			return err
+		}
+		tracks = append(tracks, t)
+		desc.Medias = append(desc.Medias, t.media)
+	}
+
+	serverStream := &gortsplib.ServerStream{
+		Server: s.server,
+		Desc:   desc,
+	}
+	if err := serverStream.Initialize(); err != nil {
+		return err
+	}
+
+	var offset [4]byte
+	if _, err := rand.Read(offset[:]); err != nil {
+		serverStream.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stream != nil {
+		s.stream.Close()
+	}
+	s.stream = serverStream
+	s.tracks = tracks
+	s.start = time.Now()
+	s.offset = binary.BigEndian.Uint32(offset[:])
+
+	return nil
+}
+
+func (s *Server) WritePacket(packet stream.Packet) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.stream == nil || int(packet.Idx) < 0 || int(packet.Idx) >= len(s.tracks) {
+		return nil
+	}
+
+	t := s.tracks[packet.Idx]
+	pkts, err := t.encode(packet)
+	if err != nil {
+		return fmt.Errorf("track %d: %w", packet.Idx, err)
+	}
+
+	pts := packet.Time + packet.CompositionTime
+	timestamp := s.offset + uint32(output.Ticks(pts, t.clockRate))
+	ntp := s.start.Add(pts)
+	for _, pkt := range pkts {
+		pkt.Timestamp += timestamp
+		if err := s.stream.WritePacketRTPWithNTP(t.media, pkt, ntp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteTrailer stops serving the stream. The server keeps running for the
+// next one.
+func (s *Server) WriteTrailer() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stream != nil {
+		s.stream.Close()
+		s.stream = nil
+		s.tracks = nil
+	}
+
+	return nil
+}
+
+func (s *Server) Close() error {
+	s.WriteTrailer()
+	if s.server != nil {
+		log.Print("[RTSP SERVER] close")
+		s.server.Close()
+		s.server = nil
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package rtsp
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/codec/h26x"
+	"github.com/jaesung9507/playgo/stream/internal/streamtest"
+	"github.com/jaesung9507/playgo/stream/output"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/pion/rtp"
+)
+
+type received struct {
+	video     bool
+	timestamp uint32
+	au        [][]byte
+}
+
+func TestServer(t *testing.T) {
+	codecs, packets := streamtest.Collect(t, "testsrc://bars?size=240p&fps=10&gop=4&duration=800ms&realtime=0")
+
+	s := NewServer(streamtest.FreeAddress(t), "live")
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.WriteHeader(codecs); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := base.ParseURL(s.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	protocol := gortsplib.ProtocolTCP
+	c := gortsplib.Client{Scheme: u.Scheme, Host: u.Host, Protocol: &protocol}
+	if err = c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	desc, _, err := c.Describe(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var h264Format *format.H264
+	videoMedia := desc.FindFormat(&h264Format)
+	if videoMedia == nil {
+		t.Fatal("no H264 track")
+	}
+	var aacFormat *format.MPEG4Audio
+	audioMedia := desc.FindFormat(&aacFormat)
+	if audioMedia == nil {
+		t.Fatal("no AAC track")
+	}
+
+	videoDecoder, err := h264Format.CreateDecoder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	audioDecoder, err := aacFormat.CreateDecoder()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = c.SetupAll(desc.BaseURL, desc.Medias); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan received, len(packets))
+	c.OnPacketRTP(videoMedia, h264Format, func(pkt *rtp.Packet) {
+		if au, err := videoDecoder.Decode(pkt); err == nil {
+			ch <- received{video: true, timestamp: pkt.Timestamp, au: au}
+		}
+	})
+	c.OnPacketRTP(audioMedia, aacFormat, func(pkt *rtp.Packet) {
+		if aus, err := audioDecoder.Decode(pkt); err == nil {
+			ch <- received{timestamp: pkt.Timestamp, au: aus}
+		}
+	})
+
+	if _, err = c.Play(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var video, audio []stream.Packet
+	for _, packet := range packets {
+		if err = s.WritePacket(packet); err != nil {
+			t.Fatal(err)
+		}
+		if packet.Idx == 0 {
+			video = append(video, packet)
+		} else {
+			audio = append(audio, packet)
+		}
+	}
+
+	var started bool
+	var firstTimestamp uint32
+	var firstPTS time.Duration
+	timeout := time.After(10 * time.Second)
+	for len(video) > 0 || len(audio) > 0 {
+		var r received
+		select {
+		case r = <-ch:
+		case <-timeout:
+			t.Fatalf("timeout: %d video and %d audio packets missing", len(video), len(audio))
+		}
+
+		if r.video {
+			if len(video) <= 0 {
+				t.Fatal("unexpected video access unit")
+			}
+			packet := video[0]
+			video = video[1:]
+
+			want, err := output.AccessUnit(codecs[0], packet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalNALUs(r.au, want) {
+				t.Errorf("video access unit at %v differs", packet.Time)
+			}
+
+			pts := packet.Time + packet.CompositionTime
+			if !started {
+				if !packet.IsKeyFrame {
+					t.Error("first video access unit is not a keyframe")
+				}
+				started = true
+				firstTimestamp = r.timestamp
+				firstPTS = pts
+			}
+			wantTimestamp := int64(pts-firstPTS) * 90000 / int64(time.Second)
+			if got := int64(r.timestamp - firstTimestamp); got != wantTimestamp {
+				t.Errorf("video timestamp at %v: got %d, want %d", pts, got, wantTimestamp)
+			}
+			continue
+		}
+
+		for _, au := range r.au {
+			if len(audio) <= 0 {
+				t.Fatal("unexpected audio access unit")
+			}
+			packet := audio[0]
+			audio = audio[1:]
+
+			if !bytes.Equal(au, packet.Data) {
+				t.Errorf("audio access unit at %v differs", packet.Time)
+			}
+		}
+	}
+}
+
+func equalNALUs(a, b h26x.AVCC) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,176 @@
+package srt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/codec/aac"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h264"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h265"
+	"github.com/jaesung9507/playgo/stream/output"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/formats/mpegts"
+	tscodecs "github.com/bluenviron/mediacommon/v2/pkg/formats/mpegts/codecs"
+	srt "github.com/datarhei/gosrt"
+)
+
+const (
+	// payloadSize fits seven TS packets into an SRT packet.
+	payloadSize = 7 * 188
+
+	// startOffset keeps the first DTS ahead of the PCR.
+	startOffset = time.Second
+)
+
+// Publisher pushes the stream written to it as MPEG-TS to an SRT listener,
+// e.g. srt://host:port?streamid=publish:live. Each stream is published on a
+// new connection.
+type Publisher struct {
+	url *url.URL
+
+	mu     sync.Mutex
+	conn   srt.Conn
+	buf    *bufio.Writer
+	writer *mpegts.Writer
+	codecs []stream.Codec
+	gate   output.KeyFrameGate
+	tracks []*mpegts.Track
+}
+
+func NewPublisher(parsedURL *url.URL) *Publisher {
+	return &Publisher{url: parsedURL}
+}
+
+func (p *Publisher) getConfig() (*srt.Config, error) {
+	cfg := srt.DefaultConfig()
+	if _, err := cfg.UnmarshalURL(p.url.String()); err != nil {
+		return nil, err
+	}
+
+	if len(cfg.StreamId) <= 0 && strings.HasPrefix(p.url.Fragment, "!::") {
+		cfg.StreamId = "#" + p.url.Fragment
+	}
+
+	return &cfg, nil
+}
+
+func newTrack(codec stream.Codec) (*mpegts.Track, error) {
+	switch codec := codec.(type) {
+	case *h264.Codec:
+		return &mpegts.Track{Codec: &tscodecs.H264{}}, nil
+	case *h265.Codec:
+		return &mpegts.Track{Codec: &tscodecs.H265{}}, nil
+	case *aac.Codec:
+		return &mpegts.Track{Codec: &tscodecs.MPEG4Audio{Config: codec.Config}}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported codec: %s", codec.CodecString())
+}
+
+func (p *Publisher) WriteHeader(codecs []stream.Codec) error {
+	p.WriteTrailer()
+
+	tracks := make([]*mpegts.Track, 0, len(codecs))
+	for _, codec := range codecs {
+		track, err := newTrack(codec)
+		if err != nil {
+			return err
+		}
+		tracks = append(tracks, track)
+	}
+
+	cfg, err := p.getConfig()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[SRT PUBLISH] dial: %s", p.url.Host)
+	conn, err := srt.Dial(p.url.Scheme, p.url.Host, *cfg)
+	if err != nil {
+		return err
+	}
+
+	buf := bufio.NewWriterSize(conn, payloadSize)
+	writer := &mpegts.Writer{W: buf, Tracks: tracks}
+	if err := writer.Initialize(); err != nil {
+		conn.Close()
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.conn = conn
+	p.buf = buf
+	p.writer = writer
+	p.codecs = codecs
+	p.gate.Reset(codecs)
+	p.tracks = tracks
+
+	return nil
+}
+
+func (p *Publisher) WritePacket(packet stream.Packet) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.writer == nil || int(packet.Idx) < 0 || int(packet.Idx) >= len(p.tracks) || !p.gate.Pass(packet) {
+		return nil
+	}
+
+	track := p.tracks[packet.Idx]
+	dts := output.Ticks(packet.Time+startOffset, 90000)
+	pts := output.Ticks(packet.Time+packet.CompositionTime+startOffset, 90000)
+
+	var err error
+	switch codec := p.codecs[packet.Idx].(type) {
+	case *h264.Codec:
+		var au [][]byte
+		if au, err = output.AccessUnit(codec, packet); err == nil {
+			err = p.writer.WriteH264(track, pts, dts, au)
+		}
+	case *h265.Codec:
+		var au [][]byte
+		if au, err = output.AccessUnit(codec, packet); err == nil {
+			err = p.writer.WriteH265(track, pts, dts, au)
+		}
+	case *aac.Codec:
+		err = p.writer.WriteMPEG4Audio(track, pts, [][]byte{packet.Data})
+	default:
+		err = errors.New("unsupported track")
+	}
+	if err != nil {
+		return err
+	}
+
+	return p.buf.Flush()
+}
+
+func (p *Publisher) WriteTrailer() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn != nil {
+		log.Print("[SRT PUBLISH] close")
+		p.buf.Flush()
+		p.conn.Close()
+		p.conn = nil
+		p.buf = nil
+		p.writer = nil
+		p.codecs = nil
+		p.tracks = nil
+	}
+
+	return nil
+}
+
+func (p *Publisher) Close() error {
+	return p.WriteTrailer()
+}
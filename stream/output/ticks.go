@@ -0,0 +1,9 @@
+package output
+
+import "time"
+
+// Ticks converts a timestamp to units of a clock rate. It splits off the
+// whole seconds so that long timestamps do not overflow the multiplication.
+func Ticks(d time.Duration, rate int) int64 {
+	return int64(d/time.Second)*int64(rate) + int64(d%time.Second)*int64(rate)/int64(time.Second)
+}
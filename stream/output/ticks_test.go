@@ -0,0 +1,27 @@
+package output
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTicks(t *testing.T) {
+	for _, tc := range []struct {
+		d    time.Duration
+		rate int
+		want int64
+	}{
+		{0, 90000, 0},
+		{40 * time.Millisecond, 90000, 3600},
+		{1500 * time.Millisecond, 48000, 72000},
+		{-time.Second, 90000, -90000},
+		// d * rate overflows int64 beyond about 28 hours at 90 kHz.
+		{1000 * time.Hour, 90000, 1000 * 3600 * 90000},
+		{math.MaxInt64, 90000, 830103483316929},
+	} {
+		if got := Ticks(tc.d, tc.rate); got != tc.want {
+			t.Errorf("Ticks(%v, %d) = %d, want %d", tc.d, tc.rate, got, tc.want)
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"regexp"
 
+	"github.com/jaesung9507/playgo/stream/platform"
+
 	"github.com/dop251/goja"
 )
 
@@ -46,7 +48,7 @@ func GetLiveHLSURL(client *http.Client, channelSlug string) (string, error) {
 	}
 
 	if len(result.Args) < 1 || len(result.Args[0].BodyData.Live.PlaybackURL) <= 0 {
-		return "", fmt.Errorf("not found playback url: %+v", result)
+		return "", fmt.Errorf("%w: not found playback url: %+v", platform.ErrOffline, result)
 	}
 
 	return result.Args[0].BodyData.Live.PlaybackURL, nil
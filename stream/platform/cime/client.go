@@ -8,135 +8,67 @@ import (
 	"strings"
 
 	"github.com/jaesung9507/playgo/secure"
-	"github.com/jaesung9507/playgo/stream"
-	"github.com/jaesung9507/playgo/stream/protocol/hls"
-	httpStream "github.com/jaesung9507/playgo/stream/protocol/http"
+	"github.com/jaesung9507/playgo/stream/platform"
 )
 
-type Client struct {
-	url        *url.URL
-	hlsClient  *hls.Client
-	httpClient *httpStream.MP4Client
+type Extractor struct {
+	url *url.URL
 }
 
-func New(parsedURL *url.URL) *Client {
+func New(parsedURL *url.URL) *platform.Client {
 	parsedURL.Path = strings.TrimSuffix(parsedURL.Path, "/")
-	return &Client{
-		url: parsedURL,
-	}
+	return platform.NewClient("CIME", &Extractor{url: parsedURL})
 }
 
-func (c *Client) Dial() error {
+func (e *Extractor) Extract() (*platform.Media, error) {
 	httpClient := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: (&secure.TLS{}).Config(),
 		},
 	}
 
-	log.Printf("[CEMI] dial: %s", c.url.String())
-	var hlsURL, mp4URL *url.URL
-	if channelPath, ok := strings.CutPrefix(c.url.Path, "/@"); ok {
+	log.Printf("[CIME] dial: %s", e.url.String())
+	if channelPath, ok := strings.CutPrefix(e.url.Path, "/@"); ok {
 		if channelSlug, tailPath, ok := strings.Cut(channelPath, "/"); ok {
 			if tailPath == "live" {
 				rawURL, err := GetLiveHLSURL(httpClient, channelSlug)
 				if err != nil {
-					return err
+					return nil, err
 				}
 
-				hlsURL, err = url.Parse(rawURL)
-				if err != nil {
-					return err
+				media := &platform.Media{Kind: platform.KindLive, Channel: channelSlug}
+				if err = media.AddURL(platform.ProtocolHLS, rawURL); err != nil {
+					return nil, err
 				}
+
+				return media, nil
 			} else if vodID, ok := strings.CutPrefix(tailPath, "vods/"); ok {
 				rawURL, err := GetVODHLSURL(httpClient, channelSlug, vodID)
 				if err != nil {
-					return err
+					return nil, err
 				}
 
-				hlsURL, err = url.Parse(rawURL)
-				if err != nil {
-					return err
+				media := &platform.Media{Kind: platform.KindVOD, Channel: channelSlug}
+				if err = media.AddURL(platform.ProtocolHLS, rawURL); err != nil {
+					return nil, err
 				}
+
+				return media, nil
 			}
 		}
-	} else if clipID, ok := strings.CutPrefix(c.url.Path, "/clips/"); ok {
+	} else if clipID, ok := strings.CutPrefix(e.url.Path, "/clips/"); ok {
 		rawURL, err := GetClipMP4URL(httpClient, clipID)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		mp4URL, err = url.Parse(rawURL)
-		if err != nil {
-			return err
+		media := &platform.Media{Kind: platform.KindClip}
+		if err = media.AddURL(platform.ProtocolMP4, rawURL); err != nil {
+			return nil, err
 		}
-	}
-
-	if hlsURL != nil {
-		c.hlsClient = hls.New(hlsURL)
-		return c.hlsClient.Dial()
-	} else if mp4URL != nil {
-		c.httpClient = httpStream.NewMP4Client(mp4URL)
-		return c.httpClient.Dial()
-	}
-
-	return errors.New("not supported url")
-}
-
-func (c *Client) Close() {
-	log.Print("[CEMI] close")
-	if c.hlsClient != nil {
-		c.hlsClient.Close()
-	}
-
-	if c.httpClient != nil {
-		c.httpClient.Close()
-	}
-}
-
-func (c *Client) CodecData() ([]stream.Codec, error) {
-	if c.hlsClient != nil {
-		return c.hlsClient.CodecData()
-	}
-
-	if c.httpClient != nil {
-		return c.httpClient.CodecData()
-	}
-
-	return nil, errors.New("not supported")
-}
-
-func (c *Client) PacketQueue() <-chan *stream.Packet {
-	if c.hlsClient != nil {
-		return c.hlsClient.PacketQueue()
-	}
-
-	if c.httpClient != nil {
-		return c.httpClient.PacketQueue()
-	}
-
-	return nil
-}
-
-func (c *Client) CloseCh() <-chan any {
-	if c.hlsClient != nil {
-		return c.hlsClient.CloseCh()
-	}
-
-	if c.httpClient != nil {
-		return c.httpClient.CloseCh()
-	}
-
-	return nil
-}
-
-func (c *Client) Secure() (bool, bool, map[string]string) {
-	if c.hlsClient != nil {
-		return c.hlsClient.Secure()
-	}
 
-	if c.httpClient != nil {
-		return c.httpClient.Secure()
+		return media, nil
 	}
 
-	return false, false, nil
+	return nil, errors.New("not supported url")
 }
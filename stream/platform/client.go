@@ -0,0 +1,221 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jaesung9507/playgo/secure"
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/protocol/hls"
+	httpStream "github.com/jaesung9507/playgo/stream/protocol/http"
+)
+
+// Client plays the media resolved by an Extractor through the matching
+// protocol client.
+type Client struct {
+	name      string
+	extractor Extractor
+	media     *Media
+	quality   QualityPreference
+	selected  *Quality
+	client    stream.Client
+	tls       *secure.TLS
+
+	waitCtx      context.Context
+	waitInterval time.Duration
+	onStatus     func(string)
+}
+
+func NewClient(name string, extractor Extractor) *Client {
+	return &Client{
+		name:      name,
+		extractor: extractor,
+	}
+}
+
+// SetQualityPreference limits the quality picked by Dial.
+func (c *Client) SetQualityPreference(pref QualityPreference) {
+	c.quality = pref
+}
+
+// WaitForLive makes Dial poll an offline channel every interval until it
+// starts broadcasting or ctx is done. onStatus receives the reason for each
+// retry.
+func (c *Client) WaitForLive(ctx context.Context, interval time.Duration, onStatus func(string)) {
+	c.waitCtx = ctx
+	c.waitInterval = interval
+	c.onStatus = onStatus
+}
+
+func (c *Client) extract(ctx context.Context) (*Media, error) {
+	for {
+		var media *Media
+		var err error
+		if ce, ok := c.extractor.(ContextExtractor); ok {
+			media, err = ce.ExtractContext(ctx)
+		} else {
+			media, err = c.extractor.Extract()
+		}
+		if err == nil || c.waitCtx == nil || c.waitInterval <= 0 || !errors.Is(err, ErrOffline) {
+			return media, err
+		}
+
+		log.Printf("[%s] wait for live: %v", c.name, err)
+		if c.onStatus != nil {
+			c.onStatus(err.Error())
+		}
+
+		select {
+		case <-c.waitCtx.Done():
+			return nil, c.waitCtx.Err()
+		case <-time.After(c.waitInterval):
+		}
+	}
+}
+
+type transport struct {
+	Transport http.RoundTripper
+	Header    map[string]string
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for k, v := range t.Header {
+		req.Header.Set(k, v)
+	}
+	return t.Transport.RoundTrip(req)
+}
+
+func (c *Client) httpClient() *http.Client {
+	c.tls = &secure.TLS{}
+	return &http.Client{
+		Transport: &transport{
+			Transport: &http.Transport{
+				TLSClientConfig: c.tls.Config(),
+			},
+			Header: c.media.Header,
+		},
+		Jar: c.media.Jar,
+	}
+}
+
+func (c *Client) newMP4Client(u *url.URL) *httpStream.MP4Client {
+	mp4Client := httpStream.NewMP4Client(u)
+	if len(c.media.Header) > 0 || c.media.Jar != nil {
+		mp4Client.SetHTTPClient(c.httpClient())
+	}
+
+	return mp4Client
+}
+
+func (c *Client) Dial() error {
+	return c.DialContext(context.Background())
+}
+
+// DialContext is Dial with the requests of extractors that implement
+// ContextExtractor canceled when ctx is done.
+func (c *Client) DialContext(ctx context.Context) error {
+	media, err := c.extract(ctx)
+	if err != nil {
+		return err
+	}
+	c.media = media
+	log.Printf("[%s] %s: title=%q channel=%q", c.name, media.Kind, media.Title, media.Channel)
+
+	for _, q := range media.Qualities {
+		log.Printf("[%s] available: %q %dx%d %d audioOnly=%t", c.name, q.Label, q.Width, q.Height, q.Bitrate, q.AudioOnly)
+	}
+
+	quality := media.Select(c.quality)
+	if quality == nil {
+		return errors.New("not supported url")
+	}
+	log.Printf("[%s] quality(%s): %q %dx%d %d separateAudio=%t", c.name, c.quality, quality.Label, quality.Width, quality.Height, quality.Bitrate, quality.AudioURL != nil)
+	if quality.Resolve != nil {
+		if quality.URL, err = quality.Resolve(ctx); err != nil {
+			return err
+		}
+	}
+	c.selected = quality
+
+	switch quality.Protocol {
+	case ProtocolHLS:
+		hlsClient := hls.New(quality.URL)
+		c.client = hlsClient
+		return hlsClient.DialWithHeader(media.Header)
+	case ProtocolMP4:
+		if quality.AudioURL != nil {
+			c.client = stream.NewMergedClient(c.newMP4Client(quality.URL), c.newMP4Client(quality.AudioURL))
+		} else {
+			c.client = c.newMP4Client(quality.URL)
+		}
+		return c.client.Dial()
+	case ProtocolHTTP:
+		httpClient := httpStream.New(quality.URL)
+		c.client = httpClient
+		return httpClient.DialWithHeader(media.Header)
+	}
+
+	return fmt.Errorf("unsupported protocol: %s", quality.Protocol)
+}
+
+// Media returns the description resolved by Dial.
+func (c *Client) Media() *Media {
+	return c.media
+}
+
+// Quality returns the quality picked by Dial.
+func (c *Client) Quality() *Quality {
+	return c.selected
+}
+
+func (c *Client) Close() {
+	log.Printf("[%s] close", c.name)
+	if c.client != nil {
+		c.client.Close()
+	}
+}
+
+func (c *Client) CodecData() ([]stream.Codec, error) {
+	if c.client != nil {
+		return c.client.CodecData()
+	}
+
+	return nil, errors.New("not supported")
+}
+
+func (c *Client) SkippedTracks() []stream.SkippedTrack {
+	return stream.SkippedTracks(c.client)
+}
+
+func (c *Client) PacketQueue() *stream.PacketQueue {
+	if c.client != nil {
+		return c.client.PacketQueue()
+	}
+
+	return nil
+}
+
+func (c *Client) CloseCh() <-chan any {
+	if c.client != nil {
+		return c.client.CloseCh()
+	}
+
+	return nil
+}
+
+func (c *Client) Secure() (bool, bool, map[string]string) {
+	if c.tls != nil {
+		return c.tls.Info()
+	}
+
+	if c.client != nil {
+		return c.client.Secure()
+	}
+
+	return false, false, nil
+}
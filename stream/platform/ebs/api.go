@@ -0,0 +1,98 @@
+package ebs
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+type OnAir struct {
+	Title       string
+	ChannelName string
+	HLSURL      string
+}
+
+var channels = []struct {
+	ID   string
+	Name string
+}{
+	{"tv", "EBS 1TV"},
+	{"2tv", "EBS 2TV"},
+}
+
+// GetOnAir returns the HLS URL that the on-air page of a channel plays, with
+// the title of the program on air.
+func GetOnAir(client *http.Client, channelID string) (*OnAir, error) {
+	for _, channel := range channels {
+		if channel.ID != channelID {
+			continue
+		}
+
+		hlsURL, err := getHLSURL(client, channelID)
+		if err != nil {
+			return nil, err
+		}
+
+		onAir := &OnAir{
+			Title:       channel.Name,
+			ChannelName: channel.Name,
+			HLSURL:      hlsURL,
+		}
+		if title, err := getProgramTitle(client, channelID); err == nil && len(title) > 0 {
+			onAir.Title = title
+		}
+
+		return onAir, nil
+	}
+
+	return nil, fmt.Errorf("not supported channel: %s", channelID)
+}
+
+// getHLSURL returns the playlist the player of the on-air page is set up
+// with, as a plain or JSON escaped URL in the page scripts.
+func getHLSURL(client *http.Client, channelID string) (string, error) {
+	resp, err := client.Get(fmt.Sprintf("https://www.ebs.co.kr/onair/%s", channelID))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("onair page status=%d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4*1024*1024))
+	if err != nil {
+		return "", fmt.Errorf("failed to read body: %w", err)
+	}
+
+	m := regexp.MustCompile(`https?:(?:\\?/)+[^"'\s<>]+?\.m3u8[^"'\s<>]*`).Find(data)
+	if m == nil {
+		return "", fmt.Errorf("not found hls url: content-length=%d", len(data))
+	}
+
+	return html.UnescapeString(strings.ReplaceAll(string(m), `\/`, "/")), nil
+}
+
+func getProgramTitle(client *http.Client, channelID string) (string, error) {
+	resp, err := client.Get(fmt.Sprintf("https://www.ebs.co.kr/onair/cururentOnair.json?channelCd=%s", channelID))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	result := &struct {
+		NowProgram struct {
+			Title string `json:"title"`
+		} `json:"nowProgram"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return "", fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	return result.NowProgram.Title, nil
+}
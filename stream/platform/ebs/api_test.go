@@ -0,0 +1,70 @@
+package ebs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jaesung9507/playgo/stream/internal/streamtest"
+)
+
+func onAirServer(t *testing.T, page string) *http.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/onair/2tv":
+			w.Write([]byte(page))
+		case "/onair/cururentOnair.json":
+			if r.URL.Query().Get("channelCd") != "2tv" {
+				t.Errorf("unexpected query: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"nowProgram":{"title":"program title"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return streamtest.Client(srv)
+}
+
+func TestGetOnAir(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		page string
+		want string
+	}{
+		{
+			name: "script",
+			page: `<script>var player = new Player({src: "https://ebsonair.ebs.co.kr/ebs2familypc/familypc1m/playlist.m3u8?token=a&amp;e=1"});</script>`,
+			want: "https://ebsonair.ebs.co.kr/ebs2familypc/familypc1m/playlist.m3u8?token=a&e=1",
+		},
+		{
+			name: "json",
+			page: `<script>window.__DATA__ = {"onair":{"hlsUrl":"https:\/\/ebsonair.ebs.co.kr\/ebs2familypc\/familypc1m\/playlist.m3u8"}};</script>`,
+			want: "https://ebsonair.ebs.co.kr/ebs2familypc/familypc1m/playlist.m3u8",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			onAir, err := GetOnAir(onAirServer(t, tt.page), "2tv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if onAir.HLSURL != tt.want || onAir.Title != "program title" || onAir.ChannelName != "EBS 2TV" {
+				t.Errorf("on air = %+v", onAir)
+			}
+		})
+	}
+}
+
+func TestGetOnAirErrors(t *testing.T) {
+	client := onAirServer(t, `<html><body>no player</body></html>`)
+	if _, err := GetOnAir(client, "2tv"); err == nil {
+		t.Error("expected an error for a page without a playlist")
+	}
+	if _, err := GetOnAir(client, "tv"); err == nil {
+		t.Error("expected an error for a missing page")
+	}
+	if _, err := GetOnAir(client, "radio"); err == nil {
+		t.Error("expected an error for an unknown channel")
+	}
+}
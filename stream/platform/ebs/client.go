@@ -0,0 +1,70 @@
+package ebs
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jaesung9507/playgo/secure"
+	"github.com/jaesung9507/playgo/stream/platform"
+)
+
+type Extractor struct {
+	url *url.URL
+}
+
+func New(parsedURL *url.URL) *platform.Client {
+	parsedURL.Path = strings.TrimSuffix(parsedURL.Path, "/")
+	return platform.NewClient("EBS", &Extractor{url: parsedURL})
+}
+
+// Channels returns the on-air channels for the channel guide.
+func Channels() []platform.Channel {
+	var result []platform.Channel
+	for _, channel := range channels {
+		result = append(result, platform.Channel{
+			Network: "EBS",
+			Name:    channel.Name,
+			URL:     fmt.Sprintf("https://www.ebs.co.kr/onair/%s", channel.ID),
+		})
+	}
+
+	return result
+}
+
+func (e *Extractor) Extract() (*platform.Media, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: (&secure.TLS{}).Config(),
+		},
+	}
+
+	log.Printf("[EBS] dial: %s", e.url.String())
+	channelID, ok := strings.CutPrefix(e.url.Path, "/onair")
+	if !ok {
+		return nil, errors.New("not supported url")
+	}
+	channelID = strings.TrimPrefix(channelID, "/")
+	if len(channelID) <= 0 {
+		channelID = "tv"
+	}
+
+	onAir, err := GetOnAir(client, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	media := &platform.Media{
+		Kind:    platform.KindLive,
+		Title:   onAir.Title,
+		Channel: onAir.ChannelName,
+	}
+	if err = media.AddURL(platform.ProtocolHLS, onAir.HLSURL); err != nil {
+		return nil, err
+	}
+
+	return media, nil
+}
@@ -0,0 +1,97 @@
+package kbs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type Stream struct {
+	Bitrate int
+	HLSURL  string
+}
+
+type OnAir struct {
+	Title       string
+	ChannelName string
+	Streams     []Stream
+}
+
+var channels = []struct {
+	Code string
+	Name string
+}{
+	{"11", "KBS 1TV"},
+	{"12", "KBS 2TV"},
+	{"81", "KBS NEWS D"},
+}
+
+// parseBitrate parses bitrates such as "1M" or "500K" into bits per second.
+func parseBitrate(s string) int {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	unit := 1
+	if v, ok := strings.CutSuffix(s, "M"); ok {
+		s, unit = v, 1000*1000
+	} else if v, ok := strings.CutSuffix(s, "K"); ok {
+		s, unit = v, 1000
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+
+	return int(f * float64(unit))
+}
+
+func GetOnAir(client *http.Client, channelCode string) (*OnAir, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://cfpwwwapi.kbs.co.kr/api/v1/landing/live/channel_code/%s", channelCode), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Referer", "https://onair.kbs.co.kr/")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("api status=%d", resp.StatusCode)
+	}
+
+	result := &struct {
+		ChannelMaster struct {
+			Title string `json:"title"`
+		} `json:"channelMaster"`
+		ChannelItem []struct {
+			Bitrate    string `json:"bitrate"`
+			ServiceURL string `json:"service_url"`
+		} `json:"channel_item"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	onAir := &OnAir{
+		Title:       result.ChannelMaster.Title,
+		ChannelName: result.ChannelMaster.Title,
+	}
+	for _, item := range result.ChannelItem {
+		if len(item.ServiceURL) > 0 {
+			onAir.Streams = append(onAir.Streams, Stream{
+				Bitrate: parseBitrate(item.Bitrate),
+				HLSURL:  item.ServiceURL,
+			})
+		}
+	}
+
+	if len(onAir.Streams) <= 0 {
+		return nil, fmt.Errorf("not found service url: %s", channelCode)
+	}
+
+	return onAir, nil
+}
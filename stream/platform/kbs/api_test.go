@@ -0,0 +1,66 @@
+package kbs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jaesung9507/playgo/stream/internal/streamtest"
+)
+
+func apiServer(t *testing.T, status int, body string) *http.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/landing/live/channel_code/11" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Referer") != "https://onair.kbs.co.kr/" {
+			t.Errorf("Referer = %q", r.Header.Get("Referer"))
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return streamtest.Client(srv)
+}
+
+func TestGetOnAir(t *testing.T) {
+	client := apiServer(t, http.StatusOK, `{"channelMaster":{"title":"KBS 1TV"},"channel_item":[
+		{"bitrate":"1M","service_url":"https://1tv.example.com/1m.m3u8"},
+		{"bitrate":"500K","service_url":"https://1tv.example.com/500k.m3u8"},
+		{"bitrate":"2M","service_url":""}]}`)
+
+	onAir, err := GetOnAir(client, "11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Stream{
+		{Bitrate: 1000000, HLSURL: "https://1tv.example.com/1m.m3u8"},
+		{Bitrate: 500000, HLSURL: "https://1tv.example.com/500k.m3u8"},
+	}
+	if onAir.Title != "KBS 1TV" || len(onAir.Streams) != len(want) {
+		t.Fatalf("on air = %+v", onAir)
+	}
+	for i := range want {
+		if onAir.Streams[i] != want[i] {
+			t.Errorf("stream %d = %+v, want %+v", i, onAir.Streams[i], want[i])
+		}
+	}
+}
+
+func TestGetOnAirErrors(t *testing.T) {
+	if _, err := GetOnAir(apiServer(t, http.StatusNotFound, ""), "11"); err == nil {
+		t.Error("expected an error for a failed request")
+	}
+	if _, err := GetOnAir(apiServer(t, http.StatusOK, `{"channel_item":[]}`), "11"); err == nil {
+		t.Error("expected an error without streams")
+	}
+}
+
+func TestParseBitrate(t *testing.T) {
+	for s, want := range map[string]int{"1M": 1000000, "1.5m": 1500000, "500K": 500000, "800": 800, "HD": 0} {
+		if got := parseBitrate(s); got != want {
+			t.Errorf("parseBitrate(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package kbs
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/jaesung9507/playgo/secure"
+	"github.com/jaesung9507/playgo/stream/platform"
+)
+
+type Extractor struct {
+	url *url.URL
+}
+
+func New(parsedURL *url.URL) *platform.Client {
+	return platform.NewClient("KBS", &Extractor{url: parsedURL})
+}
+
+// Channels returns the on-air channels for the channel guide.
+func Channels() []platform.Channel {
+	var result []platform.Channel
+	for _, channel := range channels {
+		result = append(result, platform.Channel{
+			Network: "KBS",
+			Name:    channel.Name,
+			URL:     fmt.Sprintf("https://onair.kbs.co.kr/index.html?sname=onair&stype=live&ch_code=%s", channel.Code),
+		})
+	}
+
+	return result
+}
+
+func (e *Extractor) Extract() (*platform.Media, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: (&secure.TLS{}).Config(),
+		},
+	}
+
+	log.Printf("[KBS] dial: %s", e.url.String())
+	channelCode := e.url.Query().Get("ch_code")
+	if len(channelCode) <= 0 {
+		return nil, errors.New("not supported url")
+	}
+
+	onAir, err := GetOnAir(client, channelCode)
+	if err != nil {
+		return nil, err
+	}
+
+	media := &platform.Media{
+		Kind:    platform.KindLive,
+		Title:   onAir.Title,
+		Channel: onAir.ChannelName,
+	}
+	for _, stream := range onAir.Streams {
+		parsedURL, err := url.Parse(stream.HLSURL)
+		if err != nil {
+			continue
+		}
+
+		media.AddQuality(platform.Quality{
+			Label:    fmt.Sprintf("%dk", stream.Bitrate/1000),
+			Bitrate:  stream.Bitrate,
+			Protocol: platform.ProtocolHLS,
+			URL:      parsedURL,
+		})
+	}
+
+	return media, nil
+}
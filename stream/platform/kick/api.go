@@ -0,0 +1,151 @@
+package kick
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/jaesung9507/playgo/stream/platform"
+)
+
+// userAgent is sent to the API, which rejects clients that do not look like
+// a browser.
+const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/140.0.0.0 Safari/537.36"
+
+var apiURL = "https://kick.com/api"
+
+type Live struct {
+	Title     string
+	Username  string
+	Thumbnail string
+	HLSURL    string
+}
+
+type Video struct {
+	Title     string
+	Username  string
+	Thumbnail string
+	HLSURL    string
+}
+
+type Clip struct {
+	Title     string
+	Username  string
+	Thumbnail string
+	// MP4URL is empty for clips only available as HLS.
+	MP4URL string
+	HLSURL string
+}
+
+func getJSON(client *http.Client, rawURL string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("api status=%d", resp.StatusCode)
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	return nil
+}
+
+func GetLive(client *http.Client, channelSlug string) (*Live, error) {
+	result := &struct {
+		PlaybackURL string `json:"playback_url"`
+		User        struct {
+			Username string `json:"username"`
+		} `json:"user"`
+		Livestream *struct {
+			SessionTitle string `json:"session_title"`
+			IsLive       bool   `json:"is_live"`
+			Thumbnail    struct {
+				URL string `json:"url"`
+			} `json:"thumbnail"`
+		} `json:"livestream"`
+	}{}
+	if err := getJSON(client, fmt.Sprintf("%s/v2/channels/%s", apiURL, channelSlug), result); err != nil {
+		return nil, err
+	}
+
+	if result.Livestream == nil || !result.Livestream.IsLive || len(result.PlaybackURL) <= 0 {
+		return nil, fmt.Errorf("%w: %s", platform.ErrOffline, channelSlug)
+	}
+
+	return &Live{
+		Title:     result.Livestream.SessionTitle,
+		Username:  result.User.Username,
+		Thumbnail: result.Livestream.Thumbnail.URL,
+		HLSURL:    result.PlaybackURL,
+	}, nil
+}
+
+func GetVideo(client *http.Client, videoID string) (*Video, error) {
+	result := &struct {
+		Source     string `json:"source"`
+		Livestream struct {
+			SessionTitle string `json:"session_title"`
+			Thumbnail    string `json:"thumbnail"`
+			Channel      struct {
+				User struct {
+					Username string `json:"username"`
+				} `json:"user"`
+			} `json:"channel"`
+		} `json:"livestream"`
+	}{}
+	if err := getJSON(client, fmt.Sprintf("%s/v1/video/%s", apiURL, videoID), result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Source) <= 0 {
+		return nil, fmt.Errorf("not found video source: %s", videoID)
+	}
+
+	return &Video{
+		Title:     result.Livestream.SessionTitle,
+		Username:  result.Livestream.Channel.User.Username,
+		Thumbnail: result.Livestream.Thumbnail,
+		HLSURL:    result.Source,
+	}, nil
+}
+
+func GetClip(client *http.Client, clipID string) (*Clip, error) {
+	result := &struct {
+		Clip *struct {
+			Title        string `json:"title"`
+			ClipURL      string `json:"clip_url"`
+			VideoURL     string `json:"video_url"`
+			ThumbnailURL string `json:"thumbnail_url"`
+			Channel      struct {
+				Username string `json:"username"`
+			} `json:"channel"`
+		} `json:"clip"`
+	}{}
+	if err := getJSON(client, fmt.Sprintf("%s/v2/clips/%s", apiURL, clipID), result); err != nil {
+		return nil, err
+	}
+
+	if result.Clip == nil || (len(result.Clip.VideoURL) <= 0 && len(result.Clip.ClipURL) <= 0) {
+		return nil, fmt.Errorf("not found clip: %s", clipID)
+	}
+
+	return &Clip{
+		Title:     result.Clip.Title,
+		Username:  result.Clip.Channel.Username,
+		Thumbnail: result.Clip.ThumbnailURL,
+		MP4URL:    result.Clip.VideoURL,
+		HLSURL:    result.Clip.ClipURL,
+	}, nil
+}
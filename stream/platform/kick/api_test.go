@@ -0,0 +1,98 @@
+package kick
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jaesung9507/playgo/stream/platform"
+)
+
+// apiServer serves the API paths with the given bodies; other paths get 404
+// and, if status is not 200, every path gets status.
+func apiServer(t *testing.T, status int, responses map[string]string) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != userAgent {
+			t.Errorf("User-Agent = %q", r.Header.Get("User-Agent"))
+		}
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+
+	old := apiURL
+	apiURL = srv.URL + "/api"
+	t.Cleanup(func() { apiURL = old })
+}
+
+func extract(t *testing.T, rawURL string) (*platform.Media, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return (&Extractor{url: parsedURL}).Extract()
+}
+
+func TestExtractLive(t *testing.T) {
+	apiServer(t, http.StatusOK, map[string]string{
+		"/api/v2/channels/streamer": `{"playback_url":"https://playback.live-video.net/api/video/v1/channel.m3u8?token=abc","user":{"username":"Streamer"},"livestream":{"session_title":"live title","is_live":true,"thumbnail":{"url":"https://thumb"}}}`,
+	})
+
+	media, err := extract(t, "https://kick.com/streamer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media.Kind != platform.KindLive || media.Title != "live title" || media.Channel != "Streamer" || media.Thumbnail != "https://thumb" {
+		t.Errorf("media = %+v", media)
+	}
+	if playlist := media.Best(); playlist.Protocol != platform.ProtocolHLS || playlist.URL.Query().Get("token") != "abc" {
+		t.Errorf("playlist = %+v", playlist)
+	}
+}
+
+func TestExtractClip(t *testing.T) {
+	apiServer(t, http.StatusOK, map[string]string{
+		"/api/v2/clips/clip_01": `{"clip":{"title":"clip title","clip_url":"https://clips/playlist.m3u8","video_url":"https://clips/clip.mp4","channel":{"username":"Streamer"}}}`,
+	})
+
+	media, err := extract(t, "https://kick.com/streamer?clip=clip_01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if playlist := media.Best(); media.Kind != platform.KindClip || playlist.Protocol != platform.ProtocolMP4 {
+		t.Errorf("media = %+v, %+v", media, playlist)
+	}
+}
+
+func TestExtractOffline(t *testing.T) {
+	apiServer(t, http.StatusOK, map[string]string{
+		"/api/v2/channels/streamer": `{"playback_url":"","user":{"username":"Streamer"},"livestream":null}`,
+	})
+
+	if _, err := extract(t, "https://kick.com/streamer"); !errors.Is(err, platform.ErrOffline) {
+		t.Errorf("err = %v, want ErrOffline", err)
+	}
+}
+
+func TestExtractForbidden(t *testing.T) {
+	apiServer(t, http.StatusForbidden, nil)
+
+	_, err := extract(t, "https://kick.com/streamer")
+	if err == nil || !strings.Contains(err.Error(), "status=403") {
+		t.Errorf("err = %v, want status 403", err)
+	}
+}
@@ -0,0 +1,103 @@
+package kick
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/jaesung9507/playgo/secure"
+	"github.com/jaesung9507/playgo/stream/platform"
+)
+
+type Extractor struct {
+	url *url.URL
+}
+
+func New(parsedURL *url.URL) *platform.Client {
+	parsedURL.Path = strings.TrimSuffix(parsedURL.Path, "/")
+	return platform.NewClient("Kick", &Extractor{url: parsedURL})
+}
+
+func (e *Extractor) Extract() (*platform.Media, error) {
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: (&secure.TLS{}).Config(),
+		},
+	}
+
+	log.Printf("[Kick] dial: %s", e.url.String())
+	if clipID := e.url.Query().Get("clip"); len(clipID) > 0 {
+		return extractClip(httpClient, clipID)
+	}
+
+	if m := regexp.MustCompile(`^(?:/[^/]+)?/videos?/([0-9a-f-]{36})$`).FindStringSubmatch(e.url.Path); m != nil {
+		video, err := GetVideo(httpClient, m[1])
+		if err != nil {
+			return nil, err
+		}
+
+		media := &platform.Media{
+			Kind:      platform.KindVOD,
+			Title:     video.Title,
+			Channel:   video.Username,
+			Thumbnail: video.Thumbnail,
+		}
+		if err = media.AddURL(platform.ProtocolHLS, video.HLSURL); err != nil {
+			return nil, err
+		}
+
+		return media, nil
+	}
+
+	if m := regexp.MustCompile(`^/[^/]+/clips/([^/]+)$`).FindStringSubmatch(e.url.Path); m != nil {
+		return extractClip(httpClient, m[1])
+	}
+
+	if m := regexp.MustCompile(`^/([A-Za-z0-9_-]+)$`).FindStringSubmatch(e.url.Path); m != nil {
+		live, err := GetLive(httpClient, m[1])
+		if err != nil {
+			return nil, err
+		}
+
+		media := &platform.Media{
+			Kind:      platform.KindLive,
+			Title:     live.Title,
+			Channel:   live.Username,
+			Thumbnail: live.Thumbnail,
+		}
+		if err = media.AddURL(platform.ProtocolHLS, live.HLSURL); err != nil {
+			return nil, err
+		}
+
+		return media, nil
+	}
+
+	return nil, errors.New("not supported url")
+}
+
+func extractClip(httpClient *http.Client, clipID string) (*platform.Media, error) {
+	clip, err := GetClip(httpClient, clipID)
+	if err != nil {
+		return nil, err
+	}
+
+	media := &platform.Media{
+		Kind:      platform.KindClip,
+		Title:     clip.Title,
+		Channel:   clip.Username,
+		Thumbnail: clip.Thumbnail,
+	}
+	if len(clip.MP4URL) > 0 {
+		err = media.AddURL(platform.ProtocolMP4, clip.MP4URL)
+	} else {
+		err = media.AddURL(platform.ProtocolHLS, clip.HLSURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return media, nil
+}
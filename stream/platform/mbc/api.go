@@ -0,0 +1,72 @@
+package mbc
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type OnAir struct {
+	ChannelName string
+	HLSURL      string
+}
+
+var channels = []struct {
+	ID   string
+	Name string
+}{
+	{"MBC", "MBC"},
+	{"every1", "MBC every1"},
+	{"drama", "MBC Drama"},
+	{"on", "MBC ON"},
+	{"sports", "MBC Sports+"},
+}
+
+func channelName(channelID string) string {
+	for _, channel := range channels {
+		if channel.ID == channelID {
+			return channel.Name
+		}
+	}
+
+	return channelID
+}
+
+// GetOnAir returns the HLS URL of a channel. The API answers with the
+// playlist URL as plain text.
+func GetOnAir(client *http.Client, channelID string) (*OnAir, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://mediaapi.imbc.com/Player/OnAirURLUtil_secure.ashx", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Set("type", "Streaming")
+	q.Set("ch", channelID)
+	q.Set("protocol", "M3U8")
+	q.Set("agent", "pc")
+	req.URL.RawQuery = q.Encode()
+	req.Header.Set("Referer", "https://onair.imbc.com/")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	body := strings.Trim(strings.TrimSpace(string(data)), `"`)
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(body, "http") {
+		return nil, fmt.Errorf("not found hls url: status=%d, content-length=%d", resp.StatusCode, len(data))
+	}
+
+	return &OnAir{
+		ChannelName: channelName(channelID),
+		HLSURL:      body,
+	}, nil
+}
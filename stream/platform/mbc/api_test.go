@@ -0,0 +1,44 @@
+package mbc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jaesung9507/playgo/stream/internal/streamtest"
+)
+
+func apiServer(t *testing.T, status int, body string) *http.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/Player/OnAirURLUtil_secure.ashx" || q.Get("ch") != "drama" || q.Get("protocol") != "M3U8" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return streamtest.Client(srv)
+}
+
+func TestGetOnAir(t *testing.T) {
+	client := apiServer(t, http.StatusOK, "\"https://drama.example.com/playlist.m3u8?key=1\"\r\n")
+
+	onAir, err := GetOnAir(client, "drama")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if onAir.ChannelName != "MBC Drama" || onAir.HLSURL != "https://drama.example.com/playlist.m3u8?key=1" {
+		t.Errorf("on air = %+v", onAir)
+	}
+}
+
+func TestGetOnAirErrors(t *testing.T) {
+	if _, err := GetOnAir(apiServer(t, http.StatusForbidden, "https://drama.example.com/playlist.m3u8"), "drama"); err == nil {
+		t.Error("expected an error for a failed request")
+	}
+	if _, err := GetOnAir(apiServer(t, http.StatusOK, "<html>error</html>"), "drama"); err == nil {
+		t.Error("expected an error without a URL")
+	}
+}
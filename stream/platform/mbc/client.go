@@ -0,0 +1,67 @@
+package mbc
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jaesung9507/playgo/secure"
+	"github.com/jaesung9507/playgo/stream/platform"
+)
+
+type Extractor struct {
+	url *url.URL
+}
+
+func New(parsedURL *url.URL) *platform.Client {
+	return platform.NewClient("MBC", &Extractor{url: parsedURL})
+}
+
+// Channels returns the on-air channels for the channel guide.
+func Channels() []platform.Channel {
+	var result []platform.Channel
+	for _, channel := range channels {
+		result = append(result, platform.Channel{
+			Network: "MBC",
+			Name:    channel.Name,
+			URL:     fmt.Sprintf("https://onair.imbc.com/?ch=%s", channel.ID),
+		})
+	}
+
+	return result
+}
+
+func (e *Extractor) Extract() (*platform.Media, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: (&secure.TLS{}).Config(),
+		},
+	}
+
+	log.Printf("[MBC] dial: %s", e.url.String())
+	channelID := e.url.Query().Get("ch")
+	if len(channelID) <= 0 {
+		channelID = strings.Trim(e.url.Path, "/")
+	}
+	if len(channelID) <= 0 {
+		channelID = "MBC"
+	}
+
+	onAir, err := GetOnAir(client, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	media := &platform.Media{
+		Kind:    platform.KindLive,
+		Title:   onAir.ChannelName,
+		Channel: onAir.ChannelName,
+	}
+	if err = media.AddURL(platform.ProtocolHLS, onAir.HLSURL); err != nil {
+		return nil, err
+	}
+
+	return media, nil
+}
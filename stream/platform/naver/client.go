@@ -12,359 +12,330 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/jaesung9507/playgo/credential"
 	"github.com/jaesung9507/playgo/secure"
-	"github.com/jaesung9507/playgo/stream"
-	"github.com/jaesung9507/playgo/stream/protocol/hls"
-	httpStream "github.com/jaesung9507/playgo/stream/protocol/http"
+	"github.com/jaesung9507/playgo/stream/platform"
 
+	"github.com/jaesung9507/nvver"
 	"github.com/jaesung9507/nvver/chzzk"
 	"github.com/jaesung9507/nvver/shoppinglive"
 	"github.com/jaesung9507/nvver/tv"
 	"github.com/jaesung9507/nvver/webtoon"
 )
 
-type Client struct {
-	url        *url.URL
-	hlsClient  *hls.Client
-	httpClient *httpStream.MP4Client
+type Extractor struct {
+	url *url.URL
 }
 
-func New(parsedURL *url.URL) *Client {
-	return &Client{
-		url: parsedURL,
+func New(parsedURL *url.URL) *platform.Client {
+	return platform.NewClient("NAVER", &Extractor{url: parsedURL})
+}
+
+// addVODs adds the HLS renditions of a VOD playback, skipping those rejected
+// by skip.
+func addVODs(media *platform.Media, vods map[string]nvver.VODInfo, skip func(*url.URL) bool) {
+	for id, info := range vods {
+		parsedURL, err := url.Parse(info.URL)
+		if err != nil {
+			continue
+		}
+
+		if ext := filepath.Ext(path.Base(parsedURL.Path)); ext != ".m3u8" {
+			continue
+		}
+
+		if skip != nil && skip(parsedURL) {
+			continue
+		}
+
+		media.AddQuality(platform.Quality{
+			Label:     id,
+			Width:     info.Width,
+			Height:    info.Height,
+			Bitrate:   info.Bandwidth,
+			AudioOnly: info.Width == 0 && info.Height == 0 && strings.HasPrefix(info.Codecs, "mp4a"),
+			Protocol:  platform.ProtocolHLS,
+			URL:       parsedURL,
+		})
+	}
+}
+
+func addMP4s(media *platform.Media, mp4URLs map[string]string) error {
+	for id, rawURL := range mp4URLs {
+		parsedURL, err := url.Parse(rawURL)
+		if err != nil {
+			return err
+		}
+
+		media.AddQuality(platform.Quality{
+			Label:    id,
+			Protocol: platform.ProtocolMP4,
+			URL:      parsedURL,
+		})
 	}
+
+	return nil
 }
 
-func (c *Client) Dial() error {
+func channelName(channel any) string {
+	if m, ok := channel.(map[string]any); ok {
+		if name, ok := m["channelName"].(string); ok {
+			return name
+		}
+	}
+
+	return ""
+}
+
+func (e *Extractor) Extract() (*platform.Media, error) {
 	httpClient := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: (&secure.TLS{}).Config(),
 		},
+		Jar: credential.Lookup(credential.Naver).Jar(),
 	}
 
-	log.Printf("[NAVER] dial: %s", c.url.String())
-	var hlsURL, mp4URL *url.URL
-	switch c.url.Host {
+	log.Printf("[NAVER] dial: %s", e.url.String())
+	switch e.url.Host {
 	case "chzzk.naver.com":
 		client := chzzk.NewClient(httpClient)
-		if channelID, ok := strings.CutPrefix(c.url.Path, "/live/"); ok {
+		if channelID, ok := strings.CutPrefix(e.url.Path, "/live/"); ok {
 			liveDetail, err := client.GetLiveDetail(channelID)
 			if err != nil {
-				return err
+				return nil, err
+			}
+
+			if liveDetail.Status != "OPEN" {
+				return nil, fmt.Errorf("%w: status: %s", platform.ErrOffline, liveDetail.Status)
 			}
 
 			playback, err := liveDetail.GetLivePlayback()
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			rawURL := playback.HLSPath()
 			if len(rawURL) <= 0 {
-				return fmt.Errorf("status: %s", liveDetail.Status)
+				return nil, fmt.Errorf("status: %s", liveDetail.Status)
 			}
 
-			hlsURL, err = url.Parse(rawURL)
-			if err != nil {
-				return err
+			media := &platform.Media{
+				Kind:      platform.KindLive,
+				Title:     liveDetail.LiveTitle,
+				Channel:   channelName(liveDetail.Channel),
+				Thumbnail: strings.ReplaceAll(liveDetail.LiveImageURL, "{type}", "480"),
+			}
+			if err = media.AddURL(platform.ProtocolHLS, rawURL); err != nil {
+				return nil, err
 			}
-		} else if videoNo, ok := strings.CutPrefix(c.url.Path, "/video/"); ok {
+
+			return media, nil
+		} else if videoNo, ok := strings.CutPrefix(e.url.Path, "/video/"); ok {
 			videoNo, err := strconv.ParseInt(videoNo, 10, 64)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			video, err := client.GetVideoInfo(videoNo)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
+			media := &platform.Media{
+				Kind:      platform.KindVOD,
+				Title:     video.VideoTitle,
+				Channel:   channelName(video.Channel),
+				Thumbnail: video.ThumbnailImageURL,
+			}
 			if playback, err := video.GetLiveRewindPlayback(); err != nil {
 				vods, err := client.GetVideoURL(video.VideoNo, video.VideoID, video.InKey)
 				if err != nil {
-					return err
-				}
-
-				resolution := 0
-				for _, info := range vods {
-					currentResolution := info.Width * info.Height
-					if resolution < currentResolution {
-						parsedURL, err := url.Parse(info.URL)
-						if err != nil {
-							continue
-						}
-
-						if ext := filepath.Ext(path.Base(parsedURL.Path)); ext == ".m3u8" {
-							hlsURL = parsedURL
-							resolution = currentResolution
-						}
-					}
+					return nil, err
 				}
-			} else {
-				if rawURL := playback.HLSPath(); len(rawURL) > 0 {
-					hlsURL, err = url.Parse(rawURL)
-					if err != nil {
-						return err
-					}
+				addVODs(media, vods, nil)
+			} else if rawURL := playback.HLSPath(); len(rawURL) > 0 {
+				if err = media.AddURL(platform.ProtocolHLS, rawURL); err != nil {
+					return nil, err
 				}
 			}
-		} else if clipID, ok := strings.CutPrefix(c.url.Path, "/clips/"); ok {
+
+			return media, nil
+		} else if clipID, ok := strings.CutPrefix(e.url.Path, "/clips/"); ok {
 			clipDetail, err := client.GetClipDetail(clipID)
 			if err != nil {
-				return err
+				return nil, err
 			}
-			log.Printf("[NAVER] clip title: %s", clipDetail.ClipTitle)
 
 			mp4URLs, err := client.GetClipMP4URL(clipDetail.ClipUID, clipDetail.VideoID)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
-			for _, rawURL := range mp4URLs {
-				mp4URL, err = url.Parse(rawURL)
-				if err != nil {
-					return err
-				}
-				break
+			media := &platform.Media{
+				Kind:      platform.KindClip,
+				Title:     clipDetail.ClipTitle,
+				Thumbnail: clipDetail.ThumbnailImageURL,
 			}
+			if err = addMP4s(media, mp4URLs); err != nil {
+				return nil, err
+			}
+
+			return media, nil
 		}
 	case "tv.naver.com":
 		client := tv.NewClient(httpClient)
-		if liveNo, ok := strings.CutPrefix(c.url.Path, "/l/"); ok {
+		if liveNo, ok := strings.CutPrefix(e.url.Path, "/l/"); ok {
 			liveNo, err := strconv.ParseInt(liveNo, 10, 64)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			playback, err := client.GetLivePlayback(liveNo)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			rawURL := playback.HLSPath()
 			if len(rawURL) <= 0 {
-				return fmt.Errorf("not found hls path: %v", playback)
+				return nil, fmt.Errorf("not found hls path: %v", playback)
 			}
 
-			hlsURL, err = url.Parse(rawURL)
-			if err != nil {
-				return err
+			media := &platform.Media{Kind: platform.KindLive}
+			if err = media.AddURL(platform.ProtocolHLS, rawURL); err != nil {
+				return nil, err
 			}
-		} else if vodNo, ok := strings.CutPrefix(c.url.Path, "/v/"); ok {
+
+			return media, nil
+		} else if vodNo, ok := strings.CutPrefix(e.url.Path, "/v/"); ok {
 			vodNo, err := strconv.ParseInt(vodNo, 10, 64)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			vodInfo, err := client.GetVODInfo(vodNo)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			vods, err := client.GetVODURL(vodInfo.Clip.ClipNo, vodInfo.Clip.VideoID, vodInfo.Play.InKey)
 			if err != nil {
-				return err
-			}
-
-			resolution := 0
-			for _, info := range vods {
-				currentResolution := info.Width * info.Height
-				if resolution < currentResolution {
-					parsedURL, err := url.Parse(info.URL)
-					if err != nil {
-						continue
-					}
-
-					if ext := filepath.Ext(path.Base(parsedURL.Path)); ext == ".m3u8" {
-						hlsURL = parsedURL
-						resolution = currentResolution
-					}
-				}
+				return nil, err
+			}
+
+			media := &platform.Media{
+				Kind:    platform.KindVOD,
+				Title:   vodInfo.Clip.Title,
+				Channel: vodInfo.Clip.ChannelName,
 			}
-		} else if clipNo, ok := strings.CutPrefix(c.url.Path, "/h/"); ok {
+			addVODs(media, vods, nil)
+
+			return media, nil
+		} else if clipNo, ok := strings.CutPrefix(e.url.Path, "/h/"); ok {
 			clipNo, err := strconv.ParseInt(clipNo, 10, 64)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			videoID, err := client.GetClipVideoID(clipNo)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			log.Printf("[NAVER] video id: %s", videoID)
 
 			mp4URLs, err := client.GetClipMP4URL(videoID)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
-			for _, rawURL := range mp4URLs {
-				mp4URL, err = url.Parse(rawURL)
-				if err != nil {
-					return err
-				}
-				break
+			media := &platform.Media{Kind: platform.KindClip}
+			if err = addMP4s(media, mp4URLs); err != nil {
+				return nil, err
 			}
+
+			return media, nil
 		}
 	case "view.shoppinglive.naver.com":
 		client := shoppinglive.NewClient(httpClient)
-		if broadcastID, ok := strings.CutPrefix(c.url.Path, "/lives/"); ok {
+		if broadcastID, ok := strings.CutPrefix(e.url.Path, "/lives/"); ok {
 			broadcastID, err := strconv.ParseInt(broadcastID, 10, 64)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			playback, err := client.GetLivePlayback(broadcastID)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			rawURL := playback.HLSPath()
 			if len(rawURL) <= 0 {
-				return fmt.Errorf("not found hls path: %v", playback)
+				return nil, fmt.Errorf("not found hls path: %v", playback)
 			}
 
-			hlsURL, err = url.Parse(rawURL)
-			if err != nil {
-				return err
+			media := &platform.Media{Kind: platform.KindLive}
+			if err = media.AddURL(platform.ProtocolHLS, rawURL); err != nil {
+				return nil, err
 			}
-		} else if shortClipID, ok := strings.CutPrefix(c.url.Path, "/shortclips/"); ok {
+
+			return media, nil
+		} else if shortClipID, ok := strings.CutPrefix(e.url.Path, "/shortclips/"); ok {
 			shortClipID, err := strconv.ParseInt(shortClipID, 10, 64)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			clip, err := client.GetShortClipInfo(shortClipID)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			vods, err := client.GetShortClipURL(clip.ShortClipID, clip.VODMediaURL)
 			if err != nil {
-				return err
-			}
-
-			resolution := 0
-			for _, info := range vods {
-				currentResolution := info.Width * info.Height
-				if resolution < currentResolution {
-					parsedURL, err := url.Parse(info.URL)
-					if err != nil {
-						continue
-					}
-
-					if ext := filepath.Ext(path.Base(parsedURL.Path)); ext == ".m3u8" {
-						if !strings.Contains(parsedURL.Path, "/cmaf/") {
-							hlsURL = parsedURL
-							resolution = currentResolution
-						}
-					}
-				}
+				return nil, err
 			}
+
+			media := &platform.Media{Kind: platform.KindClip}
+			addVODs(media, vods, func(u *url.URL) bool {
+				return strings.Contains(u.Path, "/cmaf/")
+			})
+
+			return media, nil
 		}
 	case "comic.naver.com":
-		httpClient.Jar, _ = cookiejar.New(nil)
+		if httpClient.Jar == nil {
+			httpClient.Jar, _ = cookiejar.New(nil)
+		}
 		client := webtoon.NewClient(httpClient)
-		if strings.HasPrefix(c.url.Path, "/cuts/") {
-			cutsID := c.url.Query().Get("id")
+		if strings.HasPrefix(e.url.Path, "/cuts/") {
+			cutsID := e.url.Query().Get("id")
 			if len(cutsID) <= 0 {
-				return errors.New("not found cuts id")
+				return nil, errors.New("not found cuts id")
 			}
 
 			token, err := client.GetCutsToken(cutsID)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			cuts, err := client.GetCutsInfo(cutsID)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			vods, err := client.GetCutsURL(cutsID, cuts.AssetID(), token)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
-			for _, info := range vods {
-				parsedURL, err := url.Parse(info.URL)
-				if err != nil {
-					continue
-				}
-
-				if ext := filepath.Ext(path.Base(parsedURL.Path)); ext == ".m3u8" {
-					hlsURL = parsedURL
-					break
-				}
+			media := &platform.Media{
+				Kind:  platform.KindClip,
+				Title: cuts.Title,
 			}
-		}
-	}
-
-	if hlsURL != nil {
-		c.hlsClient = hls.New(hlsURL)
-		return c.hlsClient.Dial()
-	} else if mp4URL != nil {
-		c.httpClient = httpStream.NewMP4Client(mp4URL)
-		return c.httpClient.Dial()
-	}
-
-	return errors.New("not supported url")
-}
-
-func (c *Client) Close() {
-	log.Print("[NAVER] close")
-	if c.hlsClient != nil {
-		c.hlsClient.Close()
-	}
-
-	if c.httpClient != nil {
-		c.httpClient.Close()
-	}
-}
-
-func (c *Client) CodecData() ([]stream.Codec, error) {
-	if c.hlsClient != nil {
-		return c.hlsClient.CodecData()
-	}
+			addVODs(media, vods, nil)
 
-	if c.httpClient != nil {
-		return c.httpClient.CodecData()
-	}
-
-	return nil, errors.New("not supported")
-}
-
-func (c *Client) PacketQueue() <-chan *stream.Packet {
-	if c.hlsClient != nil {
-		return c.hlsClient.PacketQueue()
-	}
-
-	if c.httpClient != nil {
-		return c.httpClient.PacketQueue()
-	}
-
-	return nil
-}
-
-func (c *Client) CloseCh() <-chan any {
-	if c.hlsClient != nil {
-		return c.hlsClient.CloseCh()
-	}
-
-	if c.httpClient != nil {
-		return c.httpClient.CloseCh()
-	}
-
-	return nil
-}
-
-func (c *Client) Secure() (bool, bool, map[string]string) {
-	if c.hlsClient != nil {
-		return c.hlsClient.Secure()
-	}
-
-	if c.httpClient != nil {
-		return c.httpClient.Secure()
+			return media, nil
+		}
 	}
 
-	return false, false, nil
+	return nil, errors.New("not supported url")
 }
@@ -0,0 +1,98 @@
+package naver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/jaesung9507/playgo/credential"
+	"github.com/jaesung9507/playgo/secure"
+	"github.com/jaesung9507/playgo/stream/platform"
+
+	"github.com/jaesung9507/nvver/chzzk"
+)
+
+const maxChannelVideos = 100
+
+var apiURL = "https://api.chzzk.naver.com"
+
+// Expand returns the latest videos of a CHZZK channel page, or nil if the URL
+// does not refer to a channel.
+func Expand(ctx context.Context, parsedURL *url.URL) ([]platform.Entry, error) {
+	if parsedURL.Host != "chzzk.naver.com" {
+		return nil, nil
+	}
+
+	m := regexp.MustCompile(`^/([0-9a-f]{32})(/videos)?/?$`).FindStringSubmatch(parsedURL.Path)
+	if m == nil {
+		return nil, nil
+	}
+
+	client := chzzk.NewClient(&http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: (&secure.TLS{}).Config(),
+		},
+		Jar: credential.Lookup(credential.Naver).Jar(),
+	})
+
+	var entries []platform.Entry
+	for page := 0; len(entries) < maxChannelVideos; page++ {
+		videos, total, err := getChannelVideos(ctx, client, m[1], page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, video := range videos {
+			entries = append(entries, platform.Entry{
+				Title:    video.VideoTitle,
+				URL:      fmt.Sprintf("https://chzzk.naver.com/video/%d", video.VideoNo),
+				Duration: time.Duration(video.Duration) * time.Second,
+			})
+		}
+
+		if len(videos) == 0 || len(entries) >= total {
+			break
+		}
+	}
+	entries = entries[:min(len(entries), maxChannelVideos)]
+	log.Printf("[NAVER] channel %s: %d videos", m[1], len(entries))
+
+	return entries, nil
+}
+
+func getChannelVideos(ctx context.Context, client *chzzk.Client, channelID string, page int) ([]chzzk.VideoInfo, int, error) {
+	rawURL := fmt.Sprintf("%s/service/v1/channels/%s/videos?sortType=LATEST&pagingType=PAGE&page=%d&size=30", apiURL, channelID, page)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to new request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	result := &struct {
+		Code    int `json:"code"`
+		Content struct {
+			TotalCount int               `json:"totalCount"`
+			Data       []chzzk.VideoInfo `json:"data"`
+		} `json:"content"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	return result.Content.Data, result.Content.TotalCount, nil
+}
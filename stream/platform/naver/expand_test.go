@@ -0,0 +1,113 @@
+package naver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const channelID = "0123456789abcdef0123456789abcdef"
+
+// channelServer serves total videos of a channel in pages of 30 and counts
+// the requested pages.
+func channelServer(t *testing.T, total int, reportedTotal int) (*httptest.Server, *int) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/service/v1/channels/"+channelID+"/videos" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+
+		var data []map[string]any
+		for no := page * size; no < min((page+1)*size, total); no++ {
+			data = append(data, map[string]any{
+				"videoNo":    no,
+				"videoTitle": fmt.Sprintf("video %d", no),
+				"duration":   60,
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"code":    200,
+			"content": map[string]any{"totalCount": reportedTotal, "data": data},
+		})
+	}))
+	old := apiURL
+	apiURL = srv.URL
+	t.Cleanup(func() { apiURL = old })
+
+	return srv, &requests
+}
+
+func TestExpandChannel(t *testing.T) {
+	tests := []struct {
+		name          string
+		total         int
+		reportedTotal int
+		wantEntries   int
+		wantRequests  int
+	}{
+		{"one page", 10, 10, 10, 1},
+		{"stops at total", 60, 60, 60, 2},
+		{"stops at empty page", 45, 1000, 45, 3},
+		{"stops at maximum", 500, 500, maxChannelVideos, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := channelServer(t, tt.total, tt.reportedTotal)
+			defer srv.Close()
+
+			u, _ := url.Parse("https://chzzk.naver.com/" + channelID + "/videos")
+			entries, err := Expand(context.Background(), u)
+			if err != nil {
+				t.Fatalf("expand: %v", err)
+			}
+			if len(entries) != tt.wantEntries {
+				t.Errorf("got %d entries, want %d", len(entries), tt.wantEntries)
+			}
+			if *requests != tt.wantRequests {
+				t.Errorf("got %d requests, want %d", *requests, tt.wantRequests)
+			}
+			if len(entries) > 0 {
+				first := entries[0]
+				if first.URL != "https://chzzk.naver.com/video/0" || first.Title != "video 0" || first.Duration != time.Minute {
+					t.Errorf("unexpected first entry: %+v", first)
+				}
+			}
+		})
+	}
+}
+
+func TestExpandNotChannel(t *testing.T) {
+	for _, rawURL := range []string{
+		"https://chzzk.naver.com/live/" + channelID,
+		"https://chzzk.naver.com/video/123",
+	} {
+		u, _ := url.Parse(rawURL)
+		if entries, err := Expand(context.Background(), u); entries != nil || err != nil {
+			t.Errorf("%s: got %v, %v", rawURL, entries, err)
+		}
+	}
+}
+
+func TestExpandStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+	old := apiURL
+	apiURL = srv.URL
+	t.Cleanup(func() { apiURL = old })
+
+	u, _ := url.Parse("https://chzzk.naver.com/" + channelID)
+	if _, err := Expand(context.Background(), u); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+
+	"github.com/jaesung9507/playgo/stream/platform"
 )
 
 func GetLiveHLSURL(client *http.Client, userID string) (string, error) {
@@ -40,7 +42,7 @@ func GetLiveHLSURL(client *http.Client, userID string) (string, error) {
 	}
 
 	if !result.Result || len(result.PlayList.HLS) <= 0 || len(result.PlayList.HLS[0].URL) <= 0 {
-		return "", fmt.Errorf("api status result=%t, msg=%q", result.Result, result.Msg)
+		return "", fmt.Errorf("%w: api status result=%t, msg=%q", platform.ErrOffline, result.Result, result.Msg)
 	}
 
 	return result.PlayList.HLS[0].URL, nil
@@ -8,22 +8,18 @@ import (
 	"strings"
 
 	"github.com/jaesung9507/playgo/secure"
-	"github.com/jaesung9507/playgo/stream"
-	"github.com/jaesung9507/playgo/stream/protocol/hls"
+	"github.com/jaesung9507/playgo/stream/platform"
 )
 
-type Client struct {
-	url       *url.URL
-	hlsClient *hls.Client
+type Extractor struct {
+	url *url.URL
 }
 
-func New(parsedURL *url.URL) *Client {
-	return &Client{
-		url: parsedURL,
-	}
+func New(parsedURL *url.URL) *platform.Client {
+	return platform.NewClient("PandaTV", &Extractor{url: parsedURL})
 }
 
-func (c *Client) Dial() error {
+func (e *Extractor) Extract() (*platform.Media, error) {
 	var tls secure.TLS
 	client := &http.Client{
 		Transport: &http.Transport{
@@ -31,65 +27,26 @@ func (c *Client) Dial() error {
 		},
 	}
 
-	log.Printf("[PandaTV] dial: %s", c.url.String())
-	var hlsURL *url.URL
-	if userID, ok := strings.CutPrefix(c.url.Path, "/play/"); ok {
+	log.Printf("[PandaTV] dial: %s", e.url.String())
+	if userID, ok := strings.CutPrefix(e.url.Path, "/play/"); ok {
 		rawURL, err := GetLiveHLSURL(client, userID)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		hlsURL, err = url.Parse(rawURL)
-		if err != nil {
-			return err
+		media := &platform.Media{
+			Kind:    platform.KindLive,
+			Channel: userID,
+			Header: map[string]string{
+				"Origin": "https://www.pandalive.co.kr",
+			},
+		}
+		if err = media.AddURL(platform.ProtocolHLS, rawURL); err != nil {
+			return nil, err
 		}
-	}
-
-	if hlsURL != nil {
-		c.hlsClient = hls.New(hlsURL)
-		return c.hlsClient.DialWithHeader(map[string]string{
-			"Origin": "https://www.pandalive.co.kr",
-		})
-	}
-
-	return errors.New("not supported url")
-}
-
-func (c *Client) Close() {
-	log.Print("[PandaTV] close")
-	if c.hlsClient != nil {
-		c.hlsClient.Close()
-	}
-}
-
-func (c *Client) CodecData() ([]stream.Codec, error) {
-	if c.hlsClient != nil {
-		return c.hlsClient.CodecData()
-	}
-
-	return nil, errors.New("not supported")
-}
-
-func (c *Client) PacketQueue() <-chan *stream.Packet {
-	if c.hlsClient != nil {
-		return c.hlsClient.PacketQueue()
-	}
-
-	return nil
-}
-
-func (c *Client) CloseCh() <-chan any {
-	if c.hlsClient != nil {
-		return c.hlsClient.CloseCh()
-	}
-
-	return nil
-}
 
-func (c *Client) Secure() (bool, bool, map[string]string) {
-	if c.hlsClient != nil {
-		return c.hlsClient.Secure()
+		return media, nil
 	}
 
-	return false, false, nil
+	return nil, errors.New("not supported url")
 }
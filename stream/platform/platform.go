@@ -0,0 +1,213 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrOffline is returned by extractors when a live channel is not
+// broadcasting.
+var ErrOffline = errors.New("channel is offline")
+
+type Kind string
+
+const (
+	KindLive Kind = "live"
+	KindVOD  Kind = "vod"
+	KindClip Kind = "clip"
+)
+
+type Protocol string
+
+const (
+	ProtocolHLS  Protocol = "hls"
+	ProtocolMP4  Protocol = "mp4"
+	ProtocolHTTP Protocol = "http"
+)
+
+type Quality struct {
+	Label     string
+	Width     int
+	Height    int
+	Bitrate   int
+	AudioOnly bool
+	Protocol  Protocol
+	URL       *url.URL
+	// AudioURL is set when the audio is delivered separately from URL, as
+	// with DASH representations.
+	AudioURL *url.URL
+	// Resolve, if set, returns URL once the quality has been selected, for
+	// platforms that authorize each quality with a separate request.
+	Resolve func(ctx context.Context) (*url.URL, error)
+}
+
+func (q *Quality) better(o *Quality) bool {
+	if q.Width*q.Height != o.Width*o.Height {
+		return q.Width*q.Height > o.Width*o.Height
+	}
+
+	if q.Height != o.Height {
+		return q.Height > o.Height
+	}
+
+	return q.Bitrate > o.Bitrate
+}
+
+// QualityPreference limits the quality picked by Media.Select. Zero values
+// mean no limit.
+type QualityPreference struct {
+	MaxHeight  int
+	MaxBitrate int
+	AudioOnly  bool
+}
+
+// ParseQualityPreference parses "best", "audio", a maximum height such as
+// "720p" or a maximum bitrate such as "1500k".
+func ParseQualityPreference(s string) (QualityPreference, error) {
+	var pref QualityPreference
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "" || s == "best":
+	case s == "audio":
+		pref.AudioOnly = true
+	case strings.HasSuffix(s, "p"):
+		height, err := strconv.Atoi(strings.TrimSuffix(s, "p"))
+		if err != nil || height <= 0 {
+			return pref, fmt.Errorf("invalid quality: %s", s)
+		}
+		pref.MaxHeight = height
+	case strings.HasSuffix(s, "k"):
+		kbps, err := strconv.Atoi(strings.TrimSuffix(s, "k"))
+		if err != nil || kbps <= 0 {
+			return pref, fmt.Errorf("invalid quality: %s", s)
+		}
+		pref.MaxBitrate = kbps * 1000
+	default:
+		return pref, fmt.Errorf("invalid quality: %s", s)
+	}
+
+	return pref, nil
+}
+
+func (p QualityPreference) String() string {
+	switch {
+	case p.AudioOnly:
+		return "audio"
+	case p.MaxHeight > 0:
+		return fmt.Sprintf("%dp", p.MaxHeight)
+	case p.MaxBitrate > 0:
+		return fmt.Sprintf("%dk", p.MaxBitrate/1000)
+	}
+
+	return "best"
+}
+
+// allows reports whether q is within the limits. Unknown resolutions and
+// bitrates are allowed.
+func (p QualityPreference) allows(q *Quality) bool {
+	if p.MaxHeight > 0 && q.Height > p.MaxHeight {
+		return false
+	}
+
+	return p.MaxBitrate <= 0 || q.Bitrate <= p.MaxBitrate
+}
+
+// Media is what an extractor resolves a platform page URL to.
+type Media struct {
+	Kind      Kind
+	Title     string
+	Channel   string
+	Thumbnail string
+	Qualities []Quality
+	Header    map[string]string
+	Jar       http.CookieJar
+}
+
+func (m *Media) AddQuality(q Quality) {
+	m.Qualities = append(m.Qualities, q)
+}
+
+// AddURL adds a single stream of unknown resolution.
+func (m *Media) AddURL(protocol Protocol, rawURL string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	m.AddQuality(Quality{Protocol: protocol, URL: parsedURL})
+
+	return nil
+}
+
+func (m *Media) pick(filter func(*Quality) bool, lowest bool) *Quality {
+	var found *Quality
+	for i := range m.Qualities {
+		q := &m.Qualities[i]
+		if !filter(q) {
+			continue
+		}
+
+		if found == nil || q.better(found) != lowest {
+			found = q
+		}
+	}
+
+	return found
+}
+
+// Best returns the quality with the largest resolution, then bitrate.
+func (m *Media) Best() *Quality {
+	return m.Select(QualityPreference{})
+}
+
+// Select returns the best quality within the preference. If every quality
+// exceeds it, the lowest one is returned instead. Audio only falls back to
+// the lowest quality with video when there is no audio only stream.
+func (m *Media) Select(pref QualityPreference) *Quality {
+	isVideo := func(q *Quality) bool { return !q.AudioOnly }
+	if pref.AudioOnly {
+		if q := m.pick(func(q *Quality) bool { return q.AudioOnly }, false); q != nil {
+			return q
+		}
+		return m.pick(isVideo, true)
+	}
+
+	if q := m.pick(func(q *Quality) bool { return isVideo(q) && pref.allows(q) }, false); q != nil {
+		return q
+	}
+
+	if q := m.pick(isVideo, true); q != nil {
+		return q
+	}
+
+	return m.pick(func(*Quality) bool { return true }, false)
+}
+
+type Extractor interface {
+	Extract() (*Media, error)
+}
+
+// ContextExtractor is implemented by extractors whose requests are canceled
+// with the context of the dial.
+type ContextExtractor interface {
+	ExtractContext(ctx context.Context) (*Media, error)
+}
+
+// Entry is a playable item of a collection such as a playlist or a channel.
+type Entry struct {
+	Title    string        `json:"title"`
+	URL      string        `json:"url"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Channel is an on-air channel listed in the channel guide.
+type Channel struct {
+	Network string `json:"network"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"regexp"
+
+	"github.com/jaesung9507/playgo/stream/platform"
 )
 
 type LiveInfo struct {
@@ -66,7 +68,7 @@ func (i *LiveInfo) GetHLSURL(client *http.Client) (string, error) {
 	}
 
 	if len(result.Data.CastHLSURL) <= 0 {
-		return "", fmt.Errorf("not found hls url: status code=%q msg=%q", result.StatusCode, result.StatusMsg)
+		return "", fmt.Errorf("%w: not found hls url: status code=%q msg=%q", platform.ErrOffline, result.StatusCode, result.StatusMsg)
 	}
 
 	return result.Data.CastHLSURL, nil
@@ -8,24 +8,18 @@ import (
 	"strings"
 
 	"github.com/jaesung9507/playgo/secure"
-	"github.com/jaesung9507/playgo/stream"
-	"github.com/jaesung9507/playgo/stream/protocol/hls"
-	httpStream "github.com/jaesung9507/playgo/stream/protocol/http"
+	"github.com/jaesung9507/playgo/stream/platform"
 )
 
-type Client struct {
-	url       *url.URL
-	hlsClient *hls.Client
-	mp4Client *httpStream.Client
+type Extractor struct {
+	url *url.URL
 }
 
-func New(parsedURL *url.URL) *Client {
-	return &Client{
-		url: parsedURL,
-	}
+func New(parsedURL *url.URL) *platform.Client {
+	return platform.NewClient("PopkonTV", &Extractor{url: parsedURL})
 }
 
-func (c *Client) Dial() error {
+func (e *Extractor) Extract() (*platform.Media, error) {
 	var tls secure.TLS
 	client := &http.Client{
 		Transport: &http.Transport{
@@ -33,103 +27,44 @@ func (c *Client) Dial() error {
 		},
 	}
 
-	log.Printf("[PopkonTV] dial: %s", c.url.String())
-	var hlsURL, mp4URL *url.URL
-	if strings.HasPrefix(c.url.Path, "/live/view") {
-		live, err := GetLiveInfo(client, c.url.String())
+	log.Printf("[PopkonTV] dial: %s", e.url.String())
+	if strings.HasPrefix(e.url.Path, "/live/view") {
+		live, err := GetLiveInfo(client, e.url.String())
 		if err != nil {
-			return err
+			return nil, err
 		}
-		log.Printf("[PopkonTV] video title: %s", live.Title)
 
 		rawURL, err := live.GetHLSURL(client)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		hlsURL, err = url.Parse(rawURL)
-		if err != nil {
-			return err
+		media := &platform.Media{
+			Kind:    platform.KindLive,
+			Title:   live.Title,
+			Channel: live.SignID,
 		}
-	} else if strings.HasPrefix(c.url.Path, "/clip/") {
-		clip, err := GetClipInfo(client, c.url.String())
-		if err != nil {
-			return err
+		if err = media.AddURL(platform.ProtocolHLS, rawURL); err != nil {
+			return nil, err
 		}
-		log.Printf("[PopkonTV] video title: %s", clip.Title)
 
-		mp4URL, err = url.Parse(clip.Address)
+		return media, nil
+	} else if strings.HasPrefix(e.url.Path, "/clip/") {
+		clip, err := GetClipInfo(client, e.url.String())
 		if err != nil {
-			return err
+			return nil, err
 		}
-	}
-
-	if hlsURL != nil {
-		c.hlsClient = hls.New(hlsURL)
-		return c.hlsClient.Dial()
-	} else if mp4URL != nil {
-		c.mp4Client = httpStream.New(mp4URL)
-		return c.mp4Client.Dial()
-	}
-
-	return errors.New("not supported url")
-}
-
-func (c *Client) Close() {
-	log.Print("[PopkonTV] close")
-	if c.hlsClient != nil {
-		c.hlsClient.Close()
-	}
-
-	if c.mp4Client != nil {
-		c.mp4Client.Close()
-	}
-}
-
-func (c *Client) CodecData() ([]stream.Codec, error) {
-	if c.hlsClient != nil {
-		return c.hlsClient.CodecData()
-	}
-
-	if c.mp4Client != nil {
-		return c.mp4Client.CodecData()
-	}
 
-	return nil, errors.New("not supported")
-}
-
-func (c *Client) PacketQueue() <-chan *stream.Packet {
-	if c.hlsClient != nil {
-		return c.hlsClient.PacketQueue()
-	}
-
-	if c.mp4Client != nil {
-		return c.mp4Client.PacketQueue()
-	}
-
-	return nil
-}
-
-func (c *Client) CloseCh() <-chan any {
-	if c.hlsClient != nil {
-		return c.hlsClient.CloseCh()
-	}
-
-	if c.mp4Client != nil {
-		return c.mp4Client.CloseCh()
-	}
-
-	return nil
-}
-
-func (c *Client) Secure() (bool, bool, map[string]string) {
-	if c.hlsClient != nil {
-		return c.hlsClient.Secure()
-	}
+		media := &platform.Media{
+			Kind:  platform.KindClip,
+			Title: clip.Title,
+		}
+		if err = media.AddURL(platform.ProtocolHTTP, clip.Address); err != nil {
+			return nil, err
+		}
 
-	if c.mp4Client != nil {
-		return c.mp4Client.Secure()
+		return media, nil
 	}
 
-	return false, false, nil
+	return nil, errors.New("not supported url")
 }
@@ -26,6 +26,13 @@ func (o *Response) HLSURL() string {
 	return o.Source.MediaSource.MediaURL
 }
 
+var channels = []struct {
+	ID   string
+	Name string
+}{
+	{"S01", "SBS"},
+}
+
 func getChannelPath(channelID string) string {
 	if m := regexp.MustCompile(`\d+$`).FindString(channelID); len(m) > 0 {
 		if num, err := strconv.Atoi(m); err == nil {
@@ -38,7 +45,9 @@ func getChannelPath(channelID string) string {
 	return ""
 }
 
-func GetOnAir(client *http.Client, channelID string) (*Response, error) {
+// GetOnAir and GetVOD take the jwt-token of a signed-in session, which may be
+// empty for content that does not require one.
+func GetOnAir(client *http.Client, channelID, jwtToken string) (*Response, error) {
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://apis.sbs.co.kr/play-api/1.0/onair%s/channel/%s", getChannelPath(channelID), channelID), nil)
 	if err != nil {
 		return nil, err
@@ -48,7 +57,7 @@ func GetOnAir(client *http.Client, channelID string) (*Response, error) {
 	q.Set("v_type", "2")
 	q.Set("platform", "pcweb")
 	q.Set("protocol", "hls")
-	q.Set("jwt-token", "")
+	q.Set("jwt-token", jwtToken)
 	q.Set("ssl", "Y")
 	q.Set("rscuse", "")
 	req.URL.RawQuery = q.Encode()
@@ -75,14 +84,14 @@ func GetOnAir(client *http.Client, channelID string) (*Response, error) {
 	return &result.OnAir, nil
 }
 
-func GetVOD(client *http.Client, mediaID string) (*Response, error) {
+func GetVOD(client *http.Client, mediaID, jwtToken string) (*Response, error) {
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://apis.sbs.co.kr/play-api/1.0/sbs_vodall/%s", mediaID), nil)
 	if err != nil {
 		return nil, err
 	}
 
 	q := req.URL.Query()
-	q.Set("jwt-token", "")
+	q.Set("jwt-token", jwtToken)
 	q.Set("platform", "pcweb")
 	q.Set("service", "program")
 	q.Set("absolute_show", "Y")
@@ -2,124 +2,91 @@ package sbs
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
 
+	"github.com/jaesung9507/playgo/credential"
 	"github.com/jaesung9507/playgo/secure"
-	"github.com/jaesung9507/playgo/stream"
-	"github.com/jaesung9507/playgo/stream/protocol/hls"
+	"github.com/jaesung9507/playgo/stream/platform"
 )
 
-type Client struct {
-	url       *url.URL
-	hlsClient *hls.Client
+type Extractor struct {
+	url *url.URL
 }
 
-func New(parsedURL *url.URL) *Client {
+func New(parsedURL *url.URL) *platform.Client {
 	parsedURL.Path = strings.TrimSuffix(parsedURL.Path, "/")
-	return &Client{
-		url: parsedURL,
+	return platform.NewClient("SBS", &Extractor{url: parsedURL})
+}
+
+// Channels returns the on-air channels for the channel guide.
+func Channels() []platform.Channel {
+	var result []platform.Channel
+	for _, channel := range channels {
+		result = append(result, platform.Channel{
+			Network: "SBS",
+			Name:    channel.Name,
+			URL:     fmt.Sprintf("https://www.sbs.co.kr/live/%s", channel.ID),
+		})
 	}
+
+	return result
 }
 
-func (c *Client) Dial() error {
+func (e *Extractor) Extract() (*platform.Media, error) {
+	cred := credential.Lookup(credential.SBS)
 	client := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: (&secure.TLS{}).Config(),
 		},
+		Jar: cred.Jar(),
 	}
 
-	log.Printf("[SBS] dial: %s", c.url.String())
+	log.Printf("[SBS] dial: %s", e.url.String())
 
-	var hlsURL *url.URL
-	switch c.url.Host {
+	var (
+		resp *Response
+		kind platform.Kind
+		err  error
+	)
+	switch e.url.Host {
 	case "sbs.co.kr", "www.sbs.co.kr":
-		if channelID, ok := strings.CutPrefix(c.url.Path, "/live/"); ok {
-			resp, err := GetOnAir(client, channelID)
-			if err != nil {
-				return err
-			}
-			log.Printf("[SBS] video title: %s", resp.Info.Title)
-
-			hlsURL, err = url.Parse(resp.HLSURL())
-			if err != nil {
-				return err
-			}
+		if channelID, ok := strings.CutPrefix(e.url.Path, "/live/"); ok {
+			kind = platform.KindLive
+			resp, err = GetOnAir(client, channelID, cred.Token)
 		}
 	case "allvod.sbs.co.kr":
-		if m := regexp.MustCompile(`^/watch/[^/]+/[^/]+/([^/]+)$`).FindStringSubmatch(c.url.Path); m != nil {
-			resp, err := GetVOD(client, m[1])
-			if err != nil {
-				return err
-			}
-			log.Printf("[SBS] video title: %s", resp.Info.Title)
-
-			hlsURL, err = url.Parse(resp.HLSURL())
-			if err != nil {
-				return err
-			}
+		if m := regexp.MustCompile(`^/watch/[^/]+/[^/]+/([^/]+)$`).FindStringSubmatch(e.url.Path); m != nil {
+			kind = platform.KindVOD
+			resp, err = GetVOD(client, m[1], cred.Token)
 		}
 	case "programs.sbs.co.kr":
-		if m := regexp.MustCompile(`^/[^/]+/[^/]+/[^/]+/[^/]+/([^/]+)$`).FindStringSubmatch(c.url.Path); m != nil {
-			resp, err := GetVOD(client, m[1])
-			if err != nil {
-				return err
-			}
-			log.Printf("[SBS] video title: %s", resp.Info.Title)
-
-			hlsURL, err = url.Parse(resp.HLSURL())
-			if err != nil {
-				return err
-			}
+		if m := regexp.MustCompile(`^/[^/]+/[^/]+/[^/]+/[^/]+/([^/]+)$`).FindStringSubmatch(e.url.Path); m != nil {
+			kind = platform.KindVOD
+			resp, err = GetVOD(client, m[1], cred.Token)
 		}
 	}
 
-	if hlsURL != nil {
-		c.hlsClient = hls.New(hlsURL)
-		return c.hlsClient.Dial()
-	}
-
-	return errors.New("not supported url")
-}
-
-func (c *Client) Close() {
-	log.Print("[SBS] close")
-	if c.hlsClient != nil {
-		c.hlsClient.Close()
-	}
-}
-
-func (c *Client) CodecData() ([]stream.Codec, error) {
-	if c.hlsClient != nil {
-		return c.hlsClient.CodecData()
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, errors.New("not supported")
-}
-
-func (c *Client) PacketQueue() <-chan *stream.Packet {
-	if c.hlsClient != nil {
-		return c.hlsClient.PacketQueue()
+	if resp == nil {
+		return nil, errors.New("not supported url")
 	}
 
-	return nil
-}
-
-func (c *Client) CloseCh() <-chan any {
-	if c.hlsClient != nil {
-		return c.hlsClient.CloseCh()
+	media := &platform.Media{
+		Kind:    kind,
+		Title:   resp.Info.Title,
+		Channel: resp.Info.ChannelName,
 	}
-
-	return nil
-}
-
-func (c *Client) Secure() (bool, bool, map[string]string) {
-	if c.hlsClient != nil {
-		return c.hlsClient.Secure()
+	if err = media.AddURL(platform.ProtocolHLS, resp.HLSURL()); err != nil {
+		return nil, err
 	}
 
-	return false, false, nil
+	return media, nil
 }
@@ -0,0 +1,129 @@
+package sbs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/jaesung9507/playgo/credential"
+	"github.com/jaesung9507/playgo/secure"
+	"github.com/jaesung9507/playgo/stream/platform"
+)
+
+var programsURL = "https://programs.sbs.co.kr"
+
+var (
+	anchorRegexp = regexp.MustCompile(`(?is)<a\s([^>]*)>(.*?)</a>`)
+	hrefRegexp   = regexp.MustCompile(`(?i)\bhref\s*=\s*["']([^"']+)["']`)
+	titleRegexp  = regexp.MustCompile(`(?i)\btitle\s*=\s*["']([^"']+)["']`)
+	tagRegexp    = regexp.MustCompile(`<[^>]*>`)
+)
+
+// Expand returns the episodes linked from an SBS program page, e.g.
+// https://programs.sbs.co.kr/{section}/{programCode}/{group}/{menuID}, in
+// page order. It returns nil if the URL refers to a single video.
+func Expand(ctx context.Context, parsedURL *url.URL) ([]platform.Entry, error) {
+	m := regexp.MustCompile(`^/([^/]+)/([^/]+)(/[^/]+/\d+)?/?$`).FindStringSubmatch(parsedURL.Path)
+	if m == nil {
+		return nil, nil
+	}
+	section, program := m[1], m[2]
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: (&secure.TLS{}).Config(),
+		},
+		Jar: credential.Lookup(credential.SBS).Jar(),
+	}
+
+	pageURL := programsURL + strings.TrimSuffix(parsedURL.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to new request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := programEpisodes(string(body), section, program)
+	if len(entries) <= 0 {
+		return nil, errors.New("no videos found on the program page")
+	}
+	log.Printf("[SBS] program %s: %d videos", program, len(entries))
+
+	return entries, nil
+}
+
+// programEpisodes collects the links to videos of the program, which end in
+// /{group}/{menuID}/{mediaID}. A video linked from both its thumbnail and
+// its title is listed once, with the title text.
+func programEpisodes(page, section, program string) []platform.Entry {
+	videoPath := regexp.MustCompile(`^/` + regexp.QuoteMeta(section) + `/` + regexp.QuoteMeta(program) + `/[^/]+/\d+/(\d+)/?$`)
+	base, _ := url.Parse("https://programs.sbs.co.kr/")
+
+	var entries []platform.Entry
+	index := make(map[string]int)
+	for _, a := range anchorRegexp.FindAllStringSubmatch(page, -1) {
+		href := hrefRegexp.FindStringSubmatch(a[1])
+		if href == nil {
+			continue
+		}
+
+		link, err := base.Parse(html.UnescapeString(href[1]))
+		if err != nil || link.Host != base.Host {
+			continue
+		}
+		m := videoPath.FindStringSubmatch(link.Path)
+		if m == nil {
+			continue
+		}
+
+		title := strings.Join(strings.Fields(html.UnescapeString(tagRegexp.ReplaceAllString(a[2], " "))), " ")
+		if len(title) <= 0 {
+			if t := titleRegexp.FindStringSubmatch(a[1]); t != nil {
+				title = html.UnescapeString(t[1])
+			}
+		}
+
+		if i, ok := index[m[1]]; ok {
+			if len(entries[i].Title) <= 0 {
+				entries[i].Title = title
+			}
+			continue
+		}
+
+		index[m[1]] = len(entries)
+		link.RawQuery, link.Fragment = "", ""
+		entries = append(entries, platform.Entry{
+			Title: title,
+			URL:   strings.TrimSuffix(link.String(), "/"),
+		})
+	}
+
+	for i := range entries {
+		if len(entries[i].Title) <= 0 {
+			entries[i].Title = entries[i].URL
+		}
+	}
+
+	return entries
+}
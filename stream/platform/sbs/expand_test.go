@@ -0,0 +1,79 @@
+package sbs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+const programPage = `<html><body>
+<ul class="vod_list">
+  <li>
+    <a href="/enter/runningman/vod/54795/22000500001"><img src="a.jpg" alt=""></a>
+    <a href="/enter/runningman/vod/54795/22000500001?from=list" class="title"><strong>Episode 1</strong> &amp; friends</a>
+  </li>
+  <li><a href='https://programs.sbs.co.kr/enter/runningman/vod/54795/22000500002' title="Episode 2"><img src="b.jpg"></a></li>
+  <li><a href="/enter/othershow/vod/1/22000500003">Another program</a></li>
+  <li><a href="/enter/runningman/board/54796">Board</a></li>
+  <li><a href="https://example.com/enter/runningman/vod/54795/22000500004">Elsewhere</a></li>
+</ul>
+</body></html>`
+
+func TestExpand(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write([]byte(programPage))
+	}))
+	defer srv.Close()
+	old := programsURL
+	programsURL = srv.URL
+	t.Cleanup(func() { programsURL = old })
+
+	u, _ := url.Parse("https://programs.sbs.co.kr/enter/runningman/vod/54795/")
+	entries, err := Expand(context.Background(), u)
+	if err != nil {
+		t.Fatalf("expand: %v", err)
+	}
+	if path != "/enter/runningman/vod/54795" {
+		t.Errorf("requested %s", path)
+	}
+
+	want := []struct{ title, url string }{
+		{"Episode 1 & friends", "https://programs.sbs.co.kr/enter/runningman/vod/54795/22000500001"},
+		{"Episode 2", "https://programs.sbs.co.kr/enter/runningman/vod/54795/22000500002"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries: %+v", len(entries), entries)
+	}
+	for i, w := range want {
+		if entries[i].Title != w.title || entries[i].URL != w.url {
+			t.Errorf("entry %d: got %q %s, want %q %s", i, entries[i].Title, entries[i].URL, w.title, w.url)
+		}
+	}
+}
+
+func TestExpandSingleVideo(t *testing.T) {
+	u, _ := url.Parse("https://programs.sbs.co.kr/enter/runningman/vod/54795/22000500001")
+	entries, err := Expand(context.Background(), u)
+	if entries != nil || err != nil {
+		t.Fatalf("got %v, %v for a single video", entries, err)
+	}
+}
+
+func TestExpandNoVideos(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><div id="app"></div></body></html>`))
+	}))
+	defer srv.Close()
+	old := programsURL
+	programsURL = srv.URL
+	t.Cleanup(func() { programsURL = old })
+
+	u, _ := url.Parse("https://programs.sbs.co.kr/enter/runningman")
+	if _, err := Expand(context.Background(), u); err == nil {
+		t.Fatal("expected an error for a page without videos")
+	}
+}
@@ -0,0 +1,185 @@
+package script
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream/platform"
+
+	"github.com/dop251/goja"
+)
+
+// timeout bounds a whole resolve call including its requests.
+const timeout = 60 * time.Second
+
+type resolveQuality struct {
+	Label     string `json:"label"`
+	URL       string `json:"url"`
+	AudioURL  string `json:"audioUrl"`
+	Protocol  string `json:"protocol"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Bitrate   int    `json:"bitrate"`
+	AudioOnly bool   `json:"audioOnly"`
+}
+
+// resolveResult is what resolve returns: a single url or a list of
+// qualities, with optional headers sent to the media server.
+type resolveResult struct {
+	Kind      string            `json:"kind"`
+	Title     string            `json:"title"`
+	Channel   string            `json:"channel"`
+	Thumbnail string            `json:"thumbnail"`
+	URL       string            `json:"url"`
+	Protocol  string            `json:"protocol"`
+	Headers   map[string]string `json:"headers"`
+	Qualities []resolveQuality  `json:"qualities"`
+	Offline   bool              `json:"offline"`
+}
+
+type Extractor struct {
+	script *Script
+	url    *url.URL
+}
+
+func (s *Script) newRuntime() (*goja.Runtime, error) {
+	vm := goja.New()
+	if err := newHost(s.Name).install(vm); err != nil {
+		return nil, err
+	}
+
+	timer := time.AfterFunc(timeout, func() {
+		vm.Interrupt("timeout")
+	})
+	defer timer.Stop()
+
+	if _, err := vm.RunProgram(s.program); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Name, err)
+	}
+
+	return vm, nil
+}
+
+func toProtocol(protocol, rawURL string) (platform.Protocol, error) {
+	switch strings.ToLower(protocol) {
+	case "hls":
+		return platform.ProtocolHLS, nil
+	case "mp4":
+		return platform.ProtocolMP4, nil
+	case "flv", "http":
+		return platform.ProtocolHTTP, nil
+	case "":
+		switch {
+		case strings.Contains(rawURL, ".m3u8"):
+			return platform.ProtocolHLS, nil
+		case strings.Contains(rawURL, ".mp4"):
+			return platform.ProtocolMP4, nil
+		}
+		return platform.ProtocolHTTP, nil
+	}
+
+	return "", fmt.Errorf("unsupported protocol: %s", protocol)
+}
+
+func (e *Extractor) resolve() (*resolveResult, error) {
+	vm, err := e.script.newRuntime()
+	if err != nil {
+		return nil, err
+	}
+
+	resolve, ok := goja.AssertFunction(vm.Get("resolve"))
+	if !ok {
+		return nil, fmt.Errorf("%s: resolve is not a function", e.script.Name)
+	}
+
+	timer := time.AfterFunc(timeout, func() {
+		vm.Interrupt("timeout")
+	})
+	defer timer.Stop()
+
+	v, err := resolve(goja.Undefined(), vm.ToValue(e.url.String()))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", e.script.Name, err)
+	}
+
+	if goja.IsUndefined(v) || goja.IsNull(v) {
+		return nil, fmt.Errorf("%s: resolve returned nothing", e.script.Name)
+	}
+
+	data, err := json.Marshal(v.Export())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", e.script.Name, err)
+	}
+
+	result := &resolveResult{}
+	if err = json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("%s: invalid result: %w", e.script.Name, err)
+	}
+
+	return result, nil
+}
+
+func (e *Extractor) Extract() (*platform.Media, error) {
+	result, err := e.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Offline {
+		return nil, fmt.Errorf("%w: %s", platform.ErrOffline, e.script.Name)
+	}
+
+	media := &platform.Media{
+		Kind:      platform.Kind(result.Kind),
+		Title:     result.Title,
+		Channel:   result.Channel,
+		Thumbnail: result.Thumbnail,
+		Header:    result.Headers,
+	}
+	if len(media.Kind) <= 0 {
+		media.Kind = platform.KindLive
+	}
+
+	qualities := result.Qualities
+	if len(result.URL) > 0 {
+		qualities = append(qualities, resolveQuality{URL: result.URL, Protocol: result.Protocol})
+	}
+
+	for _, q := range qualities {
+		protocol, err := toProtocol(q.Protocol, q.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		parsedURL, err := url.Parse(q.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		quality := platform.Quality{
+			Label:     q.Label,
+			Width:     q.Width,
+			Height:    q.Height,
+			Bitrate:   q.Bitrate,
+			AudioOnly: q.AudioOnly,
+			Protocol:  protocol,
+			URL:       parsedURL,
+		}
+		if len(q.AudioURL) > 0 {
+			if quality.AudioURL, err = url.Parse(q.AudioURL); err != nil {
+				return nil, err
+			}
+		}
+		media.AddQuality(quality)
+	}
+
+	if len(media.Qualities) <= 0 {
+		return nil, errors.New("not found stream url")
+	}
+
+	return media, nil
+}
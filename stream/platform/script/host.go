@@ -0,0 +1,178 @@
+package script
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jaesung9507/playgo/secure"
+
+	"github.com/dop251/goja"
+)
+
+const maxBodySize = 16 * 1024 * 1024
+
+type fetchOptions struct {
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+type fetchResponse struct {
+	Status  int               `json:"status"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+var scriptTagRegexp = regexp.MustCompile(`(?is)<script[^>]*>(.*?)</script>`)
+
+// cgnat is the shared address space of carrier-grade NAT, which is not
+// covered by netip.Addr.IsPrivate.
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// host is the API exposed to scripts. It is limited to HTTP(S) requests and
+// text helpers; scripts have no access to files or processes.
+type host struct {
+	name   string
+	client *http.Client
+}
+
+func newHost(name string) *host {
+	dialer := &net.Dialer{
+		Timeout: 15 * time.Second,
+		Control: publicOnly,
+	}
+
+	return &host{
+		name: name,
+		client: &http.Client{
+			Timeout: 15 * time.Second,
+			Transport: &http.Transport{
+				DialContext:     dialer.DialContext,
+				TLSClientConfig: (&secure.TLS{}).Config(),
+			},
+		},
+	}
+}
+
+// publicOnly keeps scripts from reaching the local machine and the LAN, as a
+// downloaded script could otherwise probe routers or local services. It runs
+// on the resolved address of every connection, redirects included.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() || ip.IsMulticast() || cgnat.Contains(ip) {
+		return fmt.Errorf("blocked address: %s", ip)
+	}
+
+	return nil
+}
+
+func (h *host) fetch(rawURL string, opts *fetchOptions) (*fetchResponse, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme: %s", parsedURL.Scheme)
+	}
+
+	if opts == nil {
+		opts = &fetchOptions{}
+	}
+
+	method := http.MethodGet
+	if len(opts.Method) > 0 {
+		method = strings.ToUpper(opts.Method)
+	}
+
+	var body io.Reader
+	if len(opts.Body) > 0 {
+		body = strings.NewReader(opts.Body)
+	}
+
+	req, err := http.NewRequest(method, parsedURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	result := &fetchResponse{
+		Status:  resp.StatusCode,
+		URL:     resp.Request.URL.String(),
+		Headers: make(map[string]string),
+		Body:    string(data),
+	}
+	for k := range resp.Header {
+		result.Headers[strings.ToLower(k)] = resp.Header.Get(k)
+	}
+
+	return result, nil
+}
+
+// scripts returns the contents of the inline script tags of an HTML page.
+func (h *host) scripts(html string) []string {
+	var result []string
+	for _, m := range scriptTagRegexp.FindAllStringSubmatch(html, -1) {
+		if content := strings.TrimSpace(m[1]); len(content) > 0 {
+			result = append(result, content)
+		}
+	}
+
+	return result
+}
+
+func (h *host) log(msg string) {
+	log.Printf("[SCRIPT] %s: %s", h.name, msg)
+}
+
+func (h *host) install(vm *goja.Runtime) error {
+	vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+
+	if err := vm.Set("fetch", h.fetch); err != nil {
+		return err
+	}
+
+	if err := vm.Set("log", h.log); err != nil {
+		return err
+	}
+
+	html := vm.NewObject()
+	if err := html.Set("scripts", h.scripts); err != nil {
+		return err
+	}
+
+	return vm.Set("html", html)
+}
@@ -0,0 +1,176 @@
+package script
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream/platform"
+
+	"github.com/dop251/goja"
+)
+
+// Script is a user extractor written in JavaScript. It declares the URLs it
+// handles in a global patterns array of regular expressions and resolves them
+// with a global resolve(url) function.
+type Script struct {
+	Name     string
+	Path     string
+	Patterns []*regexp.Regexp
+	program  *goja.Program
+}
+
+// cachedScript is a loaded script, or the error loading it, for the version
+// of the file with the given modification time and size.
+type cachedScript struct {
+	modTime time.Time
+	size    int64
+	script  *Script
+	err     error
+}
+
+// cache keeps the scripts by path, so that the top level of a script only
+// runs again when its file changes.
+var cache = struct {
+	sync.Mutex
+	scripts map[string]*cachedScript
+}{scripts: make(map[string]*cachedScript)}
+
+// Dir returns the folder user scripts are loaded from.
+func Dir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "PlayGo", "extractors"), nil
+}
+
+func load(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Script{
+		Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Path: path,
+	}
+
+	if s.program, err = goja.Compile(path, string(data), false); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Name, err)
+	}
+
+	vm, err := s.newRuntime()
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns []string
+	if err = vm.ExportTo(vm.Get("patterns"), &patterns); err != nil || len(patterns) <= 0 {
+		return nil, fmt.Errorf("%s: patterns must be a non-empty array of strings", s.Name)
+	}
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid pattern: %w", s.Name, err)
+		}
+		s.Patterns = append(s.Patterns, re)
+	}
+
+	if _, ok := goja.AssertFunction(vm.Get("resolve")); !ok {
+		return nil, fmt.Errorf("%s: resolve is not a function", s.Name)
+	}
+
+	return s, nil
+}
+
+// Load returns the scripts in dir. Scripts are cached and only loaded again
+// when their file has changed.
+func Load(dir string) ([]*Script, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.js"))
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Lock()
+	defer cache.Unlock()
+
+	var scripts []*Script
+	found := make(map[string]bool)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		found[path] = true
+
+		cached, ok := cache.scripts[path]
+		if !ok || !cached.modTime.Equal(info.ModTime()) || cached.size != info.Size() {
+			cached = &cachedScript{modTime: info.ModTime(), size: info.Size()}
+			if cached.script, cached.err = load(path); cached.err != nil {
+				log.Printf("[SCRIPT] failed to load %s: %v", path, cached.err)
+			}
+			cache.scripts[path] = cached
+		}
+
+		if cached.script != nil {
+			scripts = append(scripts, cached.script)
+		}
+	}
+
+	for path := range cache.scripts {
+		if filepath.Dir(path) == filepath.Clean(dir) && !found[path] {
+			delete(cache.scripts, path)
+		}
+	}
+
+	return scripts, nil
+}
+
+func (s *Script) Match(u *url.URL) bool {
+	rawURL := u.String()
+	for _, re := range s.Patterns {
+		if re.MatchString(rawURL) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Lookup returns a client for the first user script that handles the URL, or
+// nil if there is none. Scripts take precedence over the built-in platforms so
+// that a broken extractor can be replaced without a new release.
+func Lookup(parsedURL *url.URL) *platform.Client {
+	dir, err := Dir()
+	if err != nil {
+		return nil
+	}
+
+	return lookup(dir, parsedURL)
+}
+
+func lookup(dir string, parsedURL *url.URL) *platform.Client {
+	scripts, err := Load(dir)
+	if err != nil {
+		log.Printf("[SCRIPT] failed to load scripts: %v", err)
+		return nil
+	}
+
+	for _, s := range scripts {
+		if s.Match(parsedURL) {
+			log.Printf("[SCRIPT] %s handles %s", s.Name, parsedURL.String())
+			return platform.NewClient("SCRIPT:"+s.Name, &Extractor{script: s, url: parsedURL})
+		}
+	}
+
+	return nil
+}
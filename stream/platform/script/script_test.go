@@ -0,0 +1,126 @@
+package script
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeScript(t *testing.T, dir, name, source string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name+".js")
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadCache(t *testing.T) {
+	dir := t.TempDir()
+	path := writeScript(t, dir, "a", `var patterns = ["^https://a\\.example/"]; function resolve(url) { return {url: url}; }`)
+	writeScript(t, dir, "broken", `var patterns = [];`)
+
+	first, err := Load(dir)
+	if err != nil || len(first) != 1 {
+		t.Fatalf("Load = %v, %v", first, err)
+	}
+
+	second, _ := Load(dir)
+	if len(second) != 1 || second[0] != first[0] {
+		t.Errorf("script loaded again without a change")
+	}
+
+	writeScript(t, dir, "a", `var patterns = ["^https://b\\.example/"]; function resolve(url) { return {url: url}; }`)
+	modTime := time.Now().Add(time.Minute)
+	os.Chtimes(path, modTime, modTime)
+
+	third, _ := Load(dir)
+	if len(third) != 1 || third[0] == first[0] || third[0].Patterns[0].String() != `^https://b\.example/` {
+		t.Errorf("changed script not reloaded: %v", third)
+	}
+
+	os.Remove(path)
+	if scripts, _ := Load(dir); len(scripts) != 0 {
+		t.Errorf("removed script still loaded: %v", scripts)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, "a", `var patterns = ["^https://a\\.example/"]; function resolve(url) { return {title: "a", url: url + "/a.m3u8"}; }`)
+	writeScript(t, dir, "b", `var patterns = ["^https://b\\.example/"]; function resolve(url) { return {title: "b", url: url + "/b.mp4"}; }`)
+
+	parsedURL, _ := url.Parse("https://b.example/live")
+	if c := lookup(dir, parsedURL); c == nil {
+		t.Fatal("no script for b.example")
+	}
+
+	scripts, _ := Load(dir)
+	var matched []*Script
+	for _, s := range scripts {
+		if s.Match(parsedURL) {
+			matched = append(matched, s)
+		}
+	}
+	if len(matched) != 1 || matched[0].Name != "b" {
+		t.Fatalf("matched = %v", matched)
+	}
+
+	media, err := (&Extractor{script: matched[0], url: parsedURL}).Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media.Title != "b" || media.Best().URL.String() != "https://b.example/live/b.mp4" {
+		t.Errorf("media = %+v", media)
+	}
+
+	parsedURL, _ = url.Parse("https://c.example/live")
+	if c := lookup(dir, parsedURL); c != nil {
+		t.Errorf("lookup(c.example) = %v, want nil", c)
+	}
+}
+
+func TestFetchBlocksLocalAddresses(t *testing.T) {
+	var requested bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer srv.Close()
+
+	_, err := newHost("test").fetch(srv.URL, nil)
+	if err == nil || !strings.Contains(err.Error(), "blocked address: 127.0.0.1") {
+		t.Errorf("err = %v, want blocked address", err)
+	}
+	if requested {
+		t.Error("request reached the local server")
+	}
+}
+
+func TestPublicOnly(t *testing.T) {
+	for address, allowed := range map[string]bool{
+		"93.184.216.34:443":    true,
+		"[2606:2800::1]:443":   true,
+		"127.0.0.1:80":         false,
+		"[::1]:80":             false,
+		"10.0.0.1:80":          false,
+		"172.16.5.4:80":        false,
+		"192.168.1.1:80":       false,
+		"169.254.169.254:80":   false,
+		"100.64.0.1:80":        false,
+		"0.0.0.0:80":           false,
+		"[fe80::1]:80":         false,
+		"[fd00::1]:80":         false,
+		"[::ffff:10.0.0.1]:80": false,
+	} {
+		if err := publicOnly("tcp", address, nil); (err == nil) != allowed {
+			t.Errorf("publicOnly(%s) = %v, want allowed=%t", address, err, allowed)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package soop
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream/platform"
+)
+
+var (
+	ErrPasswordProtected = errors.New("password protected broadcast")
+	ErrLoginRequired     = errors.New("adult or subscriber only content requires signed-in cookies")
+)
+
+var (
+	liveAPIURL = "https://live.sooplive.co.kr/afreeca/player_live_api.php"
+	vodAPIURL  = "https://api.m.sooplive.co.kr/station/video/a/view"
+)
+
+const (
+	channelResultOK            = 1
+	channelResultOffline       = 0
+	channelResultLoginRequired = -6
+)
+
+type Preset struct {
+	Name    string
+	Height  int
+	Bitrate int
+}
+
+type Channel struct {
+	BroadNo  string
+	Title    string
+	Nickname string
+	CDN      string
+	// RMD is the host that assigns stream servers.
+	RMD     string
+	Presets []Preset
+}
+
+type VODFile struct {
+	URL      string
+	Duration time.Duration
+}
+
+type VOD struct {
+	Title     string
+	Nickname  string
+	Thumbnail string
+	Files     []VODFile
+}
+
+func postForm(ctx context.Context, client *http.Client, rawURL string, form url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Referer", "https://play.sooplive.co.kr/")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	return nil
+}
+
+func playerLiveAPI(ctx context.Context, client *http.Client, bjID string, form url.Values, v any) error {
+	form.Set("bid", bjID)
+	form.Set("player_type", "html5")
+	form.Set("stream_type", "common")
+	form.Set("mode", "landing")
+	form.Set("from_api", "0")
+
+	return postForm(ctx, client, fmt.Sprintf("%s?bjid=%s", liveAPIURL, url.QueryEscape(bjID)), form, v)
+}
+
+func GetChannel(ctx context.Context, client *http.Client, bjID string) (*Channel, error) {
+	result := &struct {
+		Channel struct {
+			Result     int    `json:"RESULT"`
+			BNO        string `json:"BNO"`
+			Title      string `json:"TITLE"`
+			BJNick     string `json:"BJNICK"`
+			CDN        string `json:"CDN"`
+			RMD        string `json:"RMD"`
+			BPWD       string `json:"BPWD"`
+			ViewPreset []struct {
+				Name            string `json:"name"`
+				LabelResolution string `json:"label_resolution"`
+				BPS             int    `json:"bps"`
+			} `json:"VIEWPRESET"`
+		} `json:"CHANNEL"`
+	}{}
+	form := url.Values{}
+	form.Set("type", "live")
+	if err := playerLiveAPI(ctx, client, bjID, form, result); err != nil {
+		return nil, err
+	}
+
+	switch result.Channel.Result {
+	case channelResultOK:
+	case channelResultOffline:
+		return nil, fmt.Errorf("%w: %s", platform.ErrOffline, bjID)
+	case channelResultLoginRequired:
+		return nil, ErrLoginRequired
+	default:
+		return nil, fmt.Errorf("channel result=%d", result.Channel.Result)
+	}
+
+	if result.Channel.BPWD == "Y" {
+		return nil, ErrPasswordProtected
+	}
+
+	channel := &Channel{
+		BroadNo:  result.Channel.BNO,
+		Title:    result.Channel.Title,
+		Nickname: result.Channel.BJNick,
+		CDN:      result.Channel.CDN,
+		RMD:      result.Channel.RMD,
+	}
+	for _, preset := range result.Channel.ViewPreset {
+		if len(preset.Name) > 0 && preset.Name != "auto" {
+			height, _ := strconv.Atoi(preset.LabelResolution)
+			channel.Presets = append(channel.Presets, Preset{
+				Name:    preset.Name,
+				Height:  height,
+				Bitrate: preset.BPS * 1000,
+			})
+		}
+	}
+
+	return channel, nil
+}
+
+// GetAID returns the token that authorizes playback of a quality preset.
+func GetAID(ctx context.Context, client *http.Client, bjID, broadNo, preset string) (string, error) {
+	result := &struct {
+		Channel struct {
+			Result int    `json:"RESULT"`
+			AID    string `json:"AID"`
+		} `json:"CHANNEL"`
+	}{}
+	form := url.Values{}
+	form.Set("type", "aid")
+	form.Set("bno", broadNo)
+	form.Set("pwd", "")
+	form.Set("quality", preset)
+	if err := playerLiveAPI(ctx, client, bjID, form, result); err != nil {
+		return "", err
+	}
+
+	if result.Channel.Result == channelResultLoginRequired {
+		return "", ErrLoginRequired
+	}
+
+	if result.Channel.Result != channelResultOK || len(result.Channel.AID) <= 0 {
+		return "", fmt.Errorf("not found aid: result=%d", result.Channel.Result)
+	}
+
+	return result.Channel.AID, nil
+}
+
+// GetLiveHLSURL returns the playlist of a quality preset on the assigned
+// stream server.
+func GetLiveHLSURL(ctx context.Context, client *http.Client, bjID string, channel *Channel, preset string) (string, error) {
+	aid, err := GetAID(ctx, client, bjID, channel.BroadNo, preset)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("return_type", channel.CDN)
+	q.Set("broad_key", fmt.Sprintf("%s-common-%s-hls", channel.BroadNo, preset))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/broad_stream_assign.html?%s", channel.RMD, q.Encode()), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	result := &struct {
+		Result  int    `json:"result"`
+		ViewURL string `json:"view_url"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return "", fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	if len(result.ViewURL) <= 0 {
+		return "", fmt.Errorf("not found view url: result=%d", result.Result)
+	}
+
+	return fmt.Sprintf("%s?aid=%s", result.ViewURL, url.QueryEscape(aid)), nil
+}
+
+func GetVOD(ctx context.Context, client *http.Client, titleNo string) (*VOD, error) {
+	result := &struct {
+		Result int `json:"result"`
+		Data   struct {
+			FullTitle  string `json:"full_title"`
+			WriterNick string `json:"writer_nick"`
+			Thumb      string `json:"thumb"`
+			Files      []struct {
+				File     string `json:"file"`
+				Duration int64  `json:"duration"`
+			} `json:"files"`
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"data"`
+	}{}
+	form := url.Values{}
+	form.Set("nTitleNo", titleNo)
+	form.Set("nApiLevel", "10")
+	form.Set("nPlaylistIdx", "0")
+	if err := postForm(ctx, client, vodAPIURL, form, result); err != nil {
+		return nil, err
+	}
+
+	if result.Result != 1 {
+		if result.Data.Code == channelResultLoginRequired {
+			return nil, ErrLoginRequired
+		}
+		return nil, fmt.Errorf("vod result=%d, code=%d, message=%s", result.Result, result.Data.Code, result.Data.Message)
+	}
+
+	vod := &VOD{
+		Title:     result.Data.FullTitle,
+		Nickname:  result.Data.WriterNick,
+		Thumbnail: result.Data.Thumb,
+	}
+	for _, file := range result.Data.Files {
+		vod.Files = append(vod.Files, VODFile{
+			URL:      file.File,
+			Duration: time.Duration(file.Duration) * time.Millisecond,
+		})
+	}
+
+	if len(vod.Files) <= 0 {
+		return nil, fmt.Errorf("not found vod files: %s", titleNo)
+	}
+
+	return vod, nil
+}
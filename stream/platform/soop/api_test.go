@@ -0,0 +1,192 @@
+package soop
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream/platform"
+)
+
+// apiServer answers the player live API with live for type=live and with
+// aid for type=aid, the VOD API with vod, and assigns the stream server.
+func apiServer(t *testing.T, live, aid, vod string) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/player_live_api.php":
+			if r.Method != http.MethodPost || r.URL.Query().Get("bjid") != "bj" || r.FormValue("bid") != "bj" {
+				t.Errorf("unexpected live request: %s %s %v", r.Method, r.URL, r.PostForm)
+			}
+			switch r.FormValue("type") {
+			case "live":
+				w.Write([]byte(strings.ReplaceAll(live, "{rmd}", srv.URL)))
+			case "aid":
+				if r.FormValue("bno") != "1234" || r.FormValue("quality") != "hd" {
+					t.Errorf("unexpected aid request: %v", r.PostForm)
+				}
+				w.Write([]byte(aid))
+			default:
+				t.Errorf("unexpected type: %s", r.FormValue("type"))
+			}
+		case "/broad_stream_assign.html":
+			if key := r.URL.Query().Get("broad_key"); key != "1234-common-hd-hls" {
+				t.Errorf("unexpected broad key: %s", key)
+			}
+			w.Write([]byte(`{"result":1,"view_url":"https://cdn.example.com/live/auth_playlist.m3u8"}`))
+		case "/view":
+			if r.FormValue("nTitleNo") != "5678" {
+				t.Errorf("unexpected vod request: %v", r.PostForm)
+			}
+			w.Write([]byte(vod))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	oldLive, oldVOD := liveAPIURL, vodAPIURL
+	liveAPIURL, vodAPIURL = srv.URL+"/player_live_api.php", srv.URL+"/view"
+	t.Cleanup(func() { liveAPIURL, vodAPIURL = oldLive, oldVOD })
+
+	return srv
+}
+
+const liveResponse = `{"CHANNEL":{"RESULT":1,"BNO":"1234","TITLE":"live title","BJNICK":"BJ","CDN":"gs_cdn","RMD":"{rmd}","BPWD":"N",
+	"VIEWPRESET":[{"name":"auto","label_resolution":"","bps":0},{"name":"sd","label_resolution":"540","bps":1000},{"name":"hd","label_resolution":"720","bps":2000}]}}`
+
+func extract(ctx context.Context, t *testing.T, rawURL string) (*platform.Media, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return (&Extractor{url: parsedURL}).ExtractContext(ctx)
+}
+
+func TestExtractLive(t *testing.T) {
+	apiServer(t, liveResponse, `{"CHANNEL":{"RESULT":1,"AID":"a+b"}}`, "")
+
+	media, err := extract(context.Background(), t, "https://play.sooplive.co.kr/bj/1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media.Kind != platform.KindLive || media.Title != "live title" || media.Channel != "BJ" {
+		t.Errorf("media = %+v", media)
+	}
+	if len(media.Qualities) != 2 {
+		t.Fatalf("got %d qualities", len(media.Qualities))
+	}
+
+	quality := media.Select(platform.QualityPreference{})
+	if quality == nil || quality.Label != "hd" || quality.Height != 720 || quality.Bitrate != 2000000 || quality.URL != nil {
+		t.Fatalf("selected %+v", quality)
+	}
+	u, err := quality.Resolve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.String() != "https://cdn.example.com/live/auth_playlist.m3u8?aid=a%2Bb" {
+		t.Errorf("resolved %s", u)
+	}
+}
+
+func TestExtractLiveErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		live string
+		aid  string
+		want error
+	}{
+		{"offline", `{"CHANNEL":{"RESULT":0}}`, "", platform.ErrOffline},
+		{"adult", `{"CHANNEL":{"RESULT":-6}}`, "", ErrLoginRequired},
+		{"password", strings.Replace(liveResponse, `"BPWD":"N"`, `"BPWD":"Y"`, 1), "", ErrPasswordProtected},
+		{"subscriber", liveResponse, `{"CHANNEL":{"RESULT":-6}}`, ErrLoginRequired},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			apiServer(t, tt.live, tt.aid, "")
+
+			media, err := extract(context.Background(), t, "https://play.sooplive.co.kr/bj")
+			if err == nil {
+				_, err = media.Select(platform.QualityPreference{}).Resolve(context.Background())
+			}
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractCanceled(t *testing.T) {
+	apiServer(t, liveResponse, "", "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := extract(ctx, t, "https://play.sooplive.co.kr/bj"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+const vodResponse = `{"result":1,"data":{"full_title":"vod title","writer_nick":"BJ","thumb":"https://thumb",
+	"files":[{"file":"https://vod.example.com/1.m3u8","duration":60000},{"file":"https://vod.example.com/2.m3u8","duration":30000}]}}`
+
+func TestExtractVOD(t *testing.T) {
+	apiServer(t, "", "", vodResponse)
+
+	media, err := extract(context.Background(), t, "https://vod.sooplive.co.kr/player/5678?part=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media.Kind != platform.KindVOD || media.Title != "vod title (2/2)" || media.Channel != "BJ" || media.Thumbnail != "https://thumb" {
+		t.Errorf("media = %+v", media)
+	}
+	if len(media.Qualities) != 1 || media.Qualities[0].URL.String() != "https://vod.example.com/2.m3u8" {
+		t.Errorf("qualities = %+v", media.Qualities)
+	}
+
+	if _, err = extract(context.Background(), t, "https://vod.sooplive.co.kr/player/5678?part=3"); err == nil {
+		t.Error("expected an error for a missing part")
+	}
+}
+
+func TestExtractVODLoginRequired(t *testing.T) {
+	apiServer(t, "", "", `{"result":-1,"data":{"code":-6,"message":"adult"}}`)
+
+	if _, err := extract(context.Background(), t, "https://vod.sooplive.co.kr/player/5678"); !errors.Is(err, ErrLoginRequired) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	apiServer(t, "", "", vodResponse)
+
+	u, _ := url.Parse("https://vod.sooplive.co.kr/player/5678")
+	entries, err := Expand(context.Background(), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []platform.Entry{
+		{Title: "vod title (1/2)", URL: "https://vod.sooplive.co.kr/player/5678?part=1", Duration: time.Minute},
+		{Title: "vod title (2/2)", URL: "https://vod.sooplive.co.kr/player/5678?part=2", Duration: 30 * time.Second},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+
+	// A part is played as it is.
+	u, _ = url.Parse("https://vod.sooplive.co.kr/player/5678?part=1")
+	if entries, err = Expand(context.Background(), u); entries != nil || err != nil {
+		t.Errorf("got %v, %v for a part", entries, err)
+	}
+}
@@ -0,0 +1,126 @@
+package soop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jaesung9507/playgo/credential"
+	"github.com/jaesung9507/playgo/secure"
+	"github.com/jaesung9507/playgo/stream/platform"
+)
+
+type Extractor struct {
+	url *url.URL
+}
+
+func New(parsedURL *url.URL) *platform.Client {
+	parsedURL.Path = strings.TrimSuffix(parsedURL.Path, "/")
+	return platform.NewClient("SOOP", &Extractor{url: parsedURL})
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: (&secure.TLS{}).Config(),
+		},
+		Jar: credential.Lookup(credential.SOOP).Jar(),
+	}
+}
+
+func (e *Extractor) Extract() (*platform.Media, error) {
+	return e.ExtractContext(context.Background())
+}
+
+func (e *Extractor) ExtractContext(ctx context.Context) (*platform.Media, error) {
+	httpClient := newHTTPClient()
+
+	log.Printf("[SOOP] dial: %s", e.url.String())
+	switch e.url.Host {
+	case "play.sooplive.co.kr":
+		if m := regexp.MustCompile(`^/([^/]+)(/\d+)?$`).FindStringSubmatch(e.url.Path); m != nil {
+			return extractLive(ctx, httpClient, m[1])
+		}
+	case "vod.sooplive.co.kr":
+		if m := regexp.MustCompile(`^/player/(\d+)`).FindStringSubmatch(e.url.Path); m != nil {
+			return e.extractVOD(ctx, httpClient, m[1])
+		}
+	}
+
+	return nil, errors.New("not supported url")
+}
+
+func extractLive(ctx context.Context, httpClient *http.Client, bjID string) (*platform.Media, error) {
+	channel, err := GetChannel(ctx, httpClient, bjID)
+	if err != nil {
+		return nil, err
+	}
+
+	presets := channel.Presets
+	if len(presets) <= 0 {
+		presets = []Preset{{Name: "original"}}
+	}
+
+	media := &platform.Media{
+		Kind:    platform.KindLive,
+		Title:   channel.Title,
+		Channel: channel.Nickname,
+	}
+	for _, preset := range presets {
+		// Every preset needs its own AID, so only the selected one is
+		// requested.
+		media.AddQuality(platform.Quality{
+			Label:    preset.Name,
+			Height:   preset.Height,
+			Bitrate:  preset.Bitrate,
+			Protocol: platform.ProtocolHLS,
+			Resolve: func(ctx context.Context) (*url.URL, error) {
+				rawURL, err := GetLiveHLSURL(ctx, httpClient, bjID, channel, preset.Name)
+				if err != nil {
+					return nil, fmt.Errorf("preset %s: %w", preset.Name, err)
+				}
+
+				return url.Parse(rawURL)
+			},
+		})
+	}
+
+	return media, nil
+}
+
+// extractVOD plays the part selected by the part query, which Expand adds to
+// the entries of VODs split into several files. It defaults to the first part.
+func (e *Extractor) extractVOD(ctx context.Context, httpClient *http.Client, titleNo string) (*platform.Media, error) {
+	vod, err := GetVOD(ctx, httpClient, titleNo)
+	if err != nil {
+		return nil, err
+	}
+
+	part := 1
+	if rawPart := e.url.Query().Get("part"); len(rawPart) > 0 {
+		if part, err = strconv.Atoi(rawPart); err != nil || part < 1 || part > len(vod.Files) {
+			return nil, fmt.Errorf("invalid part: %s", rawPart)
+		}
+	}
+
+	media := &platform.Media{
+		Kind:      platform.KindVOD,
+		Title:     vod.Title,
+		Channel:   vod.Nickname,
+		Thumbnail: vod.Thumbnail,
+	}
+	if len(vod.Files) > 1 {
+		media.Title = fmt.Sprintf("%s (%d/%d)", vod.Title, part, len(vod.Files))
+	}
+	if err = media.AddURL(platform.ProtocolHLS, vod.Files[part-1].URL); err != nil {
+		return nil, err
+	}
+
+	return media, nil
+}
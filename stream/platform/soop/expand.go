@@ -0,0 +1,43 @@
+package soop
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/jaesung9507/playgo/stream/platform"
+)
+
+// Expand returns the parts of a VOD split into several files, or nil if the
+// URL does not refer to such a VOD.
+func Expand(ctx context.Context, parsedURL *url.URL) ([]platform.Entry, error) {
+	if parsedURL.Host != "vod.sooplive.co.kr" || parsedURL.Query().Has("part") {
+		return nil, nil
+	}
+
+	m := regexp.MustCompile(`^/player/(\d+)`).FindStringSubmatch(parsedURL.Path)
+	if m == nil {
+		return nil, nil
+	}
+
+	vod, err := GetVOD(ctx, newHTTPClient(), m[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vod.Files) <= 1 {
+		return nil, nil
+	}
+
+	var entries []platform.Entry
+	for i, file := range vod.Files {
+		entries = append(entries, platform.Entry{
+			Title:    fmt.Sprintf("%s (%d/%d)", vod.Title, i+1, len(vod.Files)),
+			URL:      fmt.Sprintf("https://vod.sooplive.co.kr/player/%s?part=%d", m[1], i+1),
+			Duration: file.Duration,
+		})
+	}
+
+	return entries, nil
+}
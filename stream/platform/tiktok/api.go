@@ -2,11 +2,12 @@ package tiktok
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"regexp"
+
+	"github.com/jaesung9507/playgo/stream/platform"
 )
 
 const (
@@ -14,10 +15,23 @@ const (
 	StatusOffline = 4
 )
 
-func GetLiveFLVURL(client *http.Client, uniqueID string) (string, error) {
+type LiveInfo struct {
+	Title    string
+	Nickname string
+	FLVURL   string
+}
+
+type VideoInfo struct {
+	Desc     string
+	Nickname string
+	Cover    string
+	MP4URL   string
+}
+
+func GetLiveInfo(client *http.Client, uniqueID string) (*LiveInfo, error) {
 	req, err := http.NewRequest("GET", "https://www.tiktok.com/api-live/user/room", nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	q := req.URL.Query()
@@ -29,15 +43,19 @@ func GetLiveFLVURL(client *http.Client, uniqueID string) (string, error) {
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	result := &struct {
 		StatusCode int `json:"statusCode"`
 		Data       struct {
+			User struct {
+				Nickname string `json:"nickname"`
+			} `json:"user"`
 			LiveRoom struct {
-				Status     int `json:"status"`
+				Title      string `json:"title"`
+				Status     int    `json:"status"`
 				StreamData struct {
 					PullData struct {
 						StreamData string `json:"stream_data"`
@@ -47,15 +65,20 @@ func GetLiveFLVURL(client *http.Client, uniqueID string) (string, error) {
 		} `json:"data"`
 	}{}
 	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-		return "", fmt.Errorf("failed to decode json: %w", err)
+		return nil, fmt.Errorf("failed to decode json: %w", err)
 	}
 
 	if result.StatusCode != StatusOK {
-		return "", fmt.Errorf("api status code: %d", result.StatusCode)
+		return nil, fmt.Errorf("api status code: %d", result.StatusCode)
 	}
 
 	if result.Data.LiveRoom.Status == StatusOffline {
-		return "", errors.New("channel is offline")
+		return nil, platform.ErrOffline
+	}
+
+	info := &LiveInfo{
+		Title:    result.Data.LiveRoom.Title,
+		Nickname: result.Data.User.Nickname,
 	}
 
 	streamData := &struct {
@@ -66,41 +89,43 @@ func GetLiveFLVURL(client *http.Client, uniqueID string) (string, error) {
 		} `json:"data"`
 	}{}
 	if err := json.Unmarshal([]byte(result.Data.LiveRoom.StreamData.PullData.StreamData), streamData); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if stream, ok := streamData.Data["origin"]; ok && len(stream.Main.FLV) > 0 {
-		return stream.Main.FLV, nil
+		info.FLVURL = stream.Main.FLV
+		return info, nil
 	}
 
 	for _, stream := range streamData.Data {
 		if len(stream.Main.FLV) > 0 {
-			return stream.Main.FLV, nil
+			info.FLVURL = stream.Main.FLV
+			return info, nil
 		}
 	}
 
-	return "", fmt.Errorf("not found flv url: %+v", streamData)
+	return nil, fmt.Errorf("not found flv url: %+v", streamData)
 }
 
-func GetVideoMP4URL(client *http.Client, videoURL string) (string, error) {
+func GetVideoInfo(client *http.Client, videoURL string) (*VideoInfo, error) {
 	resp, err := client.Get(videoURL)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("http status code: %d", resp.StatusCode)
+		return nil, fmt.Errorf("http status code: %d", resp.StatusCode)
 	}
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read body: %w", err)
+		return nil, fmt.Errorf("failed to read body: %w", err)
 	}
 
 	m := regexp.MustCompile(`(?s)<script [^>]*id="__UNIVERSAL_DATA_FOR_REHYDRATION__"[^>]*>(.*?)</script>`).FindSubmatch(data)
 	if len(m) < 2 {
-		return "", fmt.Errorf("not found data: content-length=%d", len(data))
+		return nil, fmt.Errorf("not found data: content-length=%d", len(data))
 	}
 
 	result := &struct {
@@ -108,7 +133,12 @@ func GetVideoMP4URL(client *http.Client, videoURL string) (string, error) {
 			VideoDetail struct {
 				ItemInfo struct {
 					ItemStruct struct {
+						Desc   string `json:"desc"`
+						Author struct {
+							Nickname string `json:"nickname"`
+						} `json:"author"`
 						Video struct {
+							Cover        string `json:"cover"`
 							DownloadAddr string `json:"downloadAddr"`
 							PlayAddr     string `json:"playAddr"`
 						} `json:"video"`
@@ -120,21 +150,27 @@ func GetVideoMP4URL(client *http.Client, videoURL string) (string, error) {
 		} `json:"__DEFAULT_SCOPE__"`
 	}{}
 	if err = json.Unmarshal(m[1], result); err != nil {
-		return "", fmt.Errorf("failed to unmarshal data: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
 	}
 
 	detail := result.DefaultScope.VideoDetail
 	if detail.StatusCode != StatusOK {
-		return "", fmt.Errorf("api status code: %d, msg: %s", detail.StatusCode, detail.StatusMsg)
+		return nil, fmt.Errorf("api status code: %d, msg: %s", detail.StatusCode, detail.StatusMsg)
 	}
 
-	mp4URL := detail.ItemInfo.ItemStruct.Video.DownloadAddr
-	if len(videoURL) <= 0 {
-		if len(detail.ItemInfo.ItemStruct.Video.PlayAddr) <= 0 {
-			return "", fmt.Errorf("not found video url: %+v", result)
+	item := detail.ItemInfo.ItemStruct
+	info := &VideoInfo{
+		Desc:     item.Desc,
+		Nickname: item.Author.Nickname,
+		Cover:    item.Video.Cover,
+		MP4URL:   item.Video.DownloadAddr,
+	}
+	if len(info.MP4URL) <= 0 {
+		if len(item.Video.PlayAddr) <= 0 {
+			return nil, fmt.Errorf("not found video url: %+v", result)
 		}
-		mp4URL = detail.ItemInfo.ItemStruct.Video.PlayAddr
+		info.MP4URL = item.Video.PlayAddr
 	}
 
-	return mp4URL, nil
+	return info, nil
 }
@@ -9,33 +9,18 @@ import (
 	"regexp"
 
 	"github.com/jaesung9507/playgo/secure"
-	"github.com/jaesung9507/playgo/stream"
-	httpStream "github.com/jaesung9507/playgo/stream/protocol/http"
+	"github.com/jaesung9507/playgo/stream/platform"
 )
 
-type Client struct {
-	url       *url.URL
-	mp4Client *httpStream.MP4Client
-	flvClient *httpStream.Client
-	tls       *secure.TLS
+type Extractor struct {
+	url *url.URL
 }
 
-func New(parsedURL *url.URL) *Client {
-	return &Client{
-		url: parsedURL,
-	}
-}
-
-type transport struct {
-	Transport http.RoundTripper
-}
-
-func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
-	req.Header.Add("Referer", "https://www.tiktok.com/")
-	return t.Transport.RoundTrip(req)
+func New(parsedURL *url.URL) *platform.Client {
+	return platform.NewClient("TikTok", &Extractor{url: parsedURL})
 }
 
-func (c *Client) Dial() error {
+func (e *Extractor) Extract() (*platform.Media, error) {
 	var tls secure.TLS
 	client := &http.Client{
 		Transport: &http.Transport{
@@ -44,102 +29,45 @@ func (c *Client) Dial() error {
 	}
 	client.Jar, _ = cookiejar.New(nil)
 
-	log.Printf("[TikTok] dial: %s", c.url.String())
-	var mp4URL, flvURL *url.URL
-	if m := regexp.MustCompile(`^/@([^/?]+)/video/(\d+)/?$`).FindStringSubmatch(c.url.Path); m != nil {
-		rawURL, err := GetVideoMP4URL(client, c.url.String())
+	log.Printf("[TikTok] dial: %s", e.url.String())
+	if m := regexp.MustCompile(`^/@([^/?]+)/video/(\d+)/?$`).FindStringSubmatch(e.url.Path); m != nil {
+		video, err := GetVideoInfo(client, e.url.String())
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		mp4URL, err = url.Parse(rawURL)
-		if err != nil {
-			return err
+		media := &platform.Media{
+			Kind:      platform.KindClip,
+			Title:     video.Desc,
+			Channel:   video.Nickname,
+			Thumbnail: video.Cover,
+			Header: map[string]string{
+				"Referer": "https://www.tiktok.com/",
+			},
+			Jar: client.Jar,
 		}
-	} else if m := regexp.MustCompile(`^/@([^/?]+)(/live)?/?$`).FindStringSubmatch(c.url.Path); m != nil {
-		rawURL, err := GetLiveFLVURL(client, m[1])
-		if err != nil {
-			return err
+		if err = media.AddURL(platform.ProtocolMP4, video.MP4URL); err != nil {
+			return nil, err
 		}
 
-		flvURL, err = url.Parse(rawURL)
+		return media, nil
+	} else if m := regexp.MustCompile(`^/@([^/?]+)(/live)?/?$`).FindStringSubmatch(e.url.Path); m != nil {
+		live, err := GetLiveInfo(client, m[1])
 		if err != nil {
-			return err
+			return nil, err
 		}
-	}
-
-	if mp4URL != nil {
-		c.mp4Client = httpStream.NewMP4Client(mp4URL)
-		client.Transport = &transport{Transport: client.Transport}
-		c.tls = &tls
-		return c.mp4Client.DialWithHTTPClient(client)
-	} else if flvURL != nil {
-		c.flvClient = httpStream.New(flvURL)
-		return c.flvClient.Dial()
-	}
-
-	return errors.New("not supported url")
-}
-
-func (c *Client) Close() {
-	log.Print("[TikTok] close")
-	if c.mp4Client != nil {
-		c.mp4Client.Close()
-	}
-
-	if c.flvClient != nil {
-		c.flvClient.Close()
-	}
-}
-
-func (c *Client) CodecData() ([]stream.Codec, error) {
-	if c.mp4Client != nil {
-		return c.mp4Client.CodecData()
-	}
-
-	if c.flvClient != nil {
-		return c.flvClient.CodecData()
-	}
-
-	return nil, errors.New("not supported")
-}
-
-func (c *Client) PacketQueue() <-chan *stream.Packet {
-	if c.mp4Client != nil {
-		return c.mp4Client.PacketQueue()
-	}
-
-	if c.flvClient != nil {
-		return c.flvClient.PacketQueue()
-	}
-
-	return nil
-}
-
-func (c *Client) CloseCh() <-chan any {
-	if c.mp4Client != nil {
-		return c.mp4Client.CloseCh()
-	}
-
-	if c.flvClient != nil {
-		return c.flvClient.CloseCh()
-	}
-
-	return nil
-}
 
-func (c *Client) Secure() (bool, bool, map[string]string) {
-	if c.tls != nil {
-		return c.tls.Info()
-	}
-
-	if c.mp4Client != nil {
-		return c.mp4Client.Secure()
-	}
+		media := &platform.Media{
+			Kind:    platform.KindLive,
+			Title:   live.Title,
+			Channel: live.Nickname,
+		}
+		if err = media.AddURL(platform.ProtocolHTTP, live.FLVURL); err != nil {
+			return nil, err
+		}
 
-	if c.flvClient != nil {
-		return c.flvClient.Secure()
+		return media, nil
 	}
 
-	return false, false, nil
+	return nil, errors.New("not supported url")
 }
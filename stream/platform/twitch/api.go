@@ -0,0 +1,305 @@
+package twitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// clientID is the public client id of the Twitch web player.
+const clientID = "kimne78kx3ncx6brgo4mv6wki5h1ko"
+
+var gqlURL = "https://gql.twitch.tv/gql"
+
+const accessTokenQuery = `query PlaybackAccessToken($login: String!, $isLive: Boolean!, $vodID: ID!, $isVod: Boolean!) {
+  streamPlaybackAccessToken(channelName: $login, params: {platform: "web", playerBackend: "mediaplayer", playerType: "site"}) @include(if: $isLive) {
+    value
+    signature
+  }
+  videoPlaybackAccessToken(id: $vodID, params: {platform: "web", playerBackend: "mediaplayer", playerType: "site"}) @include(if: $isVod) {
+    value
+    signature
+  }
+}`
+
+const channelQuery = `query Channel($login: String!) {
+  user(login: $login) {
+    displayName
+    stream {
+      id
+      title
+      previewImageURL(width: 1280, height: 720)
+    }
+  }
+}`
+
+const videoQuery = `query Video($id: ID!) {
+  video(id: $id) {
+    title
+    previewThumbnailURL(width: 1280, height: 720)
+    owner {
+      displayName
+    }
+  }
+}`
+
+const clipQuery = `query Clip($slug: ID!) {
+  clip(slug: $slug) {
+    title
+    thumbnailURL
+    broadcaster {
+      displayName
+    }
+    playbackAccessToken(params: {platform: "web", playerBackend: "mediaplayer", playerType: "site"}) {
+      value
+      signature
+    }
+    videoQualities {
+      quality
+      frameRate
+      sourceURL
+    }
+  }
+}`
+
+type AccessToken struct {
+	Value     string `json:"value"`
+	Signature string `json:"signature"`
+}
+
+type Channel struct {
+	DisplayName string
+	Live        bool
+	Title       string
+	Thumbnail   string
+}
+
+type Video struct {
+	Title     string
+	Owner     string
+	Thumbnail string
+}
+
+type ClipQuality struct {
+	Height    int
+	FrameRate float64
+	URL       string
+}
+
+type Clip struct {
+	Title       string
+	Broadcaster string
+	Thumbnail   string
+	Qualities   []ClipQuality
+}
+
+func gql(client *http.Client, query string, variables map[string]any, data any) error {
+	body, err := json.Marshal(map[string]any{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, gqlURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Client-ID", clientID)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gql status=%d", resp.StatusCode)
+	}
+
+	result := &struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("gql error: %s", result.Errors[0].Message)
+	}
+
+	if err = json.Unmarshal(result.Data, data); err != nil {
+		return fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+
+	return nil
+}
+
+func GetChannel(client *http.Client, login string) (*Channel, error) {
+	data := &struct {
+		User *struct {
+			DisplayName string `json:"displayName"`
+			Stream      *struct {
+				ID              string `json:"id"`
+				Title           string `json:"title"`
+				PreviewImageURL string `json:"previewImageURL"`
+			} `json:"stream"`
+		} `json:"user"`
+	}{}
+	if err := gql(client, channelQuery, map[string]any{"login": login}, data); err != nil {
+		return nil, err
+	}
+
+	if data.User == nil {
+		return nil, fmt.Errorf("not found channel: %s", login)
+	}
+
+	channel := &Channel{DisplayName: data.User.DisplayName}
+	if data.User.Stream != nil {
+		channel.Live = true
+		channel.Title = data.User.Stream.Title
+		channel.Thumbnail = data.User.Stream.PreviewImageURL
+	}
+
+	return channel, nil
+}
+
+func GetVideo(client *http.Client, vodID string) (*Video, error) {
+	data := &struct {
+		Video *struct {
+			Title               string `json:"title"`
+			PreviewThumbnailURL string `json:"previewThumbnailURL"`
+			Owner               struct {
+				DisplayName string `json:"displayName"`
+			} `json:"owner"`
+		} `json:"video"`
+	}{}
+	if err := gql(client, videoQuery, map[string]any{"id": vodID}, data); err != nil {
+		return nil, err
+	}
+
+	if data.Video == nil {
+		return nil, fmt.Errorf("not found video: %s", vodID)
+	}
+
+	return &Video{
+		Title:     data.Video.Title,
+		Owner:     data.Video.Owner.DisplayName,
+		Thumbnail: data.Video.PreviewThumbnailURL,
+	}, nil
+}
+
+// GetPlaybackAccessToken returns the token of a live channel, or of a VOD if
+// vodID is not empty.
+func GetPlaybackAccessToken(client *http.Client, login, vodID string) (*AccessToken, error) {
+	data := &struct {
+		Stream *AccessToken `json:"streamPlaybackAccessToken"`
+		Video  *AccessToken `json:"videoPlaybackAccessToken"`
+	}{}
+	variables := map[string]any{
+		"login":  login,
+		"isLive": len(vodID) <= 0,
+		"vodID":  vodID,
+		"isVod":  len(vodID) > 0,
+	}
+	if err := gql(client, accessTokenQuery, variables, data); err != nil {
+		return nil, err
+	}
+
+	token := data.Stream
+	if len(vodID) > 0 {
+		token = data.Video
+	}
+
+	if token == nil || len(token.Value) <= 0 {
+		return nil, errors.New("not found playback access token")
+	}
+
+	return token, nil
+}
+
+func usherURL(path string, token *AccessToken) string {
+	q := url.Values{}
+	q.Set("sig", token.Signature)
+	q.Set("token", token.Value)
+	q.Set("allow_source", "true")
+	q.Set("allow_audio_only", "true")
+	q.Set("player", "twitchweb")
+	q.Set("p", strconv.Itoa(rand.IntN(10000000)))
+
+	return fmt.Sprintf("https://usher.ttvnw.net%s?%s", path, q.Encode())
+}
+
+func GetLiveHLSURL(client *http.Client, login string) (string, error) {
+	token, err := GetPlaybackAccessToken(client, login, "")
+	if err != nil {
+		return "", err
+	}
+
+	return usherURL(fmt.Sprintf("/api/channel/hls/%s.m3u8", strings.ToLower(login)), token), nil
+}
+
+func GetVODHLSURL(client *http.Client, vodID string) (string, error) {
+	token, err := GetPlaybackAccessToken(client, "", vodID)
+	if err != nil {
+		return "", err
+	}
+
+	return usherURL(fmt.Sprintf("/vod/%s.m3u8", vodID), token), nil
+}
+
+// GetClip returns the clip with the signed MP4 URL of each quality.
+func GetClip(client *http.Client, slug string) (*Clip, error) {
+	data := &struct {
+		Clip *struct {
+			Title        string `json:"title"`
+			ThumbnailURL string `json:"thumbnailURL"`
+			Broadcaster  struct {
+				DisplayName string `json:"displayName"`
+			} `json:"broadcaster"`
+			PlaybackAccessToken AccessToken `json:"playbackAccessToken"`
+			VideoQualities      []struct {
+				Quality   string  `json:"quality"`
+				FrameRate float64 `json:"frameRate"`
+				SourceURL string  `json:"sourceURL"`
+			} `json:"videoQualities"`
+		} `json:"clip"`
+	}{}
+	if err := gql(client, clipQuery, map[string]any{"slug": slug}, data); err != nil {
+		return nil, err
+	}
+
+	if data.Clip == nil || len(data.Clip.VideoQualities) <= 0 {
+		return nil, fmt.Errorf("not found clip: %s", slug)
+	}
+
+	clip := &Clip{
+		Title:       data.Clip.Title,
+		Broadcaster: data.Clip.Broadcaster.DisplayName,
+		Thumbnail:   data.Clip.ThumbnailURL,
+	}
+	q := url.Values{}
+	q.Set("sig", data.Clip.PlaybackAccessToken.Signature)
+	q.Set("token", data.Clip.PlaybackAccessToken.Value)
+	for _, quality := range data.Clip.VideoQualities {
+		height, _ := strconv.Atoi(quality.Quality)
+		clip.Qualities = append(clip.Qualities, ClipQuality{
+			Height:    height,
+			FrameRate: quality.FrameRate,
+			URL:       quality.SourceURL + "?" + q.Encode(),
+		})
+	}
+
+	return clip, nil
+}
@@ -0,0 +1,125 @@
+package twitch
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jaesung9507/playgo/stream/platform"
+)
+
+// gqlServer answers the GraphQL queries by operation name with the given
+// data, or with status if it is not 200.
+func gqlServer(t *testing.T, status int, data map[string]string) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Client-ID") != clientID {
+			t.Errorf("Client-ID = %q", r.Header.Get("Client-ID"))
+		}
+
+		body := &struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			t.Errorf("failed to decode body: %v", err)
+		}
+
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error":"Unauthorized","status":401,"message":"The \"Client-ID\" header is invalid"}`))
+			return
+		}
+
+		name, _, _ := strings.Cut(strings.TrimPrefix(body.Query, "query "), "(")
+		response, ok := data[name]
+		if !ok {
+			t.Errorf("unexpected query: %s", name)
+		}
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+
+	old := gqlURL
+	gqlURL = srv.URL
+	t.Cleanup(func() { gqlURL = old })
+}
+
+func extract(t *testing.T, rawURL string) (*platform.Media, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return (&Extractor{url: parsedURL}).Extract()
+}
+
+func TestExtractLive(t *testing.T) {
+	gqlServer(t, http.StatusOK, map[string]string{
+		"Channel":             `{"data":{"user":{"displayName":"Streamer","stream":{"id":"1","title":"live title","previewImageURL":"https://preview"}}}}`,
+		"PlaybackAccessToken": `{"data":{"streamPlaybackAccessToken":{"value":"{\"channel\":\"streamer\"}","signature":"sig"}}}`,
+	})
+
+	media, err := extract(t, "https://www.twitch.tv/Streamer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media.Kind != platform.KindLive || media.Title != "live title" || media.Channel != "Streamer" {
+		t.Errorf("media = %+v", media)
+	}
+
+	playlist := media.Best().URL
+	if playlist.Host != "usher.ttvnw.net" || playlist.Path != "/api/channel/hls/streamer.m3u8" {
+		t.Errorf("playlist = %s", playlist)
+	}
+	if q := playlist.Query(); q.Get("sig") != "sig" || q.Get("token") != `{"channel":"streamer"}` {
+		t.Errorf("playlist query = %v", q)
+	}
+}
+
+func TestGetVODHLSURL(t *testing.T) {
+	gqlServer(t, http.StatusOK, map[string]string{
+		"PlaybackAccessToken": `{"data":{"videoPlaybackAccessToken":{"value":"vod-token","signature":"vod-sig"}}}`,
+	})
+
+	rawURL, err := GetVODHLSURL(http.DefaultClient, "123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(rawURL, "https://usher.ttvnw.net/vod/123.m3u8?") || !strings.Contains(rawURL, "sig=vod-sig") || !strings.Contains(rawURL, "token=vod-token") {
+		t.Errorf("url = %s", rawURL)
+	}
+}
+
+func TestExtractOffline(t *testing.T) {
+	gqlServer(t, http.StatusOK, map[string]string{
+		"Channel": `{"data":{"user":{"displayName":"Streamer","stream":null}}}`,
+	})
+
+	if _, err := extract(t, "https://www.twitch.tv/streamer"); !errors.Is(err, platform.ErrOffline) {
+		t.Errorf("err = %v, want ErrOffline", err)
+	}
+}
+
+func TestExtractAuthError(t *testing.T) {
+	gqlServer(t, http.StatusUnauthorized, nil)
+
+	_, err := extract(t, "https://www.twitch.tv/streamer")
+	if err == nil || !strings.Contains(err.Error(), "status=401") {
+		t.Errorf("err = %v, want status 401", err)
+	}
+}
+
+func TestGetPlaybackAccessTokenError(t *testing.T) {
+	gqlServer(t, http.StatusOK, map[string]string{
+		"PlaybackAccessToken": `{"data":{"streamPlaybackAccessToken":null},"errors":[{"message":"forbidden"}]}`,
+	})
+
+	_, err := GetLiveHLSURL(http.DefaultClient, "streamer")
+	if err == nil || !strings.Contains(err.Error(), "gql error: forbidden") {
+		t.Errorf("err = %v, want gql error", err)
+	}
+}
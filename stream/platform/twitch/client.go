@@ -0,0 +1,123 @@
+package twitch
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/jaesung9507/playgo/secure"
+	"github.com/jaesung9507/playgo/stream/platform"
+)
+
+type Extractor struct {
+	url *url.URL
+}
+
+func New(parsedURL *url.URL) *platform.Client {
+	parsedURL.Path = strings.TrimSuffix(parsedURL.Path, "/")
+	return platform.NewClient("Twitch", &Extractor{url: parsedURL})
+}
+
+func (e *Extractor) Extract() (*platform.Media, error) {
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: (&secure.TLS{}).Config(),
+		},
+	}
+
+	log.Printf("[Twitch] dial: %s", e.url.String())
+	if e.url.Host == "clips.twitch.tv" {
+		return e.extractClip(httpClient, strings.TrimPrefix(e.url.Path, "/"))
+	}
+
+	if vodID, ok := strings.CutPrefix(e.url.Path, "/videos/"); ok {
+		video, err := GetVideo(httpClient, vodID)
+		if err != nil {
+			return nil, err
+		}
+
+		rawURL, err := GetVODHLSURL(httpClient, vodID)
+		if err != nil {
+			return nil, err
+		}
+
+		media := &platform.Media{
+			Kind:      platform.KindVOD,
+			Title:     video.Title,
+			Channel:   video.Owner,
+			Thumbnail: video.Thumbnail,
+		}
+		if err = media.AddURL(platform.ProtocolHLS, rawURL); err != nil {
+			return nil, err
+		}
+
+		return media, nil
+	}
+
+	if m := regexp.MustCompile(`^/[^/]+/clip/([^/]+)$`).FindStringSubmatch(e.url.Path); m != nil {
+		return e.extractClip(httpClient, m[1])
+	}
+
+	if m := regexp.MustCompile(`^/([A-Za-z0-9_]+)$`).FindStringSubmatch(e.url.Path); m != nil {
+		channel, err := GetChannel(httpClient, m[1])
+		if err != nil {
+			return nil, err
+		}
+
+		if !channel.Live {
+			return nil, fmt.Errorf("%w: %s", platform.ErrOffline, channel.DisplayName)
+		}
+
+		rawURL, err := GetLiveHLSURL(httpClient, m[1])
+		if err != nil {
+			return nil, err
+		}
+
+		media := &platform.Media{
+			Kind:      platform.KindLive,
+			Title:     channel.Title,
+			Channel:   channel.DisplayName,
+			Thumbnail: channel.Thumbnail,
+		}
+		if err = media.AddURL(platform.ProtocolHLS, rawURL); err != nil {
+			return nil, err
+		}
+
+		return media, nil
+	}
+
+	return nil, errors.New("not supported url")
+}
+
+func (e *Extractor) extractClip(httpClient *http.Client, slug string) (*platform.Media, error) {
+	clip, err := GetClip(httpClient, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	media := &platform.Media{
+		Kind:      platform.KindClip,
+		Title:     clip.Title,
+		Channel:   clip.Broadcaster,
+		Thumbnail: clip.Thumbnail,
+	}
+	for _, quality := range clip.Qualities {
+		parsedURL, err := url.Parse(quality.URL)
+		if err != nil {
+			continue
+		}
+
+		media.AddQuality(platform.Quality{
+			Label:    fmt.Sprintf("%dp%.0f", quality.Height, quality.FrameRate),
+			Height:   quality.Height,
+			Protocol: platform.ProtocolMP4,
+			URL:      parsedURL,
+		})
+	}
+
+	return media, nil
+}
@@ -8,130 +8,140 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/jaesung9507/playgo/credential"
 	"github.com/jaesung9507/playgo/secure"
-	"github.com/jaesung9507/playgo/stream"
-	"github.com/jaesung9507/playgo/stream/protocol/hls"
-	httpStream "github.com/jaesung9507/playgo/stream/protocol/http"
+	"github.com/jaesung9507/playgo/stream/platform"
 
 	"github.com/kkdai/youtube/v2"
 )
 
-type Client struct {
-	url       *url.URL
-	hlsClient *hls.Client
-	mp4Client *httpStream.MP4Client
+type Extractor struct {
+	url *url.URL
 }
 
-func New(parsedURL *url.URL) *Client {
-	return &Client{url: parsedURL}
+func New(parsedURL *url.URL) *platform.Client {
+	return platform.NewClient("YouTube", &Extractor{url: parsedURL})
 }
 
-func (c *Client) Dial() error {
+func (e *Extractor) Extract() (*platform.Media, error) {
 	client := youtube.Client{
 		HTTPClient: &http.Client{
 			Transport: &http.Transport{
 				TLSClientConfig: (&secure.TLS{}).Config(),
 			},
+			Jar: credential.Lookup(credential.YouTube).Jar(),
 		},
 	}
 
-	log.Printf("[YouTube] dial: %s", c.url.String())
-	youtubeURL := c.url.String()
-	if videoID, ok := strings.CutPrefix(c.url.Path, "/live/"); ok {
+	log.Printf("[YouTube] dial: %s", e.url.String())
+	youtubeURL := e.url.String()
+	if videoID, ok := strings.CutPrefix(e.url.Path, "/live/"); ok {
 		youtubeURL = fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID)
 	}
 
 	video, err := client.GetVideo(youtubeURL)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	log.Printf("[YouTube] video title: %s", video.Title)
 
-	if len(video.HLSManifestURL) > 0 {
-		hlsURL, err := url.Parse(video.HLSManifestURL)
-		if err != nil {
-			return err
-		}
-		c.hlsClient = hls.New(hlsURL)
-
-		return c.hlsClient.Dial()
-	}
-
-	formats := video.Formats.WithAudioChannels().Type("video/mp4")
-	if len(formats) == 0 {
-		return errors.New("not found mp4 formats")
-	}
-	log.Printf("[Youtube] quality: %s", formats[0].QualityLabel)
-
-	streamURL, err := client.GetStreamURL(video, &formats[0])
-	if err != nil {
-		return err
-	}
-
-	mp4URL, err := url.Parse(streamURL)
-	if err != nil {
-		return err
-	}
-	c.mp4Client = httpStream.NewMP4Client(mp4URL)
-
-	return c.mp4Client.Dial()
-}
-
-func (c *Client) Close() {
-	log.Print("[YouTube] close")
-	if c.hlsClient != nil {
-		c.hlsClient.Close()
-	}
-
-	if c.mp4Client != nil {
-		c.mp4Client.Close()
-	}
-}
-
-func (c *Client) CodecData() ([]stream.Codec, error) {
-	if c.hlsClient != nil {
-		return c.hlsClient.CodecData()
+	media := &platform.Media{
+		Kind:    platform.KindVOD,
+		Title:   video.Title,
+		Channel: video.Author,
 	}
-
-	if c.mp4Client != nil {
-		return c.mp4Client.CodecData()
+	if n := len(video.Thumbnails); n > 0 {
+		media.Thumbnail = video.Thumbnails[n-1].URL
 	}
 
-	return nil, errors.New("not supported")
-}
+	if len(video.HLSManifestURL) > 0 {
+		media.Kind = platform.KindLive
+		if err = media.AddURL(platform.ProtocolHLS, video.HLSManifestURL); err != nil {
+			return nil, err
+		}
 
-func (c *Client) PacketQueue() <-chan *stream.Packet {
-	if c.hlsClient != nil {
-		return c.hlsClient.PacketQueue()
+		return media, nil
 	}
 
-	if c.mp4Client != nil {
-		return c.mp4Client.PacketQueue()
+	streamURL := func(format *youtube.Format) (*url.URL, error) {
+		rawURL, err := client.GetStreamURL(video, format)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", format.QualityLabel, err)
+		}
+		return url.Parse(rawURL)
 	}
 
-	return nil
-}
-
-func (c *Client) CloseCh() <-chan any {
-	if c.hlsClient != nil {
-		return c.hlsClient.CloseCh()
-	}
+	// Progressive formats carry both video and audio but stop at 360p or 720p.
+	formats := video.Formats.WithAudioChannels().Type("video/mp4")
+	for i := range formats {
+		mp4URL, err := streamURL(&formats[i])
+		if err != nil {
+			log.Printf("[YouTube] %v", err)
+			continue
+		}
 
-	if c.mp4Client != nil {
-		return c.mp4Client.CloseCh()
+		media.AddQuality(platform.Quality{
+			Label:    formats[i].QualityLabel,
+			Width:    formats[i].Width,
+			Height:   formats[i].Height,
+			Bitrate:  formats[i].Bitrate,
+			Protocol: platform.ProtocolMP4,
+			URL:      mp4URL,
+		})
+	}
+
+	// Adaptive formats deliver video and audio as separate fragmented MP4
+	// representations, which are merged while playing.
+	var audio *youtube.Format
+	audioFormats := video.Formats.Type("audio/mp4")
+	for i := range audioFormats {
+		format := &audioFormats[i]
+		if format.AudioTrack != nil && !format.AudioTrack.AudioIsDefault {
+			continue
+		}
+		if audio == nil || format.Bitrate > audio.Bitrate {
+			audio = format
+		}
 	}
 
-	return nil
-}
-
-func (c *Client) Secure() (bool, bool, map[string]string) {
-	if c.hlsClient != nil {
-		return c.hlsClient.Secure()
+	if audio != nil {
+		audioURL, err := streamURL(audio)
+		if err != nil {
+			log.Printf("[YouTube] %v", err)
+		} else {
+			media.AddQuality(platform.Quality{
+				Label:     "audio",
+				Bitrate:   audio.Bitrate,
+				AudioOnly: true,
+				Protocol:  platform.ProtocolMP4,
+				URL:       audioURL,
+			})
+
+			formats = video.Formats.AudioChannels(0).Type("video/mp4").Select(func(format youtube.Format) bool {
+				return strings.Contains(format.MimeType, "avc1")
+			})
+			for i := range formats {
+				videoURL, err := streamURL(&formats[i])
+				if err != nil {
+					log.Printf("[YouTube] %v", err)
+					continue
+				}
+
+				media.AddQuality(platform.Quality{
+					Label:    formats[i].QualityLabel,
+					Width:    formats[i].Width,
+					Height:   formats[i].Height,
+					Bitrate:  formats[i].Bitrate + audio.Bitrate,
+					Protocol: platform.ProtocolMP4,
+					URL:      videoURL,
+					AudioURL: audioURL,
+				})
+			}
+		}
 	}
 
-	if c.mp4Client != nil {
-		return c.mp4Client.Secure()
+	if len(media.Qualities) == 0 {
+		return nil, errors.New("not found mp4 formats")
 	}
 
-	return false, false, nil
+	return media, nil
 }
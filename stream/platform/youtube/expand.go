@@ -0,0 +1,52 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/jaesung9507/playgo/credential"
+	"github.com/jaesung9507/playgo/secure"
+	"github.com/jaesung9507/playgo/stream/platform"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// transport carries the playlist requests.
+var transport http.RoundTripper = &http.Transport{
+	TLSClientConfig: (&secure.TLS{}).Config(),
+}
+
+// Expand returns the videos of a playlist URL, or nil if the URL does not
+// refer to a playlist.
+func Expand(ctx context.Context, parsedURL *url.URL) ([]platform.Entry, error) {
+	if len(parsedURL.Query().Get("list")) <= 0 {
+		return nil, nil
+	}
+
+	client := youtube.Client{
+		HTTPClient: &http.Client{
+			Transport: transport,
+			Jar:       credential.Lookup(credential.YouTube).Jar(),
+		},
+	}
+
+	playlist, err := client.GetPlaylistContext(ctx, parsedURL.String())
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[YouTube] playlist %q: %d videos", playlist.Title, len(playlist.Videos))
+
+	var entries []platform.Entry
+	for _, video := range playlist.Videos {
+		entries = append(entries, platform.Entry{
+			Title:    video.Title,
+			URL:      fmt.Sprintf("https://www.youtube.com/watch?v=%s", video.ID),
+			Duration: video.Duration,
+		})
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,93 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream/internal/streamtest"
+)
+
+func videoItems(from, to int) string {
+	var items []string
+	for i := from; i < to; i++ {
+		items = append(items, fmt.Sprintf(`{"playlistVideoRenderer":{"videoId":"video%d","title":{"runs":[{"text":"Video %d"}]},"lengthSeconds":"%d"}}`, i, i, 60+i))
+	}
+
+	return strings.Join(items, ",")
+}
+
+const continuationItem = `{"continuationItemRenderer":{"continuationEndpoint":{"continuationCommand":{"token":"page2"}}}}`
+
+func playlistServer(t *testing.T) (*httptest.Server, *[]string) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			// The client reads its visitor ID from the home page first.
+			fmt.Fprint(w, "<script>\nytcfg.set({\"INNERTUBE_CONTEXT\":{\"client\":{\"visitorData\":\"visitor\"}}});</script>")
+			return
+		}
+		if r.URL.Path != "/youtubei/v1/browse" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+
+		switch {
+		case strings.Contains(string(body), `"page2"`):
+			requests = append(requests, "page2")
+			fmt.Fprintf(w, `{"onResponseReceivedActions":[{"appendContinuationItemsAction":{"continuationItems":[%s]}}]}`, videoItems(2, 3))
+		case strings.Contains(string(body), "VLPLtest0123456789abcdefgh"):
+			requests = append(requests, "page1")
+			fmt.Fprintf(w, `{"metadata":{"playlistHeaderRenderer":{"title":"Test list"}},`+
+				`"contents":{"twoColumnBrowseResultsRenderer":{"tabs":[{"tabRenderer":{"content":{"sectionListRenderer":{"contents":[`+
+				`{"playlistVideoListRenderer":{"contents":[%s,%s]}}]}}}}]}}}`, videoItems(0, 2), continuationItem)
+		default:
+			t.Errorf("unexpected request: %s", body)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+
+	old := transport
+	transport = streamtest.Client(srv).Transport
+	t.Cleanup(func() { transport = old })
+
+	return srv, &requests
+}
+
+func TestExpandPlaylist(t *testing.T) {
+	srv, requests := playlistServer(t)
+	defer srv.Close()
+
+	u, _ := url.Parse("https://www.youtube.com/playlist?list=PLtest0123456789abcdefgh")
+	entries, err := Expand(context.Background(), u)
+	if err != nil {
+		t.Fatalf("expand: %v", err)
+	}
+
+	if len(*requests) != 2 {
+		t.Errorf("got requests %v, want the first page and one continuation", *requests)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.URL != fmt.Sprintf("https://www.youtube.com/watch?v=video%d", i) ||
+			entry.Title != fmt.Sprintf("Video %d", i) ||
+			entry.Duration != time.Duration(60+i)*time.Second {
+			t.Errorf("entry %d: %+v", i, entry)
+		}
+	}
+}
+
+func TestExpandNotPlaylist(t *testing.T) {
+	u, _ := url.Parse("https://www.youtube.com/watch?v=video0")
+	if entries, err := Expand(context.Background(), u); entries != nil || err != nil {
+		t.Fatalf("got %v, %v", entries, err)
+	}
+}
@@ -3,7 +3,7 @@ package hls
 import (
 	"bytes"
 	"encoding/binary"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
 	"net/url"
@@ -25,8 +25,9 @@ type Client struct {
 	url         *url.URL
 	client      *gohlslib.Client
 	signal      chan any
-	packetQueue chan *stream.Packet
+	packetQueue *stream.PacketQueue
 	tls         secure.TLS
+	skipped     []stream.SkippedTrack
 
 	ready     bool
 	readyCh   chan []stream.Codec
@@ -37,7 +38,7 @@ func New(parsedUrl *url.URL) *Client {
 	return &Client{
 		url:         parsedUrl,
 		signal:      make(chan any, 1),
-		packetQueue: make(chan *stream.Packet),
+		packetQueue: stream.NewPacketQueue(stream.DefaultQueueCapacity, stream.QueueBlock),
 		readyCh:     make(chan []stream.Codec),
 	}
 }
@@ -85,9 +86,23 @@ func (c *Client) dial(header map[string]string) error {
 
 	log.Printf("[HLS] dial: %s", c.url.String())
 	c.client.OnTracks = func(tracks []*gohlslib.Track) error {
-		trackCodecs := make([]stream.Codec, len(tracks))
+		var kept []*gohlslib.Track
 		for i, track := range tracks {
 			log.Printf("[HLS] on track %d: %T", i, track.Codec)
+			switch track.Codec.(type) {
+			case *codecs.H264, *codecs.H265, *codecs.MPEG4Audio:
+				kept = append(kept, track)
+			default:
+				log.Printf("[HLS] skip track %d: %T", i, track.Codec)
+				c.skipped = append(c.skipped, stream.SkippedTrack{Index: i, Codec: stream.CodecName(track.Codec)})
+			}
+		}
+		if len(kept) <= 0 {
+			return errors.New("no supported tracks")
+		}
+
+		trackCodecs := make([]stream.Codec, len(kept))
+		for i, track := range kept {
 			switch codec := track.Codec.(type) {
 			case *codecs.H264:
 				h264Codec := &h264.Codec{SPS: codec.SPS, PPS: codec.PPS}
@@ -102,13 +117,13 @@ func (c *Client) dial(header map[string]string) error {
 					if c.ready && len(data) > 0 {
 						pts := time.Duration(pts) * time.Second / time.Duration(track.ClockRate)
 						dts := time.Duration(dts) * time.Second / time.Duration(track.ClockRate)
-						c.packetQueue <- &stream.Packet{
+						c.packetQueue.Push(&stream.Packet{
 							Idx:             int8(i),
 							IsKeyFrame:      isKeyFrame,
 							CompositionTime: pts - dts,
 							Time:            dts,
 							Data:            data,
-						}
+						})
 					}
 				})
 			case *codecs.H265:
@@ -145,13 +160,13 @@ func (c *Client) dial(header map[string]string) error {
 					if c.ready && buf.Len() > 0 {
 						pts := time.Duration(pts) * time.Second / time.Duration(track.ClockRate)
 						dts := time.Duration(dts) * time.Second / time.Duration(track.ClockRate)
-						c.packetQueue <- &stream.Packet{
+						c.packetQueue.Push(&stream.Packet{
 							Idx:             int8(i),
 							IsKeyFrame:      isKeyFrame,
 							CompositionTime: pts - dts,
 							Time:            dts,
 							Data:            slices.Clone(buf.Bytes()),
-						}
+						})
 					}
 				})
 			case *codecs.MPEG4Audio:
@@ -167,16 +182,14 @@ func (c *Client) dial(header map[string]string) error {
 					if c.ready {
 						for j, au := range aus {
 							delta := time.Duration(j) * aac.SamplesPerAccessUnit * time.Second / time.Duration(codec.Config.SampleRate)
-							c.packetQueue <- &stream.Packet{
+							c.packetQueue.Push(&stream.Packet{
 								Idx:  int8(i),
 								Time: (time.Duration(pts) * time.Second / time.Duration(track.ClockRate)) + delta,
 								Data: au,
-							}
+							})
 						}
 					}
 				})
-			default:
-				c.signal <- fmt.Errorf("unsupported codec: %T", track.Codec)
 			}
 		}
 
@@ -188,6 +201,7 @@ func (c *Client) dial(header map[string]string) error {
 
 func (c *Client) Close() {
 	log.Print("[HLS] close")
+	c.packetQueue.Close()
 	if c.client != nil {
 		c.client.Close()
 	}
@@ -195,7 +209,14 @@ func (c *Client) Close() {
 
 func (c *Client) CodecData() ([]stream.Codec, error) {
 	go func() {
-		c.signal <- c.client.Wait2()
+		err := c.client.Wait2()
+		if errors.Is(err, gohlslib.ErrClientEOS) && c.ready {
+			// The playlist has ended: let the consumer flush the last fragment.
+			log.Print("[HLS] end of playlist")
+			c.packetQueue.Finish()
+			return
+		}
+		c.signal <- err
 	}()
 
 	select {
@@ -206,7 +227,11 @@ func (c *Client) CodecData() ([]stream.Codec, error) {
 	}
 }
 
-func (c *Client) PacketQueue() <-chan *stream.Packet {
+func (c *Client) SkippedTracks() []stream.SkippedTrack {
+	return c.skipped
+}
+
+func (c *Client) PacketQueue() *stream.PacketQueue {
 	return c.packetQueue
 }
 
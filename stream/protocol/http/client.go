@@ -28,7 +28,7 @@ type Client struct {
 	closer      io.Closer
 	demuxer     stream.Demuxer
 	signal      chan any
-	packetQueue chan *stream.Packet
+	packetQueue *stream.PacketQueue
 	isLive      bool
 	tls         secure.TLS
 }
@@ -37,7 +37,7 @@ func New(parsedUrl *url.URL) *Client {
 	return &Client{
 		url:         parsedUrl,
 		signal:      make(chan any, 1),
-		packetQueue: make(chan *stream.Packet),
+		packetQueue: stream.NewPacketQueue(stream.DefaultQueueCapacity, stream.QueueBlock),
 	}
 }
 
@@ -75,7 +75,15 @@ func (c *Client) getDemuxerFunc() (func(r io.Reader) (stream.Demuxer, error), er
 	return nil, fmt.Errorf("unsupported extension: %s", ext)
 }
 
+func (c *Client) DialWithHeader(header map[string]string) error {
+	return c.dial(header)
+}
+
 func (c *Client) Dial() error {
+	return c.dial(nil)
+}
+
+func (c *Client) dial(header map[string]string) error {
 	log.Printf("[HTTP] dial: %s", c.url.String())
 	newDemuxer, err := c.getDemuxerFunc()
 	if err != nil {
@@ -87,7 +95,16 @@ func (c *Client) Dial() error {
 			TLSClientConfig: c.tls.Config(),
 		},
 	}
-	resp, err := client.Get(c.url.String())
+	req, err := http.NewRequest(http.MethodGet, c.url.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -96,6 +113,7 @@ func (c *Client) Dial() error {
 	contentLength, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
 	if contentLength <= 0 {
 		c.isLive = true
+		c.packetQueue.SetPolicy(stream.QueueDropUntilKeyFrame)
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -113,6 +131,7 @@ func (c *Client) Dial() error {
 
 func (c *Client) Close() {
 	log.Print("[HTTP] close")
+	c.packetQueue.Close()
 	if c.closer != nil {
 		c.closer.Close()
 	}
@@ -128,10 +147,14 @@ func (c *Client) CodecData() ([]stream.Codec, error) {
 					log.Printf("[HTTP] finish: %v", err)
 					if c.isLive || !errors.Is(err, io.EOF) {
 						c.signal <- err
+					} else {
+						c.packetQueue.Finish()
 					}
 					return
 				}
-				c.packetQueue <- &packet
+				if !c.packetQueue.Push(&packet) {
+					return
+				}
 			}
 		}()
 	}
@@ -139,7 +162,11 @@ func (c *Client) CodecData() ([]stream.Codec, error) {
 	return codecs, err
 }
 
-func (c *Client) PacketQueue() <-chan *stream.Packet {
+func (c *Client) SkippedTracks() []stream.SkippedTrack {
+	return stream.SkippedTracks(c.demuxer)
+}
+
+func (c *Client) PacketQueue() *stream.PacketQueue {
 	return c.packetQueue
 }
 
@@ -13,6 +13,7 @@ import (
 
 	"github.com/jaesung9507/playgo/secure"
 	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/format/fmp4"
 	"github.com/jaesung9507/playgo/stream/vdk"
 
 	"github.com/deepch/vdk/format/mp4"
@@ -23,15 +24,16 @@ type MP4Client struct {
 	closer      io.Closer
 	demuxer     stream.Demuxer
 	signal      chan any
-	packetQueue chan *stream.Packet
+	packetQueue *stream.PacketQueue
 	tls         secure.TLS
+	httpClient  *http.Client
 }
 
 func NewMP4Client(parsedUrl *url.URL) *MP4Client {
 	return &MP4Client{
 		url:         parsedUrl,
 		signal:      make(chan any, 1),
-		packetQueue: make(chan *stream.Packet),
+		packetQueue: stream.NewPacketQueue(stream.DefaultQueueCapacity, stream.QueueBlock),
 	}
 }
 
@@ -39,7 +41,16 @@ func (c *MP4Client) DialWithHTTPClient(client *http.Client) error {
 	return c.dial(client)
 }
 
+// SetHTTPClient makes Dial use client instead of a default one.
+func (c *MP4Client) SetHTTPClient(client *http.Client) {
+	c.httpClient = client
+}
+
 func (c *MP4Client) Dial() error {
+	if c.httpClient != nil {
+		return c.dial(c.httpClient)
+	}
+
 	return c.dial(&http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: c.tls.Config(),
@@ -77,17 +88,40 @@ func (c *MP4Client) dial(client *http.Client) error {
 		}
 		log.Print("[HTTP-MP4] finish download")
 
-		c.demuxer = vdk.ToDemuxer(mp4.NewDemuxer(bytes.NewReader(data)))
+		c.demuxer, err = newDemuxer(bytes.NewReader(data))
+		if err != nil {
+			c.Close()
+			return err
+		}
 	} else {
-		c.demuxer = vdk.ToDemuxer(mp4.NewDemuxer(srs))
 		c.closer = srs
+		c.demuxer, err = newDemuxer(srs)
+		if err != nil {
+			c.Close()
+			return err
+		}
 	}
 
 	return nil
 }
 
+func newDemuxer(r io.ReadSeeker) (stream.Demuxer, error) {
+	fragmented, err := fmp4.IsFragmented(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe mp4: %w", err)
+	}
+
+	if fragmented {
+		log.Print("[HTTP-MP4] fragmented mp4")
+		return fmp4.NewDemuxer(r), nil
+	}
+
+	return vdk.ToDemuxer(mp4.NewDemuxer(r)), nil
+}
+
 func (c *MP4Client) Close() {
 	log.Print("[HTTP-MP4] close")
+	c.packetQueue.Close()
 	if c.closer != nil {
 		c.closer.Close()
 	}
@@ -104,6 +138,8 @@ func (c *MP4Client) CodecData() ([]stream.Codec, error) {
 					log.Printf("[HTTP-MP4] finish: %v", err)
 					if !errors.Is(err, io.EOF) {
 						c.signal <- err
+					} else {
+						c.packetQueue.Finish()
 					}
 					return
 				}
@@ -118,7 +154,9 @@ func (c *MP4Client) CodecData() ([]stream.Codec, error) {
 					packet.CompositionTime -= baseCtsOffset
 				}
 
-				c.packetQueue <- &packet
+				if !c.packetQueue.Push(&packet) {
+					return
+				}
 			}
 		}()
 	}
@@ -126,7 +164,7 @@ func (c *MP4Client) CodecData() ([]stream.Codec, error) {
 	return codecs, err
 }
 
-func (c *MP4Client) PacketQueue() <-chan *stream.Packet {
+func (c *MP4Client) PacketQueue() *stream.PacketQueue {
 	return c.packetQueue
 }
 
@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
-	"fmt"
+	"errors"
 	"log"
 	"net"
 	"net/url"
@@ -29,15 +29,16 @@ type Client struct {
 	url         *url.URL
 	client      *gortmplib.Client
 	signal      chan any
-	packetQueue chan *stream.Packet
+	packetQueue *stream.PacketQueue
 	tls         secure.TLS
+	skipped     []stream.SkippedTrack
 }
 
 func New(parsedUrl *url.URL) *Client {
 	return &Client{
 		url:         parsedUrl,
 		signal:      make(chan any, 1),
-		packetQueue: make(chan *stream.Packet),
+		packetQueue: stream.NewPacketQueue(stream.DefaultQueueCapacity, stream.QueueDropUntilKeyFrame),
 	}
 }
 
@@ -67,6 +68,7 @@ func (c *Client) Dial() error {
 
 func (c *Client) Close() {
 	log.Print("[RTMP] close")
+	c.packetQueue.Close()
 	if c.client != nil {
 		c.client.Close()
 	}
@@ -90,13 +92,13 @@ func (c *Client) onDataH26x(index int8, pts, dts time.Duration, au [][]byte) {
 	}
 
 	if buf := buf.Bytes(); len(buf) > 0 {
-		c.packetQueue <- &stream.Packet{
+		c.packetQueue.Push(&stream.Packet{
 			Idx:             index,
 			IsKeyFrame:      isKeyFrame,
 			CompositionTime: pts - dts,
 			Time:            dts,
 			Data:            buf,
-		}
+		})
 	}
 }
 
@@ -109,6 +111,7 @@ func (c *Client) CodecData() ([]stream.Codec, error) {
 	var result []stream.Codec
 	for index, track := range reader.Tracks() {
 		log.Printf("[RTMP] on track %d: %T", index, track.Codec)
+		idx := int8(len(result))
 		switch codec := track.Codec.(type) {
 		case *codecs.H264:
 			h264Codec := &h264.Codec{SPS: codec.SPS, PPS: codec.PPS}
@@ -117,19 +120,19 @@ func (c *Client) CodecData() ([]stream.Codec, error) {
 			reader.OnDataH264(track, func(pts, dts time.Duration, au [][]byte) {
 				isKeyFrame, data := h264Codec.ParseAUPayload(au)
 				if len(data) > 0 {
-					c.packetQueue <- &stream.Packet{
-						Idx:             int8(index),
+					c.packetQueue.Push(&stream.Packet{
+						Idx:             idx,
 						IsKeyFrame:      isKeyFrame,
 						CompositionTime: pts - dts,
 						Time:            dts,
 						Data:            data,
-					}
+					})
 				}
 			})
 		case *codecs.H265:
 			result = append(result, &h265.Codec{SPS: codec.SPS, PPS: codec.PPS})
 			log.Printf("[RTMP] track %d: H265 codec ready", index)
-			reader.OnDataH265(track, func(pts, dts time.Duration, au [][]byte) { c.onDataH26x(int8(index), pts, dts, au) })
+			reader.OnDataH265(track, func(pts, dts time.Duration, au [][]byte) { c.onDataH26x(idx, pts, dts, au) })
 		case *codecs.MPEG4Audio:
 			asc, err := codec.Config.Marshal()
 			if err != nil {
@@ -138,13 +141,19 @@ func (c *Client) CodecData() ([]stream.Codec, error) {
 			result = append(result, &aac.Codec{ASC: asc, Config: *codec.Config})
 			log.Printf("[RTMP] track %d: AAC codec ready", index)
 			reader.OnDataMPEG4Audio(track, func(pts time.Duration, au []byte) {
-				c.packetQueue <- &stream.Packet{Idx: int8(index), Time: pts, Data: au}
+				c.packetQueue.Push(&stream.Packet{Idx: idx, Time: pts, Data: au})
 			})
 		default:
-			return nil, fmt.Errorf("unsupported codec: %T", track)
+			log.Printf("[RTMP] skip track %d: %T", index, track.Codec)
+			discard(reader, track)
+			c.skipped = append(c.skipped, stream.SkippedTrack{Index: index, Codec: stream.CodecName(track.Codec)})
 		}
 	}
 
+	if len(result) <= 0 {
+		return nil, errors.New("no supported tracks")
+	}
+
 	go func() {
 		for {
 			_ = c.client.NetConn().SetReadDeadline(time.Now().Add(30 * time.Second))
@@ -158,7 +167,32 @@ func (c *Client) CodecData() ([]stream.Codec, error) {
 	return result, nil
 }
 
-func (c *Client) PacketQueue() <-chan *stream.Packet {
+// discard sets a callback that drops the data of a skipped track, because the
+// reader expects one for every track.
+func discard(reader *gortmplib.Reader, track *gortmplib.Track) {
+	switch track.Codec.(type) {
+	case *codecs.AV1:
+		reader.OnDataAV1(track, func(time.Duration, [][]byte) {})
+	case *codecs.VP9:
+		reader.OnDataVP9(track, func(time.Duration, []byte) {})
+	case *codecs.Opus:
+		reader.OnDataOpus(track, func(time.Duration, []byte) {})
+	case *codecs.MPEG1Audio:
+		reader.OnDataMPEG1Audio(track, func(time.Duration, []byte) {})
+	case *codecs.AC3:
+		reader.OnDataAC3(track, func(time.Duration, []byte) {})
+	case *codecs.G711:
+		reader.OnDataG711(track, func(time.Duration, []byte) {})
+	case *codecs.LPCM:
+		reader.OnDataLPCM(track, func(time.Duration, []byte) {})
+	}
+}
+
+func (c *Client) SkippedTracks() []stream.SkippedTrack {
+	return c.skipped
+}
+
+func (c *Client) PacketQueue() *stream.PacketQueue {
 	return c.packetQueue
 }
 
@@ -1,10 +1,13 @@
 package rtsp
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/jaesung9507/playgo/secure"
@@ -14,52 +17,190 @@ import (
 
 	"github.com/bluenviron/gortsplib/v5"
 	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
 	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/headers"
+	"github.com/bluenviron/gortsplib/v5/pkg/liberrors"
 	"github.com/pion/rtp"
 )
 
 const (
 	DefaultRtspPort  = ":554"
 	DefaultRtspsPort = ":322"
+
+	// Ports of RTSP over HTTP tunneling.
+	DefaultHTTPPort  = ":80"
+	DefaultHTTPSPort = ":443"
 )
 
+// options are query parameters of the URL that configure the client, e.g.
+// rtsp://camera/stream?transport=tcp&readtimeout=5s. They are removed from
+// the URL before it is sent to the server.
+type options struct {
+	transport    string
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	keepAlive    time.Duration
+	userAgent    string
+}
+
+var optionKeys = []string{"transport", "readtimeout", "writetimeout", "keepalive", "useragent"}
+
+func parseOptions(u *url.URL) (options, error) {
+	var opts options
+	query := u.Query()
+	parseDuration := func(key string) (time.Duration, error) {
+		value := query.Get(key)
+		if len(value) <= 0 {
+			return 0, nil
+		}
+
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return 0, fmt.Errorf("invalid %s: %s", key, value)
+		}
+
+		return d, nil
+	}
+
+	var err error
+	if opts.readTimeout, err = parseDuration("readtimeout"); err != nil {
+		return opts, err
+	}
+	if opts.writeTimeout, err = parseDuration("writetimeout"); err != nil {
+		return opts, err
+	}
+	if opts.keepAlive, err = parseDuration("keepalive"); err != nil {
+		return opts, err
+	}
+	opts.userAgent = query.Get("useragent")
+
+	switch opts.transport = query.Get("transport"); opts.transport {
+	case "", "udp", "multicast", "tcp", "http":
+	default:
+		return opts, fmt.Errorf("invalid transport: %s", opts.transport)
+	}
+
+	// Only the options are removed; the rest of the query is sent as it was
+	// written, since cameras may not accept it re-encoded.
+	var kept []string
+	for item := range strings.SplitSeq(u.RawQuery, "&") {
+		key, _, _ := strings.Cut(item, "=")
+		if key, err := url.QueryUnescape(key); err == nil && slices.Contains(optionKeys, key) {
+			continue
+		}
+		kept = append(kept, item)
+	}
+	u.RawQuery = strings.Join(kept, "&")
+
+	return opts, nil
+}
+
+// defaultSessionTimeout is the session timeout of RFC 2326 for servers that
+// do not announce one, in seconds.
+const defaultSessionTimeout = 60
+
+// setKeepAlive rewrites the session timeout of res, because gortsplib sends
+// a keep-alive 5 seconds before the session expires. The timeout is never
+// raised above the one the server announced, so that a period longer than
+// it cannot let the session expire.
+func setKeepAlive(res *base.Response, period time.Duration) {
+	value, ok := res.Header["Session"]
+	if !ok {
+		return
+	}
+
+	var session headers.Session
+	if err := session.Unmarshal(value); err != nil {
+		return
+	}
+
+	serverTimeout := uint(defaultSessionTimeout)
+	if session.Timeout != nil && *session.Timeout > 0 {
+		serverTimeout = *session.Timeout
+	}
+
+	timeout := min(uint(max(period/time.Second, 1))+5, serverTimeout)
+	session.Timeout = &timeout
+	res.Header["Session"] = session.Marshal()
+}
+
 type Client struct {
 	url         *url.URL
 	client      *gortsplib.Client
 	signal      chan any
-	packetQueue chan *stream.Packet
+	packetQueue *stream.PacketQueue
 	tls         secure.TLS
+	skipped     []stream.SkippedTrack
 }
 
 func New(parsedUrl *url.URL) *Client {
 	return &Client{
 		url:         parsedUrl,
 		signal:      make(chan any, 1),
-		packetQueue: make(chan *stream.Packet, 128),
+		packetQueue: stream.NewPacketQueue(stream.DefaultQueueCapacity, stream.QueueDropUntilKeyFrame),
 	}
 }
 
 func (c *Client) Dial() error {
-	log.Printf("[RTSP] dial: %s", c.url.String())
+	log.Printf("[RTSP] dial: %s", c.url.Redacted())
 	u, err := base.ParseURL(c.url.String())
 	if err != nil {
 		return err
 	}
 	c.url = (*url.URL)(u)
 
+	opts, err := parseOptions(c.url)
+	if err != nil {
+		return err
+	}
+
 	host := c.url.Host
 	if _, _, err := net.SplitHostPort(host); err != nil {
-		if c.url.Scheme == "rtsps" {
+		switch {
+		case opts.transport == "http" && c.url.Scheme == "rtsps":
+			host += DefaultHTTPSPort
+		case opts.transport == "http":
+			host += DefaultHTTPPort
+		case c.url.Scheme == "rtsps":
 			host += DefaultRtspsPort
-		} else {
+		default:
 			host += DefaultRtspPort
 		}
 	}
 
 	c.client = &gortsplib.Client{
-		Scheme:    u.Scheme,
-		Host:      host,
-		TLSConfig: c.tls.Config(),
+		Scheme:       u.Scheme,
+		Host:         host,
+		TLSConfig:    c.tls.Config(),
+		ReadTimeout:  opts.readTimeout,
+		WriteTimeout: opts.writeTimeout,
+		UserAgent:    opts.userAgent,
+		OnTransportSwitch: func(err error) {
+			log.Printf("[RTSP] %v", err)
+		},
+	}
+
+	var protocol gortsplib.Protocol
+	switch opts.transport {
+	case "udp":
+		protocol = gortsplib.ProtocolUDP
+	case "multicast":
+		protocol = gortsplib.ProtocolUDPMulticast
+	case "tcp":
+		protocol = gortsplib.ProtocolTCP
+	case "http":
+		c.client.Tunnel = gortsplib.TunnelHTTP
+		protocol = gortsplib.ProtocolTCP
+	}
+	if len(opts.transport) > 0 {
+		c.client.Protocol = &protocol
+	}
+
+	if opts.keepAlive > 0 {
+		c.client.OnResponse = func(res *base.Response) {
+			setKeepAlive(res, opts.keepAlive)
+		}
 	}
 
 	return c.client.Start()
@@ -67,6 +208,7 @@ func (c *Client) Dial() error {
 
 func (c *Client) Close() {
 	log.Print("[RTSP] close")
+	c.packetQueue.Close()
 	if c.client != nil {
 		c.client.Close()
 	}
@@ -75,105 +217,123 @@ func (c *Client) Close() {
 func (c *Client) CodecData() ([]stream.Codec, error) {
 	desc, _, err := c.client.Describe((*base.URL)(c.url))
 	if err != nil {
+		var badStatus liberrors.ErrClientBadStatusCode
+		if errors.As(err, &badStatus) && badStatus.Code == base.StatusUnauthorized {
+			return nil, fmt.Errorf("%w: %s", stream.ErrUnauthorized, c.url.Redacted())
+		}
 		return nil, err
 	}
 
-	trackCodecs := make([]stream.Codec, len(desc.Medias))
+	var trackCodecs []stream.Codec
 	for i, media := range desc.Medias {
+		f := supportedFormat(media)
+		if f == nil {
+			names := make([]string, len(media.Formats))
+			for j, f := range media.Formats {
+				names[j] = f.Codec()
+			}
+			codec := strings.Join(names, ", ")
+			log.Printf("[RTSP] skip track %d: %s %s", i, media.Type, codec)
+			c.skipped = append(c.skipped, stream.SkippedTrack{Index: i, Codec: codec})
+			continue
+		}
+
 		if _, err = c.client.Setup(desc.BaseURL, media, 0, 0); err != nil {
 			return nil, err
 		}
 
-		for _, f := range media.Formats {
-			switch f := f.(type) {
-			case *format.H264:
-				h264Codec := &h264.Codec{SPS: f.SPS, PPS: f.PPS}
-				trackCodecs[i] = h264Codec
-				log.Printf("[RTSP] track %d: H264 codec ready", i)
+		idx := int8(len(trackCodecs))
+		switch f := f.(type) {
+		case *format.H264:
+			h264Codec := &h264.Codec{SPS: f.SPS, PPS: f.PPS}
+			trackCodecs = append(trackCodecs, h264Codec)
+			log.Printf("[RTSP] track %d: H264 codec ready", i)
 
-				dec, err := f.CreateDecoder()
-				if err != nil {
-					return nil, err
+			dec, err := f.CreateDecoder()
+			if err != nil {
+				return nil, err
+			}
+
+			dtsExtractor := &h264.DTSExtractor{}
+			dtsExtractor.Initialize()
+			dtsExtractor.Extract([][]byte{f.SPS, f.PPS}, 0)
+
+			c.client.OnPacketRTP(media, f, func(pkt *rtp.Packet) {
+				pts, ok := c.client.PacketPTS(media, pkt)
+				if !ok {
+					return
 				}
 
-				dtsExtractor := &h264.DTSExtractor{}
-				dtsExtractor.Initialize()
-				dtsExtractor.Extract([][]byte{f.SPS, f.PPS}, 0)
-
-				c.client.OnPacketRTP(media, f, func(pkt *rtp.Packet) {
-					pts, ok := c.client.PacketPTS(media, pkt)
-					if !ok {
-						return
-					}
-
-					au, err := dec.Decode(pkt)
-					if err != nil {
-						return
-					}
-
-					dts, err := dtsExtractor.Extract(au, pts)
-					if err != nil {
-						dts = pts
-					}
-
-					isKeyFrame, data := h264Codec.ParseAU(au)
-					if len(data) > 0 {
-						clockRate := time.Duration(f.ClockRate())
-						pts := time.Duration(pts) * time.Second / time.Duration(clockRate)
-						dts := time.Duration(dts) * time.Second / time.Duration(clockRate)
-
-						c.packetQueue <- &stream.Packet{
-							Idx:             int8(i),
-							IsKeyFrame:      isKeyFrame,
-							CompositionTime: pts - dts,
-							Time:            pts,
-							Data:            data,
-						}
-					}
-				})
-			case *format.MPEG4Audio:
-				asc, err := f.Config.Marshal()
+				au, err := dec.Decode(pkt)
 				if err != nil {
-					return nil, err
+					return
 				}
-				trackCodecs[i] = &aac.Codec{ASC: asc, Config: *f.Config}
-				log.Printf("[RTSP] track %d: AAC codec ready", i)
 
-				dec, err := f.CreateDecoder()
+				dts, err := dtsExtractor.Extract(au, pts)
 				if err != nil {
-					return nil, err
+					dts = pts
 				}
 
-				c.client.OnPacketRTP(media, f, func(pkt *rtp.Packet) {
-					pts, ok := c.client.PacketPTS(media, pkt)
-					if !ok {
-						return
-					}
-
-					aus, err := dec.Decode(pkt)
-					if err != nil {
-						return
-					}
-
-					clockRate := f.ClockRate()
-					for j, au := range aus {
-						delta := time.Duration(j) * aac.SamplesPerAccessUnit * time.Second / time.Duration(clockRate)
-						c.packetQueue <- &stream.Packet{
-							Idx:  int8(i),
-							Time: (time.Duration(pts) * time.Second / time.Duration(clockRate)) + delta,
-							Data: au,
-						}
-					}
-				})
-			default:
-				return nil, fmt.Errorf("unsupported codec: %T", f)
+				isKeyFrame, data := h264Codec.ParseAU(au)
+				if len(data) > 0 {
+					clockRate := time.Duration(f.ClockRate())
+					pts := time.Duration(pts) * time.Second / time.Duration(clockRate)
+					dts := time.Duration(dts) * time.Second / time.Duration(clockRate)
+
+					c.packetQueue.Push(&stream.Packet{
+						Idx:             idx,
+						IsKeyFrame:      isKeyFrame,
+						CompositionTime: pts - dts,
+						Time:            dts,
+						Data:            data,
+					})
+				}
+			})
+		case *format.MPEG4Audio:
+			asc, err := f.Config.Marshal()
+			if err != nil {
+				return nil, err
 			}
+			trackCodecs = append(trackCodecs, &aac.Codec{ASC: asc, Config: *f.Config})
+			log.Printf("[RTSP] track %d: AAC codec ready", i)
+
+			dec, err := f.CreateDecoder()
+			if err != nil {
+				return nil, err
+			}
+
+			c.client.OnPacketRTP(media, f, func(pkt *rtp.Packet) {
+				pts, ok := c.client.PacketPTS(media, pkt)
+				if !ok {
+					return
+				}
+
+				aus, err := dec.Decode(pkt)
+				if err != nil {
+					return
+				}
+
+				clockRate := f.ClockRate()
+				for j, au := range aus {
+					delta := time.Duration(j) * aac.SamplesPerAccessUnit * time.Second / time.Duration(clockRate)
+					c.packetQueue.Push(&stream.Packet{
+						Idx:  idx,
+						Time: (time.Duration(pts) * time.Second / time.Duration(clockRate)) + delta,
+						Data: au,
+					})
+				}
+			})
 		}
 	}
 
+	if len(trackCodecs) <= 0 {
+		return nil, errors.New("no supported tracks")
+	}
+
 	if _, err = c.client.Play(nil); err != nil {
 		return nil, err
 	}
+	log.Printf("[RTSP] transport: %s", c.Transport())
 
 	go func() {
 		c.signal <- c.client.Wait()
@@ -182,7 +342,45 @@ func (c *Client) CodecData() ([]stream.Codec, error) {
 	return trackCodecs, nil
 }
 
-func (c *Client) PacketQueue() <-chan *stream.Packet {
+// supportedFormat returns the first format of media that can be played.
+func supportedFormat(media *description.Media) format.Format {
+	for _, f := range media.Formats {
+		switch f.(type) {
+		case *format.H264, *format.MPEG4Audio:
+			return f
+		}
+	}
+
+	return nil
+}
+
+// Transport describes how the media is received, e.g. "UDP" or "TCP over
+// HTTP".
+func (c *Client) Transport() string {
+	if c.client == nil {
+		return ""
+	}
+
+	transport := c.client.Transport()
+	if transport.Session == nil {
+		return ""
+	}
+
+	switch transport.Conn.Tunnel {
+	case gortsplib.TunnelHTTP:
+		return transport.Session.Protocol.String() + " over HTTP"
+	case gortsplib.TunnelWebSocket:
+		return transport.Session.Protocol.String() + " over WebSocket"
+	}
+
+	return transport.Session.Protocol.String()
+}
+
+func (c *Client) SkippedTracks() []stream.SkippedTrack {
+	return c.skipped
+}
+
+func (c *Client) PacketQueue() *stream.PacketQueue {
 	return c.packetQueue
 }
 
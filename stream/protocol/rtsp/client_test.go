@@ -0,0 +1,228 @@
+package rtsp
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/codec/aac"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h264"
+	"github.com/jaesung9507/playgo/stream/internal/streamtest"
+
+	"github.com/bluenviron/gortsplib/v5"
+	"github.com/bluenviron/gortsplib/v5/pkg/base"
+	"github.com/bluenviron/gortsplib/v5/pkg/description"
+	"github.com/bluenviron/gortsplib/v5/pkg/format"
+	"github.com/bluenviron/gortsplib/v5/pkg/headers"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+)
+
+func TestSetKeepAlive(t *testing.T) {
+	for _, tc := range []struct {
+		session string
+		period  time.Duration
+		timeout uint
+	}{
+		{"12345678;timeout=60", 20 * time.Second, 25},
+		{"12345678;timeout=60", 500 * time.Millisecond, 6},
+		{"12345678;timeout=30", 50 * time.Second, 30},
+		{"12345678;timeout=30", 25 * time.Second, 30},
+		{"12345678", 20 * time.Second, 25},
+		{"12345678", 2 * time.Minute, defaultSessionTimeout},
+	} {
+		res := &base.Response{
+			StatusCode: base.StatusOK,
+			Header:     base.Header{"Session": base.HeaderValue{tc.session}},
+		}
+		setKeepAlive(res, tc.period)
+
+		var session headers.Session
+		if err := session.Unmarshal(res.Header["Session"]); err != nil {
+			t.Fatal(err)
+		}
+		if session.Session != "12345678" {
+			t.Errorf("%s: session %s", tc.session, session.Session)
+		}
+		if session.Timeout == nil || *session.Timeout != tc.timeout {
+			t.Errorf("%s keepalive=%v: timeout %v, want %d", tc.session, tc.period, session.Timeout, tc.timeout)
+		}
+	}
+
+	res := &base.Response{StatusCode: base.StatusOK, Header: base.Header{}}
+	setKeepAlive(res, 20*time.Second)
+	if _, ok := res.Header["Session"]; ok {
+		t.Error("session added to a response without one")
+	}
+}
+
+// testServer serves one stream to every path.
+type testServer struct {
+	stream *gortsplib.ServerStream
+}
+
+func (s *testServer) OnDescribe(*gortsplib.ServerHandlerOnDescribeCtx) (*base.Response, *gortsplib.ServerStream, error) {
+	return &base.Response{StatusCode: base.StatusOK}, s.stream, nil
+}
+
+func (s *testServer) OnSetup(*gortsplib.ServerHandlerOnSetupCtx) (*base.Response, *gortsplib.ServerStream, error) {
+	return &base.Response{StatusCode: base.StatusOK}, s.stream, nil
+}
+
+func (s *testServer) OnPlay(*gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+	return &base.Response{StatusCode: base.StatusOK}, nil
+}
+
+// serve starts a server of medias and returns its address.
+func serve(t *testing.T, medias ...*description.Media) (string, *gortsplib.ServerStream) {
+	t.Helper()
+
+	handler := &testServer{}
+	server := &gortsplib.Server{Handler: handler, RTSPAddress: streamtest.FreeAddress(t)}
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+
+	handler.stream = &gortsplib.ServerStream{Server: server, Desc: &description.Session{Medias: medias}}
+	if err := handler.stream.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(handler.stream.Close)
+
+	return server.RTSPAddress, handler.stream
+}
+
+func metadataMedia(t *testing.T) *description.Media {
+	t.Helper()
+
+	f := &format.Generic{PayloadTyp: 107, RTPMa: "vnd.onvif.metadata/90000"}
+	if err := f.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &description.Media{Type: description.MediaTypeApplication, Formats: []format.Format{f}}
+}
+
+func TestCodecDataSkipsMetadata(t *testing.T) {
+	video := &description.Media{Type: description.MediaTypeVideo, Formats: []format.Format{&format.H264{
+		PayloadTyp:        96,
+		PacketizationMode: 1,
+		SPS:               []byte{0x67, 0x42, 0xc0, 0x1e, 0xd9, 0x00, 0xa0, 0x3d, 0xa1, 0x00, 0x00, 0x03, 0x00, 0x01, 0x00, 0x00, 0x03, 0x00, 0x32, 0x0f, 0x16, 0x2e, 0x48},
+		PPS:               []byte{0x68, 0xcb, 0x8c, 0xb2},
+	}}}
+	audioFormat := &format.MPEG4Audio{
+		PayloadTyp:       97,
+		Config:           &mpeg4audio.AudioSpecificConfig{Type: mpeg4audio.ObjectTypeAACLC, SampleRate: 48000, ChannelCount: 1},
+		SizeLength:       13,
+		IndexLength:      3,
+		IndexDeltaLength: 3,
+	}
+	audio := &description.Media{Type: description.MediaTypeAudio, Formats: []format.Format{audioFormat}}
+	address, serverStream := serve(t, metadataMedia(t), video, audio)
+
+	parsedURL, err := url.Parse("rtsp://" + address + "/cam?transport=tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(parsedURL)
+	if err = c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	codecs, err := c.CodecData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codecs) != 2 {
+		t.Fatalf("codecs = %v", codecs)
+	}
+	if _, ok := codecs[0].(*h264.Codec); !ok {
+		t.Errorf("track 0 is %T", codecs[0])
+	}
+	if _, ok := codecs[1].(*aac.Codec); !ok {
+		t.Errorf("track 1 is %T", codecs[1])
+	}
+	if skipped := c.SkippedTracks(); !reflect.DeepEqual(skipped, []stream.SkippedTrack{{Index: 0, Codec: "Generic"}}) {
+		t.Errorf("skipped = %v", skipped)
+	}
+
+	encoder, err := audioFormat.CreateEncoder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkts, err := encoder.Encode([][]byte{{0x21, 0x10, 0x04, 0x60, 0x8c, 0x1c}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The audio is the third media of the server and the second kept track.
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case <-ticker.C:
+			for _, pkt := range pkts {
+				if err := serverStream.WritePacketRTP(audio, pkt); err != nil {
+					t.Fatal(err)
+				}
+			}
+		case packet := <-c.PacketQueue().Chan():
+			if packet.Idx != 1 {
+				t.Fatalf("audio packet on track %d", packet.Idx)
+			}
+			return
+		case <-timeout:
+			t.Fatal("no audio packet")
+		}
+	}
+}
+
+func TestCodecDataNoSupportedTracks(t *testing.T) {
+	address, _ := serve(t, metadataMedia(t))
+
+	parsedURL, err := url.Parse("rtsp://" + address + "/cam?transport=tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(parsedURL)
+	if err = c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, err = c.CodecData(); err == nil || err.Error() != "no supported tracks" {
+		t.Fatalf("got %v", err)
+	}
+	if skipped := c.SkippedTracks(); len(skipped) != 1 {
+		t.Errorf("skipped = %v", skipped)
+	}
+}
+
+func TestParseOptionsKeepsQuery(t *testing.T) {
+	for _, tc := range []struct {
+		rawQuery string
+		want     string
+	}{
+		{"", ""},
+		{"token", "token"},
+		{"transport=tcp&token&readtimeout=5s", "token"},
+		{"channel=1&subtype=0&transport=tcp", "channel=1&subtype=0"},
+		{"sig=a%2Fb+c&useragent=VLC%2F3&keepalive=20s", "sig=a%2Fb+c"},
+		{"transport=tcp", ""},
+	} {
+		u, err := url.Parse("rtsp://camera/stream?" + tc.rawQuery)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = parseOptions(u); err != nil {
+			t.Fatalf("%s: %v", tc.rawQuery, err)
+		}
+		if u.RawQuery != tc.want {
+			t.Errorf("%s: query %q, want %q", tc.rawQuery, u.RawQuery, tc.want)
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package srt
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
-	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jaesung9507/playgo/stream"
 	"github.com/jaesung9507/playgo/stream/format/ts"
@@ -13,19 +16,41 @@ import (
 	srt "github.com/datarhei/gosrt"
 )
 
+const (
+	ModeCaller   = "caller"
+	ModeListener = "listener"
+)
+
+// Stats is a snapshot of the SRT connection statistics.
+type Stats struct {
+	RTT           time.Duration
+	RecvRate      float64 // Mbps
+	Received      uint64
+	Lost          uint64
+	Retransmitted uint64
+	Dropped       uint64
+}
+
+// Client receives MPEG-TS over SRT. By default it calls the host of the URL;
+// with mode=listener it waits on the host:port of the URL for a caller, e.g.
+// srt://:9000?mode=listener&passphrase=secret, and accepts only the stream ID
+// of the URL if one is given. Rendezvous mode is not supported by gosrt.
 type Client struct {
 	url         *url.URL
+	mode        string
+	listener    srt.Listener
 	conn        srt.Conn
+	cipher      string
 	demuxer     *ts.Demuxer
 	signal      chan any
-	packetQueue chan *stream.Packet
+	packetQueue *stream.PacketQueue
 }
 
 func New(parsedUrl *url.URL) *Client {
 	return &Client{
 		url:         parsedUrl,
 		signal:      make(chan any, 1),
-		packetQueue: make(chan *stream.Packet, 128),
+		packetQueue: stream.NewPacketQueue(stream.DefaultQueueCapacity, stream.QueueDropUntilKeyFrame),
 	}
 }
 
@@ -42,27 +67,152 @@ func (c *Client) getConfig() (*srt.Config, error) {
 	return &cfg, nil
 }
 
+// redacted returns the URL for logging, without the passphrase.
+func (c *Client) redacted() string {
+	query := c.url.Query()
+	if !query.Has("passphrase") {
+		return c.url.Redacted()
+	}
+
+	u := *c.url
+	query.Set("passphrase", "xxxxx")
+	u.RawQuery = query.Encode()
+
+	return u.Redacted()
+}
+
 func (c *Client) Dial() error {
-	log.Printf("[SRT] dial: %s", c.url.String())
+	return c.DialContext(context.Background())
+}
+
+// DialContext is Dial, with ctx canceling the wait for a caller in listener
+// mode.
+func (c *Client) DialContext(ctx context.Context) error {
+	log.Printf("[SRT] dial: %s", c.redacted())
 	cfg, err := c.getConfig()
 	if err != nil {
 		return err
 	}
 
-	c.conn, err = srt.Dial(c.url.Scheme, c.url.Host, *cfg)
-	if err != nil {
-		return err
+	c.mode = c.url.Query().Get("mode")
+	if len(c.mode) <= 0 {
+		c.mode = ModeCaller
+	}
+
+	latency := cfg.ReceiverLatency
+	if cfg.Latency >= 0 {
+		latency = cfg.Latency
+	}
+	log.Printf("[SRT] mode=%s latency=%v encrypted=%t pbkeylen=%d", c.mode, latency, len(cfg.Passphrase) > 0, cfg.PBKeylen)
+
+	switch c.mode {
+	case ModeCaller:
+		c.conn, err = srt.Dial(c.url.Scheme, c.url.Host, *cfg)
+		if err != nil {
+			return err
+		}
+		if len(cfg.Passphrase) > 0 {
+			c.cipher = fmt.Sprintf("AES-%d", cfg.PBKeylen*8)
+		}
+	case ModeListener:
+		if err := c.listen(ctx, cfg); err != nil {
+			return err
+		}
+	case "rendezvous":
+		return errors.New("srt: rendezvous mode is not supported")
+	default:
+		return fmt.Errorf("srt: invalid mode: %s", c.mode)
 	}
 	c.demuxer = ts.NewDemuxer(c.conn)
 
 	return nil
 }
 
+// listen waits for the first caller that matches the stream ID and
+// encryption of the URL. Callers that arrive later are rejected.
+func (c *Client) listen(ctx context.Context, cfg *srt.Config) error {
+	listener, err := srt.Listen(c.url.Scheme, c.url.Host, *cfg)
+	if err != nil {
+		return err
+	}
+	c.listener = listener
+	log.Printf("[SRT] listening: %s", listener.Addr())
+
+	stop := context.AfterFunc(ctx, listener.Close)
+	defer stop()
+
+	for {
+		req, err := listener.Accept2()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		if reason, ok := c.check(req, cfg); !ok {
+			log.Printf("[SRT] reject %s: streamid=%q reason=%d", req.RemoteAddr(), req.StreamId(), reason)
+			req.Reject(reason)
+			continue
+		}
+
+		conn, err := req.Accept()
+		if err != nil {
+			log.Printf("[SRT] accept %s: %v", req.RemoteAddr(), err)
+			continue
+		}
+		log.Printf("[SRT] accepted %s: streamid=%q", conn.RemoteAddr(), conn.StreamId())
+
+		c.conn = conn
+		if req.IsEncrypted() {
+			c.cipher = "AES"
+		}
+		go c.rejectAll(listener)
+
+		return nil
+	}
+}
+
+func (c *Client) check(req srt.ConnRequest, cfg *srt.Config) (srt.RejectionReason, bool) {
+	if len(cfg.StreamId) > 0 && req.StreamId() != cfg.StreamId {
+		return srt.REJX_NOTFOUND, false
+	}
+
+	if req.IsEncrypted() {
+		if len(cfg.Passphrase) <= 0 {
+			return srt.REJ_UNSECURE, false
+		}
+		if err := req.SetPassphrase(cfg.Passphrase); err != nil {
+			return srt.REJ_BADSECRET, false
+		}
+	} else if len(cfg.Passphrase) > 0 && cfg.EnforcedEncryption {
+		return srt.REJ_UNSECURE, false
+	}
+
+	return 0, true
+}
+
+func (c *Client) rejectAll(listener srt.Listener) {
+	for {
+		req, err := listener.Accept2()
+		if err != nil {
+			return
+		}
+		log.Printf("[SRT] reject %s: already connected", req.RemoteAddr())
+		req.Reject(srt.REJX_CONFLICT)
+	}
+}
+
 func (c *Client) Close() {
 	log.Print("[SRT] close")
+	c.packetQueue.Close()
 	if c.conn != nil {
+		c.logStats()
 		c.conn.Close()
 	}
+	if c.listener != nil {
+		c.listener.Close()
+	}
 }
 
 func (c *Client) CodecData() ([]stream.Codec, error) {
@@ -75,7 +225,9 @@ func (c *Client) CodecData() ([]stream.Codec, error) {
 					c.signal <- err
 					return
 				}
-				c.packetQueue <- &packet
+				if !c.packetQueue.Push(&packet) {
+					return
+				}
 			}
 		}()
 	}
@@ -83,7 +235,11 @@ func (c *Client) CodecData() ([]stream.Codec, error) {
 	return codecs, err
 }
 
-func (c *Client) PacketQueue() <-chan *stream.Packet {
+func (c *Client) SkippedTracks() []stream.SkippedTrack {
+	return stream.SkippedTracks(c.demuxer)
+}
+
+func (c *Client) PacketQueue() *stream.PacketQueue {
 	return c.packetQueue
 }
 
@@ -91,14 +247,53 @@ func (c *Client) CloseCh() <-chan any {
 	return c.signal
 }
 
+// Stats returns the statistics of the connection, zero before Dial.
+func (c *Client) Stats() Stats {
+	if c.conn == nil {
+		return Stats{}
+	}
+
+	var s srt.Statistics
+	c.conn.Stats(&s)
+
+	return Stats{
+		RTT:           time.Duration(s.Instantaneous.MsRTT * float64(time.Millisecond)),
+		RecvRate:      s.Instantaneous.MbpsRecvRate,
+		Received:      s.Accumulated.PktRecv,
+		Lost:          s.Accumulated.PktRecvLoss,
+		Retransmitted: s.Accumulated.PktRecvRetrans,
+		Dropped:       s.Accumulated.PktRecvDrop,
+	}
+}
+
+// ConnStats returns the statistics for display.
+func (c *Client) ConnStats() map[string]string {
+	if c.conn == nil {
+		return nil
+	}
+
+	stats := c.Stats()
+	return map[string]string{
+		"RTT":           stats.RTT.Round(100 * time.Microsecond).String(),
+		"Receive Rate":  fmt.Sprintf("%.2f Mbps", stats.RecvRate),
+		"Received":      strconv.FormatUint(stats.Received, 10),
+		"Lost":          strconv.FormatUint(stats.Lost, 10),
+		"Retransmitted": strconv.FormatUint(stats.Retransmitted, 10),
+		"Dropped":       strconv.FormatUint(stats.Dropped, 10),
+	}
+}
+
+func (c *Client) logStats() {
+	stats := c.Stats()
+	log.Printf("[SRT] stats: rtt=%v recv=%d lost=%d retransmitted=%d dropped=%d",
+		stats.RTT, stats.Received, stats.Lost, stats.Retransmitted, stats.Dropped)
+}
+
 func (c *Client) Secure() (bool, bool, map[string]string) {
-	crypto := reflect.ValueOf(c.conn).Elem().FieldByName("crypto")
-	if crypto.IsValid() && !crypto.IsNil() {
-		keyLength := crypto.Elem().Elem().FieldByName("keyLength")
-		if keyLength.IsValid() && keyLength.CanInt() {
-			return true, true, map[string]string{
-				"Cipher": fmt.Sprintf("AES-%d", keyLength.Int()*8),
-			}
+	if len(c.cipher) > 0 {
+		return true, true, map[string]string{
+			"Cipher": c.cipher,
+			"Mode":   c.mode,
 		}
 	}
 
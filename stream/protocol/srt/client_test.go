@@ -0,0 +1,192 @@
+package srt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/internal/streamtest"
+	outsrt "github.com/jaesung9507/playgo/stream/output/srt"
+
+	srt "github.com/datarhei/gosrt"
+)
+
+func freeAddress(t *testing.T) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	return conn.LocalAddr().String()
+}
+
+func dialContext(t *testing.T, ctx context.Context, rawURL string) (*Client, <-chan error) {
+	t.Helper()
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(parsedURL)
+	ch := make(chan error, 1)
+	go func() {
+		ch <- c.DialContext(ctx)
+	}()
+
+	return c, ch
+}
+
+// call dials the listener at address until it is up or rejects the call.
+func call(t *testing.T, address string, cfg srt.Config) (srt.Conn, error) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, err := srt.Dial("srt", address, cfg)
+		if err == nil || time.Now().After(deadline) || strings.Contains(err.Error(), "rejected") {
+			return conn, err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestListenerRejects(t *testing.T) {
+	address := freeAddress(t)
+	c, ch := dialContext(t, context.Background(), "srt://"+address+"?mode=listener&streamid=cam1&passphrase=0123456789")
+	defer c.Close()
+
+	cfg := srt.DefaultConfig()
+	cfg.StreamId = "cam2"
+	cfg.Passphrase = "0123456789"
+	if conn, err := call(t, address, cfg); err == nil {
+		conn.Close()
+		t.Error("caller with another stream ID was accepted")
+	} else if !strings.Contains(err.Error(), "rejected") {
+		t.Errorf("caller with another stream ID: %v", err)
+	}
+
+	cfg.StreamId = "cam1"
+	cfg.Passphrase = ""
+	if conn, err := call(t, address, cfg); err == nil {
+		conn.Close()
+		t.Error("unencrypted caller was accepted")
+	} else if !strings.Contains(err.Error(), "rejected") {
+		t.Errorf("unencrypted caller: %v", err)
+	}
+
+	select {
+	case err := <-ch:
+		t.Fatalf("dial returned: %v", err)
+	default:
+	}
+}
+
+func TestListenerCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c, ch := dialContext(t, ctx, "srt://"+freeAddress(t)+"?mode=listener")
+	defer c.Close()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-ch:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("dial was not canceled")
+	}
+}
+
+func TestListener(t *testing.T) {
+	src, codecs := streamtest.Dial(t, "testsrc://bars?size=240p&fps=10&gop=5&duration=2s&realtime=0")
+
+	address := freeAddress(t)
+	c, ch := dialContext(t, context.Background(), "srt://"+address+"?mode=listener&streamid=cam1&passphrase=0123456789")
+	defer c.Close()
+
+	if ok, _, _ := c.Secure(); ok {
+		t.Error("secure before a caller connected")
+	}
+
+	target, err := url.Parse("srt://" + address + "?streamid=cam1&passphrase=0123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher := outsrt.NewPublisher(target)
+	defer publisher.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for err = publisher.WriteHeader(codecs); err != nil; err = publisher.WriteHeader(codecs) {
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	select {
+	case err = <-ch:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("caller was not accepted")
+	}
+
+	if secured, _, info := c.Secure(); !secured || info["Cipher"] != "AES" || info["Mode"] != ModeListener {
+		t.Errorf("unexpected secure info: %t %v", secured, info)
+	}
+
+	go func() {
+		for packet := range src.PacketQueue().Chan() {
+			if err := publisher.WritePacket(*packet); err != nil {
+				return
+			}
+		}
+	}()
+
+	got, err := c.CodecData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(codecs) {
+		t.Fatalf("got %d codecs, want %d", len(got), len(codecs))
+	}
+	for i := range codecs {
+		if fmt.Sprintf("%T", got[i]) != fmt.Sprintf("%T", codecs[i]) {
+			t.Errorf("codec %d: got %T, want %T", i, got[i], codecs[i])
+		}
+	}
+
+	var keyFrames int
+	timeout := time.After(10 * time.Second)
+	for keyFrames < 2 {
+		select {
+		case packet := <-c.PacketQueue().Chan():
+			if packet.Idx == 0 && packet.IsKeyFrame {
+				keyFrames++
+			}
+		case reason := <-c.CloseCh():
+			t.Fatalf("stream closed: %v", reason)
+		case <-timeout:
+			t.Fatal("timeout")
+		}
+	}
+
+	var _ stream.StatsReporter = c
+	stats := c.ConnStats()
+	if stats["Received"] == "" || stats["Received"] == "0" {
+		t.Errorf("unexpected stats: %v", stats)
+	}
+}
@@ -0,0 +1,56 @@
+package testsrc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream/codec/aac"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+)
+
+const audioSampleRate = 32000
+
+func audioCodec() (*aac.Codec, error) {
+	config := mpeg4audio.AudioSpecificConfig{
+		Type:          mpeg4audio.ObjectTypeAACLC,
+		SampleRate:    audioSampleRate,
+		ChannelConfig: 1,
+		ChannelCount:  1,
+	}
+	asc, err := config.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return &aac.Codec{ASC: asc, Config: config}, nil
+}
+
+// audioFrames returns the mono AAC-LC access units of silence and of the
+// 1 kHz tone.
+func audioFrames() ([2][]byte, error) {
+	var frames [2][]byte
+	for i, name := range []string{"silence.aac", "tone.aac"} {
+		data, err := clips.ReadFile("clips/" + name)
+		if err != nil {
+			return frames, err
+		}
+
+		var adts mpeg4audio.ADTSPackets
+		if err = adts.Unmarshal(data); err != nil {
+			return frames, fmt.Errorf("%s: %w", name, err)
+		}
+		if len(adts) != 1 || adts[0].SampleRate != audioSampleRate {
+			return frames, fmt.Errorf("%s: unexpected frames", name)
+		}
+		frames[i] = adts[0].AU
+	}
+
+	return frames, nil
+}
+
+// audioTime returns the timestamp of the nth access unit without
+// accumulating rounding errors.
+func audioTime(n int64) time.Duration {
+	return time.Duration(n * aac.SamplesPerAccessUnit * int64(time.Second) / audioSampleRate)
+}
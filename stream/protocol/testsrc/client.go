@@ -0,0 +1,317 @@
+package testsrc
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h264"
+	"github.com/jaesung9507/playgo/stream/protocol/testsrc/internal/clip"
+)
+
+type span struct {
+	at     time.Duration
+	length time.Duration
+}
+
+// Options configure the generated stream through the query of a testsrc URL,
+// e.g. testsrc://bars?size=720p&fps=30&gop=60&duration=1m&gap=10s/2s.
+type Options struct {
+	// Size is the preset of the embedded clip, from 240p to 1080p.
+	Size     string
+	FPS      int
+	GOP      int
+	Duration time.Duration
+	Audio    bool
+	// Realtime paces the packets by their timestamps. Otherwise they are
+	// generated as fast as the consumer reads them.
+	Realtime bool
+
+	// Gaps drop every packet for a while; the encoder keeps running, so the
+	// frames after a gap reference missing ones.
+	Gaps []span
+	// Jumps shift the timestamps after a point by a length.
+	Jumps []span
+	// DropKeyFrames drops the first keyframe after each point.
+	DropKeyFrames []time.Duration
+	// Disconnect ends the stream with an error at a point if positive.
+	Disconnect time.Duration
+}
+
+func parseDurations(values []string) ([]time.Duration, error) {
+	var result []time.Duration
+	for _, value := range values {
+		for item := range strings.SplitSeq(value, ",") {
+			d, err := time.ParseDuration(strings.TrimSpace(item))
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, d)
+		}
+	}
+
+	return result, nil
+}
+
+// parseSpans parses "at/length" pairs such as "10s/2s".
+func parseSpans(values []string) ([]span, error) {
+	var result []span
+	for _, value := range values {
+		for item := range strings.SplitSeq(value, ",") {
+			rawAt, rawLength, ok := strings.Cut(strings.TrimSpace(item), "/")
+			if !ok {
+				return nil, fmt.Errorf("invalid span: %s", item)
+			}
+
+			at, err := time.ParseDuration(rawAt)
+			if err != nil {
+				return nil, err
+			}
+
+			length, err := time.ParseDuration(rawLength)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, span{at: at, length: length})
+		}
+	}
+
+	return result, nil
+}
+
+func ParseOptions(q url.Values) (Options, error) {
+	opts := Options{
+		Size:     "360p",
+		FPS:      30,
+		Audio:    q.Get("audio") != "0",
+		Realtime: q.Get("realtime") != "0",
+	}
+
+	if size := q.Get("size"); len(size) > 0 {
+		if _, ok := clip.Presets[size]; !ok {
+			return opts, fmt.Errorf("invalid size: %s", size)
+		}
+		opts.Size = size
+	}
+
+	var err error
+	if fps := q.Get("fps"); len(fps) > 0 {
+		if opts.FPS, err = strconv.Atoi(fps); err != nil || opts.FPS <= 0 || opts.FPS > 240 {
+			return opts, fmt.Errorf("invalid fps: %s", fps)
+		}
+	}
+
+	opts.GOP = opts.FPS * 2
+	if gop := q.Get("gop"); len(gop) > 0 {
+		if opts.GOP, err = strconv.Atoi(gop); err != nil || opts.GOP <= 0 {
+			return opts, fmt.Errorf("invalid gop: %s", gop)
+		}
+	}
+
+	if duration := q.Get("duration"); len(duration) > 0 {
+		if opts.Duration, err = time.ParseDuration(duration); err != nil {
+			return opts, fmt.Errorf("invalid duration: %w", err)
+		}
+	}
+
+	if opts.Gaps, err = parseSpans(q["gap"]); err != nil {
+		return opts, fmt.Errorf("invalid gap: %w", err)
+	}
+
+	if opts.Jumps, err = parseSpans(q["jump"]); err != nil {
+		return opts, fmt.Errorf("invalid jump: %w", err)
+	}
+
+	if opts.DropKeyFrames, err = parseDurations(q["dropkey"]); err != nil {
+		return opts, fmt.Errorf("invalid dropkey: %w", err)
+	}
+
+	if disconnect := q.Get("disconnect"); len(disconnect) > 0 {
+		if opts.Disconnect, err = time.ParseDuration(disconnect); err != nil {
+			return opts, fmt.Errorf("invalid disconnect: %w", err)
+		}
+	}
+
+	return opts, nil
+}
+
+// Client plays a deterministic test pattern assembled from pre-encoded
+// slices with an optional 1 kHz beep, so the player can be exercised without
+// a network or camera.
+type Client struct {
+	url         *url.URL
+	opts        Options
+	encoder     *encoder
+	audio       [2][]byte
+	signal      chan any
+	packetQueue *stream.PacketQueue
+	done        chan struct{}
+	once        sync.Once
+}
+
+func New(parsedURL *url.URL) *Client {
+	return &Client{
+		url:         parsedURL,
+		signal:      make(chan any, 1),
+		packetQueue: stream.NewPacketQueue(stream.DefaultQueueCapacity, stream.QueueBlock),
+		done:        make(chan struct{}),
+	}
+}
+
+func (c *Client) Dial() error {
+	log.Printf("[TESTSRC] dial: %s", c.url.String())
+	opts, err := ParseOptions(c.url.Query())
+	if err != nil {
+		return err
+	}
+	c.opts = opts
+	if c.encoder, err = newEncoder(opts.Size); err != nil {
+		return err
+	}
+	if c.audio, err = audioFrames(); err != nil {
+		return err
+	}
+	log.Printf("[TESTSRC] %s %dfps gop=%d duration=%v audio=%t realtime=%t", opts.Size, opts.FPS, opts.GOP, opts.Duration, opts.Audio, opts.Realtime)
+
+	return nil
+}
+
+func (c *Client) Close() {
+	log.Print("[TESTSRC] close")
+	c.once.Do(func() {
+		close(c.done)
+	})
+	c.packetQueue.Close()
+}
+
+func (c *Client) CodecData() ([]stream.Codec, error) {
+	if c.encoder == nil {
+		return nil, errors.New("not dialed")
+	}
+
+	codecs := []stream.Codec{&h264.Codec{SPS: c.encoder.sps(), PPS: c.encoder.pps()}}
+	if c.opts.Audio {
+		audio, err := audioCodec()
+		if err != nil {
+			return nil, err
+		}
+		codecs = append(codecs, audio)
+	}
+
+	go c.generate()
+
+	return codecs, nil
+}
+
+func isBeep(t time.Duration) bool {
+	return t%time.Second < 100*time.Millisecond
+}
+
+// generate pushes video and audio packets in timestamp order until the
+// duration is reached, a disconnect is injected or the client is closed.
+func (c *Client) generate() {
+	var (
+		videoCount, audioCount int64
+		dropKey                = make([]bool, len(c.opts.DropKeyFrames))
+		start                  = time.Now()
+	)
+	for {
+		videoTime := time.Duration(videoCount) * time.Second / time.Duration(c.opts.FPS)
+		t := videoTime
+		isVideo := true
+		if c.opts.Audio && audioTime(audioCount) < videoTime {
+			t, isVideo = audioTime(audioCount), false
+		}
+
+		if c.opts.Duration > 0 && t >= c.opts.Duration {
+			log.Print("[TESTSRC] end of stream")
+			c.packetQueue.Finish()
+			return
+		}
+
+		if c.opts.Disconnect > 0 && t >= c.opts.Disconnect {
+			log.Printf("[TESTSRC] inject disconnect at %v", t)
+			c.signal <- fmt.Errorf("testsrc: injected disconnect at %v", t)
+			return
+		}
+
+		if c.opts.Realtime {
+			select {
+			case <-time.After(time.Until(start.Add(t))):
+			case <-c.done:
+				return
+			}
+		}
+
+		packet := &stream.Packet{Time: t}
+		if isVideo {
+			packet.IsKeyFrame = videoCount%int64(c.opts.GOP) == 0
+			data, err := c.encoder.packet(t, videoCount, c.opts.Audio && isBeep(t), packet.IsKeyFrame)
+			if err != nil {
+				c.signal <- err
+				return
+			}
+			packet.Data = data
+			videoCount++
+		} else {
+			packet.Idx = 1
+			packet.Data = c.audio[0]
+			if isBeep(t) {
+				packet.Data = c.audio[1]
+			}
+			audioCount++
+		}
+
+		if c.drop(packet, dropKey) {
+			continue
+		}
+
+		for _, jump := range c.opts.Jumps {
+			if t >= jump.at {
+				packet.Time += jump.length
+			}
+		}
+
+		if !c.packetQueue.Push(packet) {
+			return
+		}
+	}
+}
+
+func (c *Client) drop(packet *stream.Packet, dropKey []bool) bool {
+	for _, gap := range c.opts.Gaps {
+		if packet.Time >= gap.at && packet.Time < gap.at+gap.length {
+			return true
+		}
+	}
+
+	if packet.Idx == 0 && packet.IsKeyFrame {
+		for i, at := range c.opts.DropKeyFrames {
+			if !dropKey[i] && packet.Time >= at {
+				dropKey[i] = true
+				log.Printf("[TESTSRC] drop keyframe at %v", packet.Time)
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (c *Client) PacketQueue() *stream.PacketQueue {
+	return c.packetQueue
+}
+
+func (c *Client) CloseCh() <-chan any {
+	return c.signal
+}
+
+func (c *Client) Secure() (bool, bool, map[string]string) {
+	return false, false, nil
+}
@@ -0,0 +1,213 @@
+package testsrc
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/codec/aac"
+	"github.com/jaesung9507/playgo/stream/codec/h26x"
+	"github.com/jaesung9507/playgo/stream/codec/h26x/h264"
+
+	mch264 "github.com/bluenviron/mediacommon/v2/pkg/codecs/h264"
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+)
+
+var update = flag.Bool("update", false, "rewrite the files in testdata")
+
+// fixtureURL is the stream the files in testdata were generated from: two
+// GOPs of 240p video and the first beep.
+const fixtureURL = "testsrc://bars?size=240p&fps=10&gop=4&duration=800ms&realtime=0"
+
+func collect(t *testing.T, rawURL string) ([]stream.Codec, []*stream.Packet) {
+	t.Helper()
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(parsedURL)
+	if err = c.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	codecs, err := c.CodecData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var packets []*stream.Packet
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case packet, ok := <-c.PacketQueue().Chan():
+			if !ok {
+				return codecs, packets
+			}
+			packets = append(packets, packet)
+		case err := <-c.CloseCh():
+			t.Fatalf("stream closed: %v", err)
+		case <-timeout:
+			t.Fatal("timeout")
+		}
+	}
+}
+
+// encode returns the video as an Annex B stream and the audio as ADTS.
+func encode(t *testing.T, codecs []stream.Codec, packets []*stream.Packet) ([]byte, []byte) {
+	t.Helper()
+
+	var video []byte
+	var audio mpeg4audio.ADTSPackets
+	config := codecs[1].(*aac.Codec).Config
+	for _, packet := range packets {
+		switch packet.Idx {
+		case 0:
+			var au h26x.AVCC
+			if err := au.Unmarshal(packet.Data); err != nil {
+				t.Fatal(err)
+			}
+			for _, nalu := range au {
+				video = append(video, 0, 0, 0, 1)
+				video = append(video, nalu...)
+			}
+		case 1:
+			audio = append(audio, &mpeg4audio.ADTSPacket{
+				Type:         config.Type,
+				SampleRate:   config.SampleRate,
+				ChannelCount: config.ChannelCount,
+				AU:           packet.Data,
+			})
+		}
+	}
+
+	adts, err := audio.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return video, adts
+}
+
+func TestFixtures(t *testing.T) {
+	codecs, packets := collect(t, fixtureURL)
+	video, audio := encode(t, codecs, packets)
+
+	for name, data := range map[string][]byte{
+		"bars-240p.h264": video,
+		"tone.aac":       audio,
+	} {
+		path := filepath.Join("testdata", name)
+		if *update {
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, want) {
+			t.Errorf("%s differs from the generated stream; run go test -update if the change is intended", name)
+		}
+	}
+}
+
+func TestFixtureDecodes(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "bars-240p.h264"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := h26x.NewNALUReader(bytes.NewReader(data))
+	var idr, pictures, slices int
+	for {
+		nalu, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		switch mch264.NALUType(nalu[0] & 0x1f) {
+		case mch264.NALUTypeSPS:
+			var sps mch264.SPS
+			if err := sps.Unmarshal(nalu); err != nil {
+				t.Fatal(err)
+			}
+			if sps.Width() != 320 || sps.Height() != 240 {
+				t.Errorf("sps size = %dx%d", sps.Width(), sps.Height())
+			}
+		case mch264.NALUTypeIDR, mch264.NALUTypeNonIDR:
+			// first_mb_in_slice is 0 in the first slice of a picture.
+			if nalu[1]&0x80 == 0 {
+				slices++
+			} else if mch264.NALUType(nalu[0]&0x1f) == mch264.NALUTypeIDR {
+				idr++
+			} else {
+				pictures++
+			}
+		}
+	}
+	if idr != 2 || pictures != 6 || slices != 16 {
+		t.Errorf("idr=%d pictures=%d slices=%d, want 2, 6 and 16", idr, pictures, slices)
+	}
+
+	data, err = os.ReadFile(filepath.Join("testdata", "tone.aac"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var adts mpeg4audio.ADTSPackets
+	if err := adts.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	if want := int((800*time.Millisecond + audioTime(1) - 1) / audioTime(1)); len(adts) != want || adts[0].SampleRate != audioSampleRate {
+		t.Errorf("audio frames = %d at %d Hz, want %d", len(adts), adts[0].SampleRate, want)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	codecs, packets := collect(t, "testsrc://bars?size=240p&fps=10&gop=4&duration=1s&realtime=0&dropkey=0s")
+	if _, ok := codecs[0].(*h264.Codec); !ok || len(codecs) != 2 {
+		t.Fatalf("codecs = %v", codecs)
+	}
+
+	last := map[int8]time.Duration{0: -1, 1: -1}
+	for _, packet := range packets {
+		if packet.Time <= last[packet.Idx] {
+			t.Errorf("track %d: time %v after %v", packet.Idx, packet.Time, last[packet.Idx])
+		}
+		last[packet.Idx] = packet.Time
+
+		if packet.Idx == 0 && packet.IsKeyFrame && packet.Time < 400*time.Millisecond {
+			t.Errorf("keyframe at %v was not dropped", packet.Time)
+		}
+	}
+
+	if last[0] != 900*time.Millisecond {
+		t.Errorf("last video time %v, want 900ms", last[0])
+	}
+}
+
+func TestParseOptionsSize(t *testing.T) {
+	for size, ok := range map[string]bool{"": true, "240p": true, "1080p": true, "64x48": false, "4096x2304": false} {
+		opts, err := ParseOptions(url.Values{"size": {size}})
+		if (err == nil) != ok {
+			t.Errorf("size %q: err = %v", size, err)
+		} else if ok && len(size) > 0 && opts.Size != size {
+			t.Errorf("size %q: got %s", size, opts.Size)
+		}
+	}
+}
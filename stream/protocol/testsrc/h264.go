@@ -0,0 +1,167 @@
+package testsrc
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream/codec/h26x"
+	"github.com/jaesung9507/playgo/stream/protocol/testsrc/internal/clip"
+)
+
+//go:generate go run ./internal/gen
+
+//go:embed clips
+var clips embed.FS
+
+const (
+	naluTypeSlice = 1
+	naluTypeIDR   = 5
+
+	sliceTypeP = 0
+	sliceTypeI = 2
+)
+
+// encoder assembles the pictures of the pattern from the pre-encoded slices
+// of a clip: the bars and the bottom row are sent in keyframes and skipped
+// in the other frames, and the band is spliced from the glyphs of the
+// counter in every frame.
+type encoder struct {
+	clip     *clip.Clip
+	frameNum uint32
+	idrPicID uint32
+}
+
+func newEncoder(preset string) (*encoder, error) {
+	data, err := clips.ReadFile("clips/" + preset + ".gob")
+	if err != nil {
+		return nil, fmt.Errorf("unsupported size: %s", preset)
+	}
+
+	c := &clip.Clip{}
+	if err = c.Unmarshal(data); err != nil {
+		return nil, err
+	}
+
+	return &encoder{clip: c}, nil
+}
+
+func (e *encoder) sps() []byte {
+	return e.clip.SPS
+}
+
+func (e *encoder) pps() []byte {
+	return e.clip.PPS
+}
+
+// slice returns a slice NAL unit with the data of the macroblocks from
+// firstMB on.
+func (e *encoder) slice(firstMB int, sliceType uint32, keyFrame bool, data clip.Bits) []byte {
+	w := &clip.Writer{}
+	w.WriteUE(uint32(firstMB))
+	w.WriteUE(sliceType)
+	w.WriteUE(0) // pic_parameter_set_id
+	w.WriteBits(e.frameNum, 4)
+	if keyFrame {
+		w.WriteUE(e.idrPicID)
+	}
+	if sliceType == sliceTypeP {
+		w.WriteBit(0) // num_ref_idx_active_override_flag
+		w.WriteBit(0) // ref_pic_list_modification_flag_l0
+	}
+	if keyFrame {
+		w.WriteBit(0) // no_output_of_prior_pics_flag
+		w.WriteBit(0) // long_term_reference_flag
+	} else {
+		w.WriteBit(0) // adaptive_ref_pic_marking_mode_flag
+	}
+	w.WriteSE(0) // slice_qp_delta
+	w.WriteUE(1) // disable_deblocking_filter_idc
+	w.Write(data)
+	w.TrailingBits()
+
+	typ := byte(naluTypeSlice)
+	if keyFrame {
+		typ = naluTypeIDR
+	}
+
+	return clip.NALU(typ, w.Bytes())
+}
+
+// region returns the slice of the macroblock rows from mbY on: the intra
+// coded data in keyframes, all skipped otherwise.
+func (e *encoder) region(mbY, rows int, keyFrame bool, data clip.Bits) []byte {
+	firstMB := mbY * e.clip.MBWidth()
+	if keyFrame {
+		return e.slice(firstMB, sliceTypeI, true, data)
+	}
+
+	w := &clip.Writer{}
+	w.WriteUE(uint32(rows * e.clip.MBWidth())) // mb_skip_run
+	return e.slice(firstMB, sliceTypeP, false, w.Bits())
+}
+
+// counter returns the running time and frame number as far as they fit in
+// the cells of the clip.
+func (e *encoder) counter(t time.Duration, frame int64) string {
+	hours, minutes, seconds, millis := int(t.Hours()), int(t.Minutes())%60, int(t.Seconds())%60, t.Milliseconds()%1000
+
+	var s string
+	switch cells := e.clip.Cells; {
+	case cells >= 19:
+		s = fmt.Sprintf("%02d:%02d:%02d.%03d #%05d", hours, minutes, seconds, millis, frame%100000)
+	case cells >= 12:
+		s = fmt.Sprintf("%02d:%02d:%02d.%03d", hours, minutes, seconds, millis)
+	default:
+		s = fmt.Sprintf("%02d:%02d.%03d", int(t.Minutes())%100, seconds, millis)
+	}
+
+	if len(s) > e.clip.Cells {
+		return s[:e.clip.Cells]
+	}
+
+	return s + strings.Repeat(" ", e.clip.Cells-len(s))
+}
+
+// packet returns the AVCC payload of the picture showing the counter, with
+// the parameter sets in front of keyframes.
+func (e *encoder) packet(t time.Duration, frame int64, beep, keyFrame bool) ([]byte, error) {
+	c := e.clip
+	var au [][]byte
+	if keyFrame {
+		e.frameNum = 0
+		au = append(au, c.SPS, c.PPS)
+	}
+
+	au = append(au, e.region(0, c.BandRow, keyFrame, c.Top))
+
+	lead := c.Lead[0]
+	if beep {
+		lead = c.Lead[1]
+	}
+	text := e.counter(t, frame)
+	band := &clip.Writer{}
+	for row := range c.BandRows {
+		band.Write(lead[row])
+		for _, r := range text {
+			glyph := strings.IndexRune(clip.Glyphs, r)
+			if glyph < 0 {
+				return nil, fmt.Errorf("no glyph for %q", r)
+			}
+			band.Write(c.Glyph[glyph][row])
+		}
+		band.Write(c.Filler[row])
+	}
+	au = append(au, e.slice(c.BandRow*c.MBWidth(), sliceTypeI, keyFrame, band.Bits()))
+
+	bottom := c.BandRow + c.BandRows
+	au = append(au, e.region(bottom, c.MBHeight()-bottom, keyFrame, c.Bottom))
+
+	if keyFrame {
+		e.idrPicID = (e.idrPicID + 1) % 2
+	}
+	e.frameNum = (e.frameNum + 1) % 16
+
+	return h26x.AVCC(au).Marshal()
+}
@@ -0,0 +1,99 @@
+package clip
+
+// Writer writes the MSB-first bit fields of H.264 and AAC syntax.
+type Writer struct {
+	buf   []byte
+	cur   byte
+	nbits int
+}
+
+func (w *Writer) WriteBit(b uint32) {
+	w.cur = w.cur<<1 | byte(b&1)
+	w.nbits++
+	if w.nbits == 8 {
+		w.buf = append(w.buf, w.cur)
+		w.cur, w.nbits = 0, 0
+	}
+}
+
+func (w *Writer) WriteBits(v uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.WriteBit(v >> i)
+	}
+}
+
+// WriteUE writes an unsigned Exp-Golomb code.
+func (w *Writer) WriteUE(v uint32) {
+	v++
+	n := 0
+	for t := v; t > 1; t >>= 1 {
+		n++
+	}
+	w.WriteBits(0, n)
+	w.WriteBits(v, n+1)
+}
+
+// WriteSE writes a signed Exp-Golomb code.
+func (w *Writer) WriteSE(v int32) {
+	if v > 0 {
+		w.WriteUE(uint32(2*v - 1))
+	} else {
+		w.WriteUE(uint32(-2 * v))
+	}
+}
+
+// Write appends a bit string.
+func (w *Writer) Write(b Bits) {
+	for i := range b.Len {
+		w.WriteBit(uint32(b.Data[i/8] >> (7 - i%8)))
+	}
+}
+
+// AlignZero pads with zero bits up to the next byte boundary.
+func (w *Writer) AlignZero() {
+	for w.nbits != 0 {
+		w.WriteBit(0)
+	}
+}
+
+// TrailingBits writes rbsp_trailing_bits.
+func (w *Writer) TrailingBits() {
+	w.WriteBit(1)
+	w.AlignZero()
+}
+
+// Bits returns what has been written so far.
+func (w *Writer) Bits() Bits {
+	data := w.buf
+	if w.nbits > 0 {
+		data = append(data[:len(data):len(data)], w.cur<<(8-w.nbits))
+	}
+
+	return Bits{Data: data, Len: len(w.buf)*8 + w.nbits}
+}
+
+// Bytes returns the written bytes; the writer must be aligned.
+func (w *Writer) Bytes() []byte {
+	return w.buf
+}
+
+// NALU wraps an RBSP in an H.264 NAL unit of a reference picture, inserting
+// emulation prevention bytes.
+func NALU(typ byte, rbsp []byte) []byte {
+	out := []byte{0x60 | typ}
+	zeros := 0
+	for _, b := range rbsp {
+		if zeros >= 2 && b <= 3 {
+			out = append(out, 3)
+			zeros = 0
+		}
+		out = append(out, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+
+	return out
+}
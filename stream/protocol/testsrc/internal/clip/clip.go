@@ -0,0 +1,80 @@
+// Package clip holds the pre-encoded data of the testsrc pattern: the gob
+// file format shared by the generator and testsrc, and the bit writer both
+// build H.264 and AAC syntax with.
+package clip
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// Presets are the sizes clips are generated for.
+var Presets = map[string][2]int{
+	"240p":  {320, 240},
+	"360p":  {640, 360},
+	"480p":  {640, 480},
+	"720p":  {1280, 720},
+	"1080p": {1920, 1080},
+}
+
+// Glyphs are the characters of the counter.
+const Glyphs = "0123456789:.# "
+
+// Bits is a bit string that does not have to end on a byte boundary.
+type Bits struct {
+	Data []byte
+	Len  int
+}
+
+// Clip is the slice data of a test pattern picture: color bars over a band
+// with a beep marker and a counter. The band is one slice whose rows are
+// spliced together from pre-encoded pieces: every piece ends with a column
+// of background, and each is encoded against such a column on its left, so
+// that any cell can show any glyph.
+type Clip struct {
+	Width  int
+	Height int
+	SPS    []byte
+	PPS    []byte
+
+	// Top and Bottom are the slices of the macroblock rows above and below
+	// the band.
+	Top    Bits
+	Bottom Bits
+
+	// BandRow is the first macroblock row of the band of BandRows rows. Each
+	// row holds the lead of LeadWidth macroblocks, Cells cells of CellWidth
+	// macroblocks, then the filler up to the right edge.
+	BandRow   int
+	BandRows  int
+	LeadWidth int
+	CellWidth int
+	Cells     int
+
+	// Lead holds each band row of the lead with the beep marker off and on.
+	Lead [2][]Bits
+	// Glyph holds each band row of a cell showing each of Glyphs.
+	Glyph  [][]Bits
+	Filler []Bits
+}
+
+func (c *Clip) MBWidth() int {
+	return (c.Width + 15) / 16
+}
+
+func (c *Clip) MBHeight() int {
+	return (c.Height + 15) / 16
+}
+
+func (c *Clip) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(c); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *Clip) Unmarshal(data []byte) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(c)
+}
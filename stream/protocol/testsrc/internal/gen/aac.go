@@ -0,0 +1,85 @@
+package main
+
+import (
+	"github.com/jaesung9507/playgo/stream/protocol/testsrc/internal/clip"
+
+	"github.com/bluenviron/mediacommon/v2/pkg/codecs/mpeg4audio"
+)
+
+const (
+	audioSampleRate = 32000
+
+	// toneBand is the scale factor band starting at spectral line 64, which
+	// is 1 kHz at 32 kHz: 64 * 32000 / (2 * 1024).
+	toneBand = 13
+	// toneGain sets the tone to about -20 dBFS.
+	toneGain = 187
+
+	codebookZero = 0
+	codebookOne  = 1
+)
+
+// audioFrame returns a mono AAC-LC access unit of silence, or of a steady
+// 1 kHz sine. The tone is a single spectral line carried by pulse data over
+// an otherwise zero band; its period divides the frame length, so identical
+// frames overlap into a continuous tone.
+func audioFrame(tone bool) []byte {
+	w := &clip.Writer{}
+	w.WriteBits(0, 3) // id_syn_ele: SCE
+	w.WriteBits(0, 4) // element_instance_tag
+	w.WriteBits(toneGain, 8)
+
+	// ics_info
+	w.WriteBit(0)     // ics_reserved_bit
+	w.WriteBits(0, 2) // window_sequence: ONLY_LONG_SEQUENCE
+	w.WriteBit(0)     // window_shape: sine
+	if tone {
+		w.WriteBits(toneBand+1, 6) // max_sfb
+	} else {
+		w.WriteBits(0, 6)
+	}
+	w.WriteBit(0) // predictor_data_present
+
+	if tone {
+		// section_data
+		w.WriteBits(codebookZero, 4)
+		w.WriteBits(toneBand, 5)
+		w.WriteBits(codebookOne, 4)
+		w.WriteBits(1, 5)
+
+		// scale_factor_data: the band is at global_gain.
+		w.WriteBit(0)
+
+		// pulse_data: one pulse of amplitude 1 on the first line of the band.
+		w.WriteBit(1)
+		w.WriteBits(0, 2) // number_pulse - 1
+		w.WriteBits(toneBand, 6)
+		w.WriteBits(0, 5) // pulse_offset
+		w.WriteBits(1, 4) // pulse_amp
+	} else {
+		w.WriteBit(0) // pulse_data_present
+	}
+	w.WriteBit(0) // tns_data_present
+	w.WriteBit(0) // gain_control_data_present
+
+	if tone {
+		// spectral_data: the two quads of the band are zero.
+		w.WriteBit(0)
+		w.WriteBit(0)
+	}
+
+	w.WriteBits(7, 3) // id_syn_ele: END
+	w.AlignZero()
+
+	return w.Bytes()
+}
+
+// adts wraps an access unit in an ADTS frame.
+func adts(au []byte) ([]byte, error) {
+	return mpeg4audio.ADTSPackets{{
+		Type:         mpeg4audio.ObjectTypeAACLC,
+		SampleRate:   audioSampleRate,
+		ChannelCount: 1,
+		AU:           au,
+	}}.Marshal()
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jaesung9507/playgo/stream/protocol/testsrc/internal/clip"
+)
+
+const (
+	naluTypeSPS = 7
+	naluTypePPS = 8
+
+	// qp makes a DC coefficient level equal to the residual of every sample
+	// of a 4x4 block, so that flat blocks are reconstructed exactly.
+	qp = 16
+
+	intra16x16Vertical   = 0
+	intra16x16Horizontal = 1
+
+	chromaDC         = 0
+	chromaHorizontal = 1
+	chromaVertical   = 2
+)
+
+// cbpCodeNum maps the coded block patterns used to their me(v) code numbers
+// for intra macroblocks.
+var cbpCodeNum = map[int]uint32{0: 3, 15: 2, 31: 1}
+
+func levelIDC(mbs int) uint32 {
+	switch {
+	case mbs <= 396:
+		return 21
+	case mbs <= 1620:
+		return 31
+	case mbs <= 8192:
+		return 40
+	}
+
+	return 51
+}
+
+func sps(width, height int) []byte {
+	mbWidth, mbHeight := (width+15)/16, (height+15)/16
+
+	w := &clip.Writer{}
+	w.WriteBits(66, 8)                         // profile_idc: baseline
+	w.WriteBits(0xc0, 8)                       // constraint_set0 and constraint_set1
+	w.WriteBits(levelIDC(mbWidth*mbHeight), 8) // level_idc
+	w.WriteUE(0)                               // seq_parameter_set_id
+	w.WriteUE(0)                               // log2_max_frame_num_minus4
+	w.WriteUE(2)                               // pic_order_cnt_type
+	w.WriteUE(1)                               // max_num_ref_frames
+	w.WriteBit(0)                              // gaps_in_frame_num_value_allowed_flag
+	w.WriteUE(uint32(mbWidth - 1))             // pic_width_in_mbs_minus1
+	w.WriteUE(uint32(mbHeight - 1))            // pic_height_in_map_units_minus1
+	w.WriteBit(1)                              // frame_mbs_only_flag
+	w.WriteBit(1)                              // direct_8x8_inference_flag
+	cropRight, cropBottom := (mbWidth*16-width)/2, (mbHeight*16-height)/2
+	if cropRight > 0 || cropBottom > 0 {
+		w.WriteBit(1)
+		w.WriteUE(0)
+		w.WriteUE(uint32(cropRight))
+		w.WriteUE(0)
+		w.WriteUE(uint32(cropBottom))
+	} else {
+		w.WriteBit(0)
+	}
+	w.WriteBit(0) // vui_parameters_present_flag
+	w.TrailingBits()
+
+	return clip.NALU(naluTypeSPS, w.Bytes())
+}
+
+func pps() []byte {
+	w := &clip.Writer{}
+	w.WriteUE(0)  // pic_parameter_set_id
+	w.WriteUE(0)  // seq_parameter_set_id
+	w.WriteBit(0) // entropy_coding_mode_flag
+	w.WriteBit(0) // bottom_field_pic_order_in_frame_present_flag
+	w.WriteUE(0)  // num_slice_groups_minus1
+	w.WriteUE(0)  // num_ref_idx_l0_default_active_minus1
+	w.WriteUE(0)  // num_ref_idx_l1_default_active_minus1
+	w.WriteBit(0) // weighted_pred_flag
+	w.WriteBits(0, 2)
+	w.WriteSE(qp - 26) // pic_init_qp_minus26
+	w.WriteSE(0)       // pic_init_qs_minus26
+	w.WriteSE(0)       // chroma_qp_index_offset
+	w.WriteBit(1)      // deblocking_filter_control_present_flag
+	w.WriteBit(0)      // constrained_intra_pred_flag
+	w.WriteBit(0)      // redundant_pic_cnt_present_flag
+	w.TrailingBits()
+
+	return clip.NALU(naluTypePPS, w.Bytes())
+}
+
+// writeLevel writes the level of the only coefficient of a block that is
+// not a trailing one, with suffixLength 0.
+func writeLevel(w *clip.Writer, level int) {
+	levelCode := 2*level - 2
+	if level < 0 {
+		levelCode = -2*level - 1
+	}
+	// The first level after fewer than three trailing ones is coded 2 less.
+	levelCode -= 2
+
+	switch {
+	case levelCode < 14:
+		w.WriteBits(1, levelCode+1)
+	case levelCode < 30:
+		w.WriteBits(1, 15)
+		w.WriteBits(uint32(levelCode-14), 4)
+	default:
+		w.WriteBits(1, 16)
+		w.WriteBits(uint32(levelCode-30), 12)
+	}
+}
+
+// writeDCBlock writes a residual block whose only coefficient is the DC
+// level. Every block has at most one coefficient, so nC is always below 2.
+func writeDCBlock(w *clip.Writer, level int, chroma bool) {
+	switch {
+	case level == 0 && chroma:
+		w.WriteBits(0b01, 2)
+		return
+	case level == 0:
+		w.WriteBits(0b1, 1)
+		return
+	case (level == 1 || level == -1) && chroma:
+		w.WriteBits(0b1, 1)
+	case level == 1 || level == -1:
+		w.WriteBits(0b01, 2)
+	case chroma:
+		w.WriteBits(0b000111, 6)
+	default:
+		w.WriteBits(0b000101, 6)
+	}
+
+	if level == 1 || level == -1 {
+		w.WriteBit(uint32(level>>1) & 1) // trailing_ones_sign_flag
+	} else {
+		writeLevel(w, level)
+	}
+	w.WriteBit(1) // total_zeros: 0
+}
+
+// encoder codes the macroblocks of a picture as intra slice data. Every
+// 4x4 luma block and 8x8 chroma block must be flat: the residual is then a
+// single DC coefficient that reconstructs it exactly, so the source doubles
+// as the reconstruction the predictions are made from. With at most one
+// coefficient per block, nC stays below 2 whatever the neighbors are.
+type encoder struct {
+	p *picture
+	w *clip.Writer
+}
+
+// encodeRows returns the data of each macroblock row of p from column from
+// on. The columns before it only serve as the left neighbors.
+func encodeRows(p *picture, from int) ([]clip.Bits, error) {
+	e := &encoder{p: p}
+	rows := make([]clip.Bits, p.mbHeight)
+	for mbY := range p.mbHeight {
+		row := &clip.Writer{}
+		for mbX := range p.mbWidth {
+			e.w = row
+			if mbX < from {
+				e.w = &clip.Writer{}
+			}
+			if err := e.macroblock(mbX, mbY); err != nil {
+				return nil, fmt.Errorf("macroblock %d,%d: %w", mbX, mbY, err)
+			}
+		}
+		rows[mbY] = row.Bits()
+	}
+
+	return rows, nil
+}
+
+// encodeSlice returns the data of all macroblocks of p.
+func encodeSlice(p *picture) (clip.Bits, error) {
+	rows, err := encodeRows(p, 0)
+	if err != nil {
+		return clip.Bits{}, err
+	}
+
+	w := &clip.Writer{}
+	for _, row := range rows {
+		w.Write(row)
+	}
+
+	return w.Bits(), nil
+}
+
+// flatLuma returns the value of the w x h luma block at x, y if all its
+// samples are equal.
+func (e *encoder) flatLuma(x, y, w, h int) (int, bool) {
+	v := e.p.luma(x, y)
+	for j := range h {
+		for i := range w {
+			if e.p.luma(x+i, y+j) != v {
+				return 0, false
+			}
+		}
+	}
+
+	return v, true
+}
+
+func (e *encoder) flatChroma(plane []byte, mbX, mbY int) (int, bool) {
+	v := e.p.chroma(plane, mbX*8, mbY*8)
+	for j := range 8 {
+		for i := range 8 {
+			if e.p.chroma(plane, mbX*8+i, mbY*8+j) != v {
+				return 0, false
+			}
+		}
+	}
+
+	return v, true
+}
+
+// chromaPrediction picks the chroma prediction of a macroblock, preferring
+// one that leaves no residual. The neighbors are flat, so any mode predicts
+// a flat block.
+func (e *encoder) chromaPrediction(mbX, mbY int) (mode uint32, residual [2]int, err error) {
+	var values, above, left [2]int
+	for i, plane := range [][]byte{e.p.cb, e.p.cr} {
+		v, ok := e.flatChroma(plane, mbX, mbY)
+		if !ok {
+			return 0, residual, fmt.Errorf("chroma is not flat")
+		}
+		values[i] = v
+		if mbY > 0 {
+			above[i] = e.p.chroma(plane, mbX*8, mbY*8-1)
+		}
+		if mbX > 0 {
+			left[i] = e.p.chroma(plane, mbX*8-1, mbY*8)
+		}
+	}
+
+	switch {
+	case mbY > 0 && (above == values || mbX == 0):
+		mode = chromaVertical
+		residual = [2]int{values[0] - above[0], values[1] - above[1]}
+	case mbX > 0:
+		mode = chromaHorizontal
+		residual = [2]int{values[0] - left[0], values[1] - left[1]}
+	default:
+		mode = chromaDC
+		residual = [2]int{values[0] - 128, values[1] - 128}
+	}
+
+	return mode, residual, nil
+}
+
+// intra16x16 returns a 16x16 prediction that matches the macroblock
+// exactly, if there is one.
+func (e *encoder) intra16x16(mbX, mbY int) (uint32, bool) {
+	x, y := mbX*16, mbY*16
+	v, ok := e.flatLuma(x, y, 16, 16)
+	if !ok {
+		return 0, false
+	}
+
+	if mbY > 0 {
+		if above, ok := e.flatLuma(x, y-1, 16, 1); ok && above == v {
+			return intra16x16Vertical, true
+		}
+	}
+	if mbX > 0 {
+		if left, ok := e.flatLuma(x-1, y, 1, 16); ok && left == v {
+			return intra16x16Horizontal, true
+		}
+	}
+
+	return 0, false
+}
+
+// dcPrediction returns the Intra_4x4_DC prediction of the block at x, y.
+func (e *encoder) dcPrediction(x, y int) int {
+	var top, left int
+	for i := range 4 {
+		if y > 0 {
+			top += e.p.luma(x+i, y-1)
+		}
+		if x > 0 {
+			left += e.p.luma(x-1, y+i)
+		}
+	}
+
+	switch {
+	case x > 0 && y > 0:
+		return (top + left + 4) >> 3
+	case x > 0:
+		return (left + 2) >> 2
+	case y > 0:
+		return (top + 2) >> 2
+	}
+
+	return 128
+}
+
+func (e *encoder) macroblock(mbX, mbY int) error {
+	chromaMode, chromaResidual, err := e.chromaPrediction(mbX, mbY)
+	if err != nil {
+		return err
+	}
+	cbpChroma := 0
+	if chromaResidual != [2]int{} {
+		cbpChroma = 1
+	}
+
+	w := e.w
+	if mode, ok := e.intra16x16(mbX, mbY); ok {
+		w.WriteUE(1 + mode + 4*uint32(cbpChroma)) // mb_type: I_16x16 without AC
+		w.WriteUE(chromaMode)
+		w.WriteSE(0)              // mb_qp_delta
+		writeDCBlock(w, 0, false) // Intra16x16DCLevel
+		e.chromaDC(chromaResidual, cbpChroma)
+		return nil
+	}
+
+	// Blocks in decoding order: 8x8 blocks in raster order, and the 4x4
+	// blocks of each in raster order.
+	var residual [16]int
+	coded := false
+	for blk := range 16 {
+		x := mbX*16 + (blk/4%2)*8 + (blk%2)*4
+		y := mbY*16 + (blk/8)*8 + (blk%4/2)*4
+		v, ok := e.flatLuma(x, y, 4, 4)
+		if !ok {
+			return fmt.Errorf("4x4 block %d is not flat", blk)
+		}
+		residual[blk] = v - e.dcPrediction(x, y)
+		coded = coded || residual[blk] != 0
+	}
+
+	cbp := 0
+	switch {
+	case cbpChroma > 0:
+		cbp = 31
+	case coded:
+		cbp = 15
+	}
+
+	w.WriteUE(0) // mb_type: I_NxN
+	for range 16 {
+		w.WriteBit(1) // prev_intra4x4_pred_mode_flag: DC, as predicted
+	}
+	w.WriteUE(chromaMode)
+	w.WriteUE(cbpCodeNum[cbp])
+	if cbp > 0 {
+		w.WriteSE(0) // mb_qp_delta
+		for _, level := range residual {
+			writeDCBlock(w, level, false)
+		}
+	}
+	e.chromaDC(chromaResidual, cbpChroma)
+
+	return nil
+}
+
+// chromaDC writes the chroma DC levels. With all four blocks at the same
+// level c, each sample is reconstructed with (c + 1) >> 1.
+func (e *encoder) chromaDC(residual [2]int, cbpChroma int) {
+	if cbpChroma == 0 {
+		return
+	}
+
+	for _, r := range residual {
+		writeDCBlock(e.w, 2*r, true)
+	}
+}
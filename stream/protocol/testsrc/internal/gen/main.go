@@ -0,0 +1,135 @@
+// Command gen encodes the slices of the testsrc pattern into the clips
+// directory. Run it through go generate in the testsrc package.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/jaesung9507/playgo/stream/protocol/testsrc/internal/clip"
+)
+
+var colorBeep = yuv{180, 128, 128}
+
+// layout places the band at the bottom of the picture, leaving one
+// macroblock row below it.
+func layout(width, height int) (c *clip.Clip, scale int) {
+	scale = 4
+	if height >= 720 {
+		scale = 8
+	}
+
+	c = &clip.Clip{
+		Width:     width,
+		Height:    height,
+		BandRows:  (7*scale + 4 + 15) / 16,
+		LeadWidth: (4*scale + 8 + 15) / 16,
+		CellWidth: (5*scale + 5 + 15) / 16,
+	}
+	c.BandRow = c.MBHeight() - c.BandRows - 1
+	c.Cells = min(19, (c.MBWidth()-c.LeadWidth)/c.CellWidth)
+
+	return c, scale
+}
+
+// encodePiece encodes a piece of the band after a background column.
+func encodePiece(mbWidth, mbHeight int, draw func(p *picture, x int)) ([]clip.Bits, error) {
+	p := newPicture(1+mbWidth, mbHeight, colorBackground)
+	draw(p, 16)
+
+	return encodeRows(p, 1)
+}
+
+func generate(width, height int) (*clip.Clip, error) {
+	c, scale := layout(width, height)
+	mbWidth := c.MBWidth()
+
+	top := newPicture(mbWidth, c.BandRow, colorBackground)
+	barsHeight := min(height*2/3/16, c.BandRow) * 16
+	for i, color := range bars {
+		top.fill(i*mbWidth/8*16, 0, (i+1)*mbWidth/8*16, barsHeight, color)
+	}
+
+	var err error
+	if c.Top, err = encodeSlice(top); err != nil {
+		return nil, fmt.Errorf("top: %w", err)
+	}
+
+	bottom := newPicture(mbWidth, c.MBHeight()-c.BandRow-c.BandRows, colorBackground)
+	if c.Bottom, err = encodeSlice(bottom); err != nil {
+		return nil, fmt.Errorf("bottom: %w", err)
+	}
+
+	// The lead starts the rows of the band, with nothing on its left.
+	for i := range c.Lead {
+		lead := newPicture(c.LeadWidth, c.BandRows, colorBackground)
+		if i == 1 {
+			lead.fill(4, 4, 4+4*scale, 4+7*scale, colorBeep)
+		}
+		if c.Lead[i], err = encodeRows(lead, 0); err != nil {
+			return nil, fmt.Errorf("lead: %w", err)
+		}
+	}
+
+	for _, r := range clip.Glyphs {
+		rows, err := encodePiece(c.CellWidth, c.BandRows, func(p *picture, x int) {
+			p.glyph(x+4, 4, scale, r, colorText)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("glyph %q: %w", r, err)
+		}
+		c.Glyph = append(c.Glyph, rows)
+	}
+
+	fillerWidth := mbWidth - c.LeadWidth - c.Cells*c.CellWidth
+	if c.Filler, err = encodePiece(fillerWidth, c.BandRows, func(*picture, int) {}); err != nil {
+		return nil, fmt.Errorf("filler: %w", err)
+	}
+
+	c.SPS, c.PPS = sps(width, height), pps()
+
+	return c, nil
+}
+
+func run(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for name, size := range clip.Presets {
+		c, err := generate(size[0], size[1])
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		data, err := c.Marshal()
+		if err != nil {
+			return err
+		}
+
+		if err = os.WriteFile(filepath.Join(dir, name+".gob"), data, 0o644); err != nil {
+			return err
+		}
+	}
+
+	for name, tone := range map[string]bool{"silence.aac": false, "tone.aac": true} {
+		data, err := adts(audioFrame(tone))
+		if err != nil {
+			return err
+		}
+
+		if err = os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func main() {
+	if err := run("clips"); err != nil {
+		log.Fatal(err)
+	}
+}
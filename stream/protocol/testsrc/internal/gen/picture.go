@@ -0,0 +1,99 @@
+package main
+
+type yuv struct {
+	y, cb, cr byte
+}
+
+// bars are the 75% color bars in limited range BT.601.
+var bars = []yuv{
+	{180, 128, 128}, // white
+	{162, 44, 142},  // yellow
+	{131, 156, 44},  // cyan
+	{112, 72, 58},   // green
+	{84, 184, 198},  // magenta
+	{65, 100, 212},  // red
+	{35, 212, 114},  // blue
+	{16, 128, 128},  // black
+}
+
+var (
+	colorBackground = yuv{40, 128, 128}
+	colorText       = yuv{235, 128, 128}
+)
+
+// font is a 5x7 bitmap font for the counter, one row per byte with the
+// leftmost pixel in bit 4.
+var font = map[rune][7]byte{
+	'0': {0x0e, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0e},
+	'1': {0x04, 0x0c, 0x04, 0x04, 0x04, 0x04, 0x0e},
+	'2': {0x0e, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1f},
+	'3': {0x1f, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0e},
+	'4': {0x02, 0x06, 0x0a, 0x12, 0x1f, 0x02, 0x02},
+	'5': {0x1f, 0x10, 0x1e, 0x01, 0x01, 0x11, 0x0e},
+	'6': {0x06, 0x08, 0x10, 0x1e, 0x11, 0x11, 0x0e},
+	'7': {0x1f, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0e, 0x11, 0x11, 0x0e, 0x11, 0x11, 0x0e},
+	'9': {0x0e, 0x11, 0x11, 0x0f, 0x01, 0x02, 0x0c},
+	':': {0x00, 0x0c, 0x0c, 0x00, 0x0c, 0x0c, 0x00},
+	'.': {0x00, 0x00, 0x00, 0x00, 0x00, 0x0c, 0x0c},
+	'#': {0x0a, 0x0a, 0x1f, 0x0a, 0x1f, 0x0a, 0x0a},
+	' ': {},
+}
+
+// picture is a 4:2:0 picture of whole macroblocks.
+type picture struct {
+	mbWidth  int
+	mbHeight int
+	y        []byte
+	cb       []byte
+	cr       []byte
+}
+
+func newPicture(mbWidth, mbHeight int, c yuv) *picture {
+	p := &picture{
+		mbWidth:  mbWidth,
+		mbHeight: mbHeight,
+		y:        make([]byte, mbWidth*mbHeight*256),
+		cb:       make([]byte, mbWidth*mbHeight*64),
+		cr:       make([]byte, mbWidth*mbHeight*64),
+	}
+	p.fill(0, 0, mbWidth*16, mbHeight*16, c)
+
+	return p
+}
+
+func (p *picture) luma(x, y int) int {
+	return int(p.y[y*p.mbWidth*16+x])
+}
+
+func (p *picture) chroma(plane []byte, x, y int) int {
+	return int(plane[y*p.mbWidth*8+x])
+}
+
+func (p *picture) fill(x0, y0, x1, y1 int, c yuv) {
+	stride := p.mbWidth * 16
+	x0, y0 = max(x0, 0), max(y0, 0)
+	x1, y1 = min(x1, stride), min(y1, p.mbHeight*16)
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			p.y[y*stride+x] = c.y
+		}
+	}
+
+	for y := y0 / 2; y < (y1+1)/2; y++ {
+		for x := x0 / 2; x < (x1+1)/2; x++ {
+			p.cb[y*stride/2+x] = c.cb
+			p.cr[y*stride/2+x] = c.cr
+		}
+	}
+}
+
+func (p *picture) glyph(x, y, scale int, r rune, c yuv) {
+	for row, bits := range font[r] {
+		for col := range 5 {
+			if bits&(0x10>>col) != 0 {
+				p.fill(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale, c)
+			}
+		}
+	}
+}
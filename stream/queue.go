@@ -0,0 +1,335 @@
+package stream
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+const DefaultQueueCapacity = 128
+
+type QueuePolicy int
+
+const (
+	// QueueBlock makes Push wait until the consumer catches up.
+	QueueBlock QueuePolicy = iota
+	// QueueDropOldest discards the oldest queued packet to make room.
+	QueueDropOldest
+	// QueueDropUntilKeyFrame flushes the queue and discards packets until the
+	// next keyframe, so that decoding resumes cleanly at the live position.
+	QueueDropUntilKeyFrame
+)
+
+func (p QueuePolicy) String() string {
+	switch p {
+	case QueueBlock:
+		return "block"
+	case QueueDropOldest:
+		return "drop-oldest"
+	case QueueDropUntilKeyFrame:
+		return "drop-until-keyframe"
+	}
+
+	return "unknown"
+}
+
+type QueueStats struct {
+	Capacity int
+	Depth    int
+	MaxDepth int
+	Pushed   uint64
+	Dropped  uint64
+	Policy   string
+}
+
+// PacketQueue is a bounded queue between a client's network or demuxer
+// goroutine and the consumer of PacketQueue().
+type PacketQueue struct {
+	ch     chan *Packet
+	done   chan struct{}
+	once   sync.Once
+	policy atomic.Int32
+	// send is held for reading by Push, so that Finish does not close ch
+	// under a blocked send.
+	send sync.RWMutex
+
+	// videoTrack is the index of the track whose keyframes the queue resumes
+	// at, or -1 to accept a keyframe of any track.
+	videoTrack atomic.Int32
+
+	mu            sync.Mutex
+	keyTracks     map[int8]bool
+	waitingKey    bool
+	skipRequested atomic.Bool
+	finished      bool
+	normalizer    *Normalizer
+	passThrough   bool
+	tap           atomic.Pointer[func(Packet)]
+
+	maxDepth atomic.Int64
+	pushed   atomic.Uint64
+	dropped  atomic.Uint64
+}
+
+func NewPacketQueue(capacity int, policy QueuePolicy) *PacketQueue {
+	q := &PacketQueue{
+		ch:         make(chan *Packet, max(capacity, 1)),
+		done:       make(chan struct{}),
+		keyTracks:  make(map[int8]bool),
+		normalizer: NewNormalizer(),
+	}
+	q.policy.Store(int32(policy))
+	q.videoTrack.Store(-1)
+
+	return q
+}
+
+func (q *PacketQueue) SetPolicy(policy QueuePolicy) {
+	q.policy.Store(int32(policy))
+}
+
+// SetTap installs a function that sees a copy of every pushed packet before
+// its timestamps are normalized, e.g. to capture a trace. It runs on the
+// producer goroutine but outside the queue lock.
+func (q *PacketQueue) SetTap(tap func(Packet)) {
+	if tap == nil {
+		q.tap.Store(nil)
+		return
+	}
+	q.tap.Store(&tap)
+}
+
+// SetNormalize turns timestamp normalization off for a queue whose packets are
+// normalized further on, as with the clients of a MergedClient.
+func (q *PacketQueue) SetNormalize(enabled bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.passThrough = !enabled
+}
+
+// SetVideoTrack makes the queue resume only at keyframes of track idx, as
+// sources such as fMP4 also flag audio samples as sync samples. Until it is
+// called, or with a negative idx, a keyframe of any track is accepted.
+func (q *PacketQueue) SetVideoTrack(idx int) {
+	q.videoTrack.Store(int32(max(idx, -1)))
+}
+
+// isKeyFrame reports whether decoding can resume at packet.
+func (q *PacketQueue) isKeyFrame(packet *Packet) bool {
+	if !packet.IsKeyFrame {
+		return false
+	}
+
+	videoTrack := q.videoTrack.Load()
+	return videoTrack < 0 || int32(packet.Idx) == videoTrack
+}
+
+func (q *PacketQueue) Policy() QueuePolicy {
+	return QueuePolicy(q.policy.Load())
+}
+
+func (q *PacketQueue) Chan() <-chan *Packet {
+	return q.ch
+}
+
+// Push normalizes the packet timestamps and enqueues it according to the queue
+// policy. It returns false once the queue has been closed, which tells the
+// producer to stop.
+func (q *PacketQueue) Push(packet *Packet) bool {
+	q.send.RLock()
+	defer q.send.RUnlock()
+
+	tap := q.tap.Load()
+	var raw Packet
+	if tap != nil {
+		raw = *packet
+	}
+
+	ok, wait := q.enqueue(packet)
+	if ok && tap != nil {
+		(*tap)(raw)
+	}
+	if !wait {
+		return ok
+	}
+
+	// The queue lock is released while waiting, so that a slow consumer does
+	// not hold up the other users of the queue.
+	select {
+	case q.ch <- packet:
+		q.onPushed()
+		return true
+	case <-q.done:
+		return false
+	}
+}
+
+// enqueue applies the policy under the queue lock. It returns wait when the
+// packet has to be sent with a blocking send.
+func (q *PacketQueue) enqueue(packet *Packet) (ok bool, wait bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return false, false
+	default:
+	}
+
+	if q.finished {
+		return false, false
+	}
+	if !q.passThrough {
+		q.normalizer.Normalize(packet)
+	}
+
+	keyFrame := q.isKeyFrame(packet)
+	if keyFrame {
+		q.keyTracks[packet.Idx] = true
+	}
+
+	if q.skipRequested.CompareAndSwap(true, false) && len(q.keyTracks) > 0 {
+		q.waitingKey = true
+	}
+
+	if q.waitingKey {
+		if keyFrame {
+			q.waitingKey = false
+			log.Printf("[QUEUE] resume at keyframe: dropped=%d", q.dropped.Load())
+		} else {
+			q.dropped.Add(1)
+			return true, false
+		}
+	}
+
+	if q.trySend(packet) {
+		return true, false
+	}
+
+	switch q.Policy() {
+	case QueueDropOldest:
+		q.dropOldestAndSend(packet)
+	case QueueDropUntilKeyFrame:
+		if len(q.keyTracks) == 0 {
+			q.dropOldestAndSend(packet)
+			break
+		}
+
+		q.flush()
+		if keyFrame {
+			q.trySend(packet)
+		} else {
+			q.dropped.Add(1)
+			q.waitingKey = true
+			log.Printf("[QUEUE] overflow: dropping until next keyframe")
+		}
+	default:
+		return true, true
+	}
+
+	return true, false
+}
+
+func (q *PacketQueue) trySend(packet *Packet) bool {
+	select {
+	case q.ch <- packet:
+		q.onPushed()
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *PacketQueue) dropOldestAndSend(packet *Packet) {
+	for !q.trySend(packet) {
+		select {
+		case <-q.ch:
+			q.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+func (q *PacketQueue) flush() {
+	for {
+		select {
+		case <-q.ch:
+			q.dropped.Add(1)
+		default:
+			return
+		}
+	}
+}
+
+func (q *PacketQueue) onPushed() {
+	q.pushed.Add(1)
+	if depth := int64(len(q.ch)); depth > q.maxDepth.Load() {
+		q.maxDepth.Store(depth)
+	}
+}
+
+// SkipToLatestKeyFrame is called by the consumer to jump to the live edge.
+// It drains the queued packets and returns them starting at the most recent
+// keyframe, with current taken as the packet received just before. If no
+// keyframe is queued, everything is dropped and the producer side discards
+// packets until the next keyframe arrives.
+func (q *PacketQueue) SkipToLatestKeyFrame(current *Packet) []*Packet {
+	packets := []*Packet{current}
+	for len(q.ch) > 0 {
+		select {
+		case packet := <-q.ch:
+			packets = append(packets, packet)
+		default:
+		}
+	}
+
+	for i := len(packets) - 1; i >= 0; i-- {
+		if q.isKeyFrame(packets[i]) {
+			q.dropped.Add(uint64(i))
+			return packets[i:]
+		}
+	}
+
+	q.dropped.Add(uint64(len(packets)))
+	q.skipRequested.Store(true)
+
+	return nil
+}
+
+// Finish marks the end of the stream. The channel returned by Chan is closed
+// once the queued packets have been received, so the consumer can flush.
+func (q *PacketQueue) Finish() {
+	q.send.Lock()
+	defer q.send.Unlock()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.finished {
+		q.finished = true
+		close(q.ch)
+	}
+}
+
+// Close unblocks pending and future Push calls. Packets already queued can
+// still be received from Chan.
+func (q *PacketQueue) Close() {
+	q.once.Do(func() {
+		close(q.done)
+	})
+}
+
+func (q *PacketQueue) TimestampStats() TimestampStats {
+	return q.normalizer.Stats()
+}
+
+func (q *PacketQueue) Stats() QueueStats {
+	return QueueStats{
+		Capacity: cap(q.ch),
+		Depth:    len(q.ch),
+		MaxDepth: int(q.maxDepth.Load()),
+		Pushed:   q.pushed.Load(),
+		Dropped:  q.dropped.Load(),
+		Policy:   q.Policy().String(),
+	}
+}
@@ -0,0 +1,180 @@
+package stream_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaesung9507/playgo/stream"
+	"github.com/jaesung9507/playgo/stream/internal/streamtest"
+)
+
+// pattern is a test pattern generated as fast as the queue takes it.
+const pattern = "testsrc://bars?size=240p&fps=10&gop=10&realtime=0&"
+
+// drain reads the queue until it is finished.
+func drain(t *testing.T, q *stream.PacketQueue) []*stream.Packet {
+	t.Helper()
+
+	var packets []*stream.Packet
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case packet, ok := <-q.Chan():
+			if !ok {
+				return packets
+			}
+			packets = append(packets, packet)
+		case <-timeout:
+			t.Fatal("timeout")
+		}
+	}
+}
+
+// waitPushed waits until count packets have been pushed into the queue.
+func waitPushed(t *testing.T, q *stream.PacketQueue, count uint64) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for q.Stats().Pushed < count {
+		if time.Now().After(deadline) {
+			t.Fatalf("pushed %+v, want %d", q.Stats(), count)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitIdle waits until the queue counters stop changing, for producers that
+// drop packets without pushing them.
+func waitIdle(t *testing.T, q *stream.PacketQueue) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for stats := q.Stats(); ; {
+		time.Sleep(50 * time.Millisecond)
+		if next := q.Stats(); next == stats && next.Pushed > 0 {
+			return
+		} else {
+			stats = next
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("queue still busy: %+v", stats)
+		}
+	}
+}
+
+// total is the number of packets of a 5s test pattern: 50 frames and 157
+// AAC frames of 1024 samples at 32 kHz.
+const total = 50 + 157
+
+func TestPacketQueueBlock(t *testing.T) {
+	c, _ := streamtest.Dial(t, pattern+"duration=5s")
+	q := c.PacketQueue()
+
+	// The producer waits for the consumer instead of dropping.
+	waitPushed(t, q, uint64(q.Stats().Capacity))
+	time.Sleep(10 * time.Millisecond)
+	if stats := q.Stats(); stats.Depth != stats.Capacity || stats.Pushed != uint64(stats.Capacity) {
+		t.Errorf("stats = %+v, want a full queue", stats)
+	}
+
+	packets := drain(t, q)
+	if stats := q.Stats(); len(packets) != total || stats.Dropped != 0 || stats.Pushed != total {
+		t.Errorf("got %d packets, stats = %+v", len(packets), stats)
+	}
+}
+
+func TestPacketQueueDropOldest(t *testing.T) {
+	c, _ := streamtest.Dial(t, pattern+"duration=5s")
+	q := c.PacketQueue()
+	q.SetPolicy(stream.QueueDropOldest)
+
+	waitPushed(t, q, total)
+	packets := drain(t, q)
+	stats := q.Stats()
+	if len(packets) != stats.Capacity || stats.Dropped != uint64(total-stats.Capacity) {
+		t.Fatalf("got %d packets, stats = %+v", len(packets), stats)
+	}
+
+	// The newest packets are kept.
+	last := packets[len(packets)-1]
+	if last.Time < 4900*time.Millisecond {
+		t.Errorf("last packet at %v", last.Time)
+	}
+}
+
+func TestPacketQueueDropUntilKeyFrame(t *testing.T) {
+	c, _ := streamtest.Dial(t, pattern+"duration=5s")
+	q := c.PacketQueue()
+	q.SetPolicy(stream.QueueDropUntilKeyFrame)
+
+	waitIdle(t, q)
+	packets := drain(t, q)
+	if len(packets) <= 0 || q.Stats().Dropped <= 0 {
+		t.Fatalf("got %d packets, stats = %+v", len(packets), q.Stats())
+	}
+
+	// After an overflow the queue restarts at a video keyframe.
+	if first := packets[0]; first.Idx != 0 || !first.IsKeyFrame {
+		t.Errorf("first packet = track %d keyframe=%t at %v", first.Idx, first.IsKeyFrame, first.Time)
+	}
+	if uint64(len(packets))+q.Stats().Dropped != total {
+		t.Errorf("got %d packets, stats = %+v", len(packets), q.Stats())
+	}
+}
+
+func TestPacketQueueSkipToLatestKeyFrame(t *testing.T) {
+	c, _ := streamtest.Dial(t, pattern+"duration=5s")
+	q := c.PacketQueue()
+
+	waitPushed(t, q, uint64(q.Stats().Capacity))
+	current := <-q.Chan()
+	packets := q.SkipToLatestKeyFrame(current)
+	if len(packets) <= 0 || !packets[0].IsKeyFrame || packets[0].Idx != 0 {
+		t.Fatalf("skip returned %d packets", len(packets))
+	}
+	if packets[0].Time < time.Second {
+		t.Errorf("skipped to %v, want the latest keyframe", packets[0].Time)
+	}
+
+	rest := drain(t, q)
+	if next := rest[0]; next.Time < packets[len(packets)-1].Time-100*time.Millisecond {
+		t.Errorf("packets after the skip start at %v", next.Time)
+	}
+}
+
+func TestPacketQueueVideoKeyFrame(t *testing.T) {
+	// Like fMP4, audio samples are flagged as keyframes too.
+	video := func(i int) *stream.Packet {
+		return &stream.Packet{Idx: 0, IsKeyFrame: i%10 == 0, Time: time.Duration(i) * 100 * time.Millisecond}
+	}
+	audio := func(i int) *stream.Packet {
+		return &stream.Packet{Idx: 1, IsKeyFrame: true, Time: time.Duration(i) * 100 * time.Millisecond}
+	}
+
+	q := stream.NewPacketQueue(4, stream.QueueDropUntilKeyFrame)
+	q.SetNormalize(false)
+	q.SetVideoTrack(0)
+
+	// The overflow at packet 2 drops everything up to the video keyframe of
+	// packet 10, however many audio keyframes come in between.
+	for i := range 12 {
+		q.Push(video(i))
+		q.Push(audio(i))
+	}
+	if first := <-q.Chan(); first.Idx != 0 || !first.IsKeyFrame || first.Time != time.Second {
+		t.Fatalf("resumed at track %d keyframe=%t at %v", first.Idx, first.IsKeyFrame, first.Time)
+	}
+	for len(q.Chan()) > 0 {
+		<-q.Chan()
+	}
+
+	// The latest keyframe to skip to is the video one, not the audio after it.
+	for i := 20; i < 22; i++ {
+		q.Push(video(i))
+		q.Push(audio(i))
+	}
+	packets := q.SkipToLatestKeyFrame(<-q.Chan())
+	if len(packets) != 4 || packets[0].Idx != 0 || packets[0].Time != 2*time.Second {
+		t.Fatalf("skip returned %d packets", len(packets))
+	}
+}
@@ -1,9 +1,16 @@
 package stream
 
 import (
+	"context"
+	"errors"
+	"reflect"
 	"time"
 )
 
+// ErrUnauthorized is returned by clients when the server requires
+// credentials that the URL does not have or that it rejected.
+var ErrUnauthorized = errors.New("authentication required")
+
 type Codec interface {
 	CodecString() string
 }
@@ -25,11 +32,72 @@ type Client interface {
 	Dial() error
 	Close()
 	CodecData() ([]Codec, error)
-	PacketQueue() <-chan *Packet
+	PacketQueue() *PacketQueue
 	CloseCh() <-chan any
 	Secure() (bool, bool, map[string]string)
 }
 
+// SkippedTrack is a track that a client left out of CodecData because its
+// codec is not supported.
+type SkippedTrack struct {
+	Index int    `json:"index"`
+	Codec string `json:"codec"`
+}
+
+// TrackSkipper is implemented by clients and demuxers that play the supported
+// tracks of a stream and skip the others. Packet.Idx refers to the kept
+// tracks only.
+type TrackSkipper interface {
+	SkippedTracks() []SkippedTrack
+}
+
+// SkippedTracks returns the tracks v skipped, nil if v is not a TrackSkipper.
+func SkippedTracks(v any) []SkippedTrack {
+	if skipper, ok := v.(TrackSkipper); ok {
+		return skipper.SkippedTracks()
+	}
+
+	return nil
+}
+
+// CodecName names a codec of a library for logs and the UI, using its Codec
+// method if it has one and its type name otherwise.
+func CodecName(codec any) string {
+	if named, ok := codec.(interface{ Codec() string }); ok {
+		return named.Codec()
+	}
+
+	t := reflect.TypeOf(codec)
+	if t == nil {
+		return "unknown"
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t.Name()
+}
+
+// Transporter is implemented by clients that can receive a stream over
+// several transports and report the one in use, e.g. "TCP".
+type Transporter interface {
+	Transport() string
+}
+
+// ContextDialer is implemented by clients whose Dial may wait indefinitely,
+// such as a listener waiting for a caller. DialContext returns when ctx is
+// canceled.
+type ContextDialer interface {
+	DialContext(ctx context.Context) error
+}
+
+// StatsReporter is implemented by clients that report statistics of their
+// connection, such as the round-trip time and lost packets, as labeled
+// values for display.
+type StatsReporter interface {
+	ConnStats() map[string]string
+}
+
 func IsCodecReady(codecs []Codec) bool {
 	for _, codec := range codecs {
 		if codec == nil {